@@ -0,0 +1,87 @@
+// Package bulk runs a task on many projects at once
+package bulk
+
+import (
+	"strings"
+	"sync"
+)
+
+// Limit is how many tasks run at once by default. Fetching and pulling
+// wait on the network more than on the machine.
+const Limit = 8
+
+// Status is where a task is at on one project
+type Status int
+
+const (
+	Pending Status = iota
+	Running
+	Done
+	Failed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Done:
+		return "done"
+	case Failed:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// Event reports that the task started or finished on the project at Index
+type Event struct {
+	Index  int
+	Status Status
+	// Output is the summary of a finished task
+	Output string
+	Err    error
+}
+
+// Task runs on the project at path and returns a summary of what it did
+type Task func(path string) (string, error)
+
+// Run runs task on every path, at most limit at a time, and reports the
+// progress on the returned channel, which is closed once every task is
+// done. The channel holds every event, so tasks never wait on the reader.
+func Run(paths []string, limit int, task Task) <-chan Event {
+	if limit < 1 {
+		limit = 1
+	}
+	events := make(chan Event, 2*len(paths))
+	slots := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			events <- Event{Index: i, Status: Running}
+			output, err := task(path)
+			if err != nil {
+				events <- Event{Index: i, Status: Failed, Output: output, Err: err}
+				return
+			}
+			events <- Event{Index: i, Status: Done, Output: output}
+		}(i, path)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+	return events
+}
+
+// LastLine returns the last non-empty line of a command's output, which
+// usually sums up what it did
+func LastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
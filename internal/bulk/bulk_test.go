@@ -0,0 +1,71 @@
+package bulk
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunReportsEveryProject(t *testing.T) {
+	paths := []string{"/a", "/b", "/c", "/d", "/e"}
+	var running, most atomic.Int32
+	events := Run(paths, 2, func(path string) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if path == "/c" {
+			return "", fmt.Errorf("no remote")
+		}
+		return "updated " + path, nil
+	})
+
+	started := make(map[int]bool)
+	finished := make(map[int]Event)
+	for e := range events {
+		switch e.Status {
+		case Running:
+			if _, ok := finished[e.Index]; ok {
+				t.Errorf("project %d started after it finished", e.Index)
+			}
+			started[e.Index] = true
+		case Done, Failed:
+			if !started[e.Index] {
+				t.Errorf("project %d finished before it started", e.Index)
+			}
+			finished[e.Index] = e
+		}
+	}
+
+	if len(finished) != len(paths) {
+		t.Fatalf("expected %d results, got %d", len(paths), len(finished))
+	}
+	if e := finished[2]; e.Status != Failed || e.Err == nil {
+		t.Errorf("expected /c to fail, got %+v", e)
+	}
+	if e := finished[0]; e.Status != Done || e.Output != "updated /a" {
+		t.Errorf("expected /a to be updated, got %+v", e)
+	}
+	if n := most.Load(); n > 2 {
+		t.Errorf("expected at most 2 tasks at once, got %d", n)
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := map[string]string{
+		"":                      "",
+		"Already up to date.\n": "Already up to date.",
+		"Updating 1a2b..3c4d\nFast-forward\n 2 files changed\n\n": "2 files changed",
+	}
+	for output, want := range tests {
+		if got := LastLine(output); got != want {
+			t.Errorf("LastLine(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
@@ -1,137 +1,343 @@
 package cache
 
 import (
-	"den/internal/config"
+	"den/internal/paths"
+	"den/internal/store"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"time"
 )
 
+// scanKey is the store metadata holding the state of the last scan
+const scanKey = "scan"
+
+// ProjectCache is a snapshot of the projects in the store and of the scan
+// that found them
 type ProjectCache struct {
 	Projects     []Project      `json:"projects"`
 	LastUpdated  time.Time      `json:"lastUpdated"`
 	DirectoryMap map[string]int `json:"directoryMap"` // maps directory to number of projects
+	// Roots maps each project directory to its mtime when its entries were
+	// last listed. While it is unchanged no projects were added or removed.
+	Roots map[string]time.Time `json:"roots,omitempty"`
+	// DetectOptions describes the settings the projects were detected with,
+	// projects are detected again when they change
+	DetectOptions string `json:"detectOptions,omitempty"`
 }
 
-type Project struct {
-	Name     string    `json:"name"`
-	Path     string    `json:"path"`
-	LastMod  time.Time `json:"lastMod"`
-	GitState string    `json:"gitState"`
-	Favorite bool      `json:"favorite"`
+// scanState is ProjectCache without the projects, which the store keeps
+// as separate records
+type scanState struct {
+	LastUpdated   time.Time            `json:"lastUpdated"`
+	DirectoryMap  map[string]int       `json:"directoryMap"`
+	Roots         map[string]time.Time `json:"roots,omitempty"`
+	DetectOptions string               `json:"detectOptions,omitempty"`
 }
 
-func GetCachePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+// Project and Stamp are the project records of the store
+type (
+	Project = store.Project
+	Stamp   = store.Stamp
+)
+
+// Each config profile keeps its own store, with its own scan results and
+// history. The functions below take the name of the profile, where "" is
+// the default.
+
+// GetStorePath returns the file of the store of a config profile
+func GetStorePath(profile string) (string, error) {
+	if profile != "" {
+		return paths.StateFile("den-" + profile + ".db")
+	}
+	return paths.StateFile("den.db")
+}
+
+// legacyCachePath returns the JSON cache file used before the store
+func legacyCachePath(profile string) (string, error) {
+	if profile != "" {
+		return paths.CacheFile("projects-" + profile + ".json")
 	}
-	return filepath.Join(homeDir, ".cache", "den", "projects.json"), nil
+	return paths.CacheFile("projects.json")
 }
 
-func LoadCache() (*ProjectCache, error) {
-	cachePath, err := GetCachePath()
+// newCache returns an empty cache
+func newCache() *ProjectCache {
+	return &ProjectCache{
+		Projects:     []Project{},
+		DirectoryMap: make(map[string]int),
+	}
+}
+
+// Open opens the store of a config profile. A new store imports the
+// legacy JSON cache. Close the store as soon as possible, other den
+// processes wait for it.
+func Open(profile string) (*store.Store, error) {
+	path, err := GetStorePath(profile)
 	if err != nil {
 		return nil, err
 	}
+	_, statErr := os.Stat(path)
 
-	data, err := os.ReadFile(cachePath)
+	s, err := store.Open(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return &ProjectCache{
-				Projects:     []Project{},
-				LastUpdated:  time.Time{},
-				DirectoryMap: make(map[string]int),
-			}, nil
+		return s, err
+	}
+	if os.IsNotExist(statErr) {
+		if err := importLegacyCache(s, profile); err != nil {
+			// Try again next time
+			s.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("could not import legacy cache: %v", err)
 		}
-		return nil, err
 	}
+	return s, nil
+}
 
-	var cache ProjectCache
-	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, err
+// openForWrite is Open, rebuilding a store that can't be read: one of
+// another schema is reset, and a corrupt file is moved aside
+func openForWrite(profile string) (*store.Store, error) {
+	s, err := Open(profile)
+	var schemaErr *store.SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		if err := s.Reset(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return s, nil
+	case store.IsCorrupt(err):
+		path, _ := GetStorePath(profile)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, err
+		}
+		return Open(profile)
 	}
-
-	return &cache, nil
+	return s, err
 }
 
-func SaveCache(cache *ProjectCache) error {
-	cachePath, err := GetCachePath()
+// importLegacyCache copies projects.json into a new store and renames it to
+// projects.json.bak. A file that can't be read is only renamed, the
+// projects are found again by the next scan.
+func importLegacyCache(s *store.Store, profile string) error {
+	legacyPath, err := legacyCachePath(profile)
 	if err != nil {
 		return err
 	}
-
-	// Ensure cache directory exists
-	cacheDir := filepath.Dir(cachePath)
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(cache, "", "    ")
+	data, err := os.ReadFile(legacyPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
 		return err
 	}
 
-	return os.WriteFile(cachePath, data, 0644)
+	var legacy ProjectCache
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		if err := legacy.save(s); err != nil {
+			return err
+		}
+	}
+	return os.Rename(legacyPath, legacyPath+".bak")
 }
 
-func IsCacheValid(cache *ProjectCache, cfg *config.Config) bool {
-	if cache == nil || len(cache.Projects) == 0 {
-		return false
+// LoadCache reads the projects from the store. It always returns a usable
+// cache: a new store gives an empty cache, and an unreadable, corrupt or
+// old-format store gives an empty cache together with an error saying why,
+// so that callers rebuild it instead of failing.
+func LoadCache(profile string) (*ProjectCache, error) {
+	s, err := Open(profile)
+	if err != nil {
+		if s != nil {
+			s.Close()
+		}
+		if store.IsCorrupt(err) {
+			return newCache(), fmt.Errorf("corrupt cache: %v", err)
+		}
+		return newCache(), fmt.Errorf("could not open cache: %v", err)
 	}
+	defer s.Close()
 
-	// Check if directories have changed
-	for _, dir := range cfg.ProjectDirs {
-		if _, err := os.Stat(dir); err != nil {
-			return false
+	cache := newCache()
+	err = s.View(func(tx *store.Tx) error {
+		var state scanState
+		if _, err := tx.Meta(scanKey, &state); err != nil {
+			return err
+		}
+		projects, err := tx.Projects()
+		if err != nil {
+			return err
 		}
+		cache.Projects = projects
+		cache.LastUpdated = state.LastUpdated
+		cache.Roots = state.Roots
+		cache.DetectOptions = state.DetectOptions
+		if state.DirectoryMap != nil {
+			cache.DirectoryMap = state.DirectoryMap
+		}
+		return nil
+	})
+	if err != nil {
+		return newCache(), fmt.Errorf("corrupt cache: %v", err)
 	}
+	return cache, nil
+}
 
-	// Check if cache is too old (e.g., more than 1 hour)
-	if time.Since(cache.LastUpdated) > time.Hour {
-		return false
+// SaveCache replaces the projects in the store in one transaction, so that
+// concurrent den processes never see a partial update. Only changed
+// projects are written. Tags are kept, as scans don't detect them.
+func (c *ProjectCache) SaveCache(profile string) error {
+	s, err := openForWrite(profile)
+	if err != nil {
+		return fmt.Errorf("could not open cache: %v", err)
 	}
+	defer s.Close()
+	return c.save(s)
+}
+
+func (c *ProjectCache) save(s *store.Store) error {
+	return s.Update(func(tx *store.Tx) error {
+		old, err := tx.Projects()
+		if err != nil {
+			return err
+		}
+		tags := make(map[string][]string, len(old))
+		for _, p := range old {
+			tags[p.Path] = p.Tags
+		}
 
-	return true
+		for _, p := range c.Projects {
+			if p.Tags == nil {
+				p.Tags = tags[p.Path]
+			}
+			if err := tx.PutProject(p); err != nil {
+				return err
+			}
+			delete(tags, p.Path)
+		}
+		// What is left are the projects that are gone
+		for path := range tags {
+			if err := tx.DeleteProject(path); err != nil {
+				return err
+			}
+		}
+
+		return tx.SetMeta(scanKey, scanState{
+			LastUpdated:   c.LastUpdated,
+			DirectoryMap:  c.DirectoryMap,
+			Roots:         c.Roots,
+			DetectOptions: c.DetectOptions,
+		})
+	})
 }
 
-func (c *ProjectCache) IsCacheValid(cfg *config.Config) bool {
-	if c == nil || len(c.Projects) == 0 {
-		return false
+// RecordVisit adds a visit of the project at path to the usage history
+func RecordVisit(profile, path string) error {
+	s, err := openForWrite(profile)
+	if err != nil {
+		return fmt.Errorf("could not open cache: %v", err)
 	}
+	defer s.Close()
+	return s.Update(func(tx *store.Tx) error {
+		return tx.RecordVisit(path, time.Now())
+	})
+}
 
-	// Check if directories have changed
-	for _, dir := range cfg.ProjectDirs {
-		if _, err := os.Stat(dir); err != nil {
-			return false
+// Tags returns the tags of the project at path
+func Tags(profile, path string) ([]string, error) {
+	s, err := Open(profile)
+	if err != nil {
+		if s != nil {
+			s.Close()
 		}
+		return nil, fmt.Errorf("could not open cache: %v", err)
 	}
+	defer s.Close()
 
-	// Check if cache is too old (e.g., more than 1 hour)
-	if time.Since(c.LastUpdated) > time.Hour {
-		return false
-	}
+	var tags []string
+	err = s.View(func(tx *store.Tx) error {
+		p, _, err := tx.Project(path)
+		tags = p.Tags
+		return err
+	})
+	return tags, err
+}
 
-	return true
+// AddTag tags the projects at paths, which keep their other tags
+func AddTag(profile string, paths []string, tag string) error {
+	s, err := openForWrite(profile)
+	if err != nil {
+		return fmt.Errorf("could not open cache: %v", err)
+	}
+	defer s.Close()
+	return s.Update(func(tx *store.Tx) error {
+		for _, path := range paths {
+			p, ok, err := tx.Project(path)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("unknown project %s", path)
+			}
+			if slices.Contains(p.Tags, tag) {
+				continue
+			}
+			if err := tx.SetTags(path, append(p.Tags, tag)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (c *ProjectCache) SaveCache() error {
-	cachePath, err := GetCachePath()
+// recentCommandsKey is the store metadata holding the commands last run
+// from the command palette, most recent first
+const recentCommandsKey = "recentCommands"
+
+// recentCommandsLimit bounds the recent commands
+const recentCommandsLimit = 20
+
+// RecentCommands returns the commands last run from the command palette,
+// most recent first
+func RecentCommands(profile string) ([]string, error) {
+	s, err := Open(profile)
 	if err != nil {
-		return err
+		if s != nil {
+			s.Close()
+		}
+		return nil, fmt.Errorf("could not open cache: %v", err)
 	}
+	defer s.Close()
 
-	// Ensure cache directory exists
-	cacheDir := filepath.Dir(cachePath)
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+	var recent []string
+	err = s.View(func(tx *store.Tx) error {
+		_, err := tx.Meta(recentCommandsKey, &recent)
 		return err
-	}
+	})
+	return recent, err
+}
 
-	data, err := json.MarshalIndent(c, "", "    ")
+// RecordCommand moves a command to the front of the recent commands
+func RecordCommand(profile, id string) error {
+	s, err := openForWrite(profile)
 	if err != nil {
-		return err
+		return fmt.Errorf("could not open cache: %v", err)
 	}
-
-	return os.WriteFile(cachePath, data, 0644)
+	defer s.Close()
+	return s.Update(func(tx *store.Tx) error {
+		var recent []string
+		if _, err := tx.Meta(recentCommandsKey, &recent); err != nil {
+			// Start over rather than keep failing
+			recent = nil
+		}
+		updated := []string{id}
+		for _, r := range recent {
+			if r != id && len(updated) < recentCommandsLimit {
+				updated = append(updated, r)
+			}
+		}
+		return tx.SetMeta(recentCommandsKey, updated)
+	})
 }
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"den/internal/store"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveCacheRoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	saved := &ProjectCache{
+		Projects: []Project{
+			{Name: "den", Path: "/home/me/code/den", Favorite: true},
+			{Name: "old", Path: "/home/me/code/old"},
+		},
+		LastUpdated:  time.Now(),
+		DirectoryMap: map[string]int{"/home/me/code": 2},
+	}
+	if err := saved.SaveCache(""); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+
+	loaded, err := LoadCache("")
+	if err != nil {
+		t.Fatalf("LoadCache failed: %v", err)
+	}
+	if len(loaded.Projects) != 2 || !loaded.Projects[0].Favorite {
+		t.Errorf("unexpected cache after round trip: %+v", loaded)
+	}
+	if loaded.DirectoryMap["/home/me/code"] != 2 {
+		t.Errorf("DirectoryMap was not kept: %v", loaded.DirectoryMap)
+	}
+
+	// Tags survive a rescan, which doesn't know about them
+	s, err := Open("")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := s.Update(func(tx *store.Tx) error {
+		return tx.SetTags("/home/me/code/den", []string{"work"})
+	}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	s.Close()
+
+	rescanned := &ProjectCache{Projects: []Project{{Name: "den", Path: "/home/me/code/den"}}}
+	if err := rescanned.SaveCache(""); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+	loaded, _ = LoadCache("")
+	if len(loaded.Projects) != 1 {
+		t.Fatalf("expected the removed project to be dropped, got %+v", loaded.Projects)
+	}
+	if tags := loaded.Projects[0].Tags; len(tags) != 1 || tags[0] != "work" {
+		t.Errorf("expected tags to be kept, got %v", tags)
+	}
+}
+
+func TestOpenImportsLegacyCache(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	legacyPath, err := legacyCachePath("")
+	if err != nil {
+		t.Fatalf("legacyCachePath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+		t.Fatalf("Failed to create cache dir: %v", err)
+	}
+	legacy := `{"version": 1, "projects": [{"name": "den", "path": "/srv/den", "favorite": true}], "lastUpdated": "2024-01-01T00:00:00Z", "detectOptions": "git=true style=default"}`
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy cache: %v", err)
+	}
+
+	loaded, err := LoadCache("")
+	if err != nil {
+		t.Fatalf("LoadCache failed: %v", err)
+	}
+	if len(loaded.Projects) != 1 || loaded.Projects[0].Path != "/srv/den" || !loaded.Projects[0].Favorite {
+		t.Errorf("legacy projects were not imported: %+v", loaded.Projects)
+	}
+	if loaded.DetectOptions != "git=true style=default" || loaded.LastUpdated.Year() != 2024 {
+		t.Errorf("legacy scan state was not imported: %+v", loaded)
+	}
+	if _, err := os.Stat(legacyPath + ".bak"); err != nil {
+		t.Errorf("expected the legacy cache to be renamed: %v", err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("expected the legacy cache to be gone, got %v", err)
+	}
+}
+
+func TestCorruptStoreIsRebuilt(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	path, err := GetStorePath("")
+	if err != nil {
+		t.Fatalf("GetStorePath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("not a store, but long enough to hold a page header"), 0644); err != nil {
+		t.Fatalf("Failed to write store: %v", err)
+	}
+
+	loaded, err := LoadCache("")
+	if err == nil {
+		t.Error("expected an error describing the bad cache")
+	}
+	if loaded == nil || len(loaded.Projects) != 0 || !loaded.LastUpdated.IsZero() {
+		t.Errorf("expected an empty cache to rebuild, got %+v", loaded)
+	}
+
+	rebuilt := &ProjectCache{Projects: []Project{{Name: "den", Path: "/srv/den"}}}
+	if err := rebuilt.SaveCache(""); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+	if loaded, err := LoadCache(""); err != nil || len(loaded.Projects) != 1 {
+		t.Errorf("expected the rebuilt cache to load, got %+v, %v", loaded, err)
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Errorf("expected the corrupt store to be kept aside: %v", err)
+	}
+}
+
+func TestRecentCommands(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for _, id := range []string{"a", "b", "a", "c"} {
+		if err := RecordCommand("", id); err != nil {
+			t.Fatalf("RecordCommand failed: %v", err)
+		}
+	}
+	recent, err := RecentCommands("")
+	if err != nil {
+		t.Fatalf("RecentCommands failed: %v", err)
+	}
+	if strings.Join(recent, ",") != "c,a,b" {
+		t.Errorf("expected c,a,b, got %v", recent)
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	saved := &ProjectCache{Projects: []Project{
+		{Name: "api", Path: "/code/api", Tags: []string{"work"}},
+		{Name: "web", Path: "/code/web"},
+	}}
+	if err := saved.SaveCache(""); err != nil {
+		t.Fatalf("SaveCache failed: %v", err)
+	}
+
+	if err := AddTag("", []string{"/code/api", "/code/web"}, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := AddTag("", []string{"/code/web"}, "frontend"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	for path, want := range map[string]string{"/code/api": "work", "/code/web": "work,frontend"} {
+		tags, err := Tags("", path)
+		if err != nil {
+			t.Fatalf("Tags failed: %v", err)
+		}
+		if strings.Join(tags, ",") != want {
+			t.Errorf("expected %s to be tagged %s, got %v", path, want, tags)
+		}
+	}
+
+	if err := AddTag("", []string{"/code/missing"}, "work"); err == nil {
+		t.Error("expected an error for an unknown project")
+	}
+}
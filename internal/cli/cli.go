@@ -3,19 +3,26 @@ package cli
 import (
 	"den/internal/cache"
 	"den/internal/cli/completion"
+	"den/internal/cli/doctor"
 	"den/internal/cli/man"
 	"den/internal/config"
+	"den/internal/daemon"
+	"den/internal/paths"
 	"den/internal/project"
+	"den/internal/runner"
 	"den/internal/theme"
 	"den/internal/tui"
 	"den/internal/ui"
+	"den/internal/watch"
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
+	"time"
 
 	"den/internal/cli/install"
 
-	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -34,6 +41,16 @@ const (
 	resetFlag   = "--reset"
 	debugFlag   = "--debug"
 	installFlag = "--install"
+	configFlag  = "--config"
+	profileFlag = "--profile"
+)
+
+// Commands
+const (
+	doctorCommand = "doctor"
+	daemonCommand = "daemon"
+	tmuxCommand   = "tmux"
+	startCommand  = "start"
 )
 
 type CLI struct {
@@ -47,15 +64,70 @@ func New() *CLI {
 
 // Run executes the CLI application
 func (c *CLI) Run() error {
-	if len(os.Args) > 1 {
-		return c.handleFlags()
+	args, err := c.parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return c.handleFlags(args)
 	}
 	return c.startUI()
 }
 
+// parseGlobalFlags applies flags that can be combined with any command and
+// returns the remaining arguments
+func (c *CLI) parseGlobalFlags(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == configFlag:
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a path", configFlag)
+			}
+			i++
+			if err := c.setConfigFile(args[i]); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, configFlag+"="):
+			if err := c.setConfigFile(strings.TrimPrefix(arg, configFlag+"=")); err != nil {
+				return nil, err
+			}
+		case arg == profileFlag:
+			if i+1 >= len(args) || args[i+1] == "" {
+				return nil, fmt.Errorf("%s requires a profile name", profileFlag)
+			}
+			i++
+			config.SetProfile(args[i])
+		case strings.HasPrefix(arg, profileFlag+"="):
+			name := strings.TrimPrefix(arg, profileFlag+"=")
+			if name == "" {
+				return nil, fmt.Errorf("%s requires a profile name", profileFlag)
+			}
+			config.SetProfile(name)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, nil
+}
+
+// setConfigFile overrides the config file location
+func (c *CLI) setConfigFile(path string) error {
+	if path == "" {
+		return fmt.Errorf("%s requires a path", configFlag)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid config path: %v", err)
+	}
+	paths.SetConfigFile(abs)
+	return nil
+}
+
 // handleFlags processes command line flags
-func (c *CLI) handleFlags() error {
-	switch os.Args[1] {
+func (c *CLI) handleFlags(args []string) error {
+	switch args[0] {
 	case helpFlag, "-h":
 		c.printHelp()
 		return nil
@@ -69,8 +141,19 @@ func (c *CLI) handleFlags() error {
 	case installFlag:
 		c.installMode = true
 		return c.install()
+	case doctorCommand:
+		return doctor.Run(os.Stdout)
+	case daemonCommand:
+		return c.runDaemon(args[1:])
+	case tmuxCommand:
+		return c.runTmux(args[1:])
+	case startCommand:
+		return c.runStart(args[1:])
 	default:
-		fmt.Printf("Unknown flag: %s\n\n", os.Args[1])
+		if plugin, ok := runner.FindPlugin(args[0]); ok {
+			return runPlugin(plugin, args[1:])
+		}
+		fmt.Printf("Unknown flag: %s\n\n", args[0])
 		c.printHelp()
 		return fmt.Errorf("invalid flag")
 	}
@@ -86,13 +169,25 @@ Description:
 
 Usage:
     den [flags]
+    den <command>
+
+Commands:
+    doctor            Check configuration, shell integration, cache and git
+    daemon start      Keep the project index warm in the background
+    daemon stop       Stop the background daemon
+    daemon status     Show whether the daemon is running
+    tmux <project>    Open a project in a tmux session, by name or path
+    start <project>   Run the launch profile of a project
+    <plugin> [args]   Run the den-<plugin> executable from PATH
 
 Flags:
-    -h, --help      Show help information
-    -v, --version   Display version information
-    --reset         Reset all configuration and start fresh
-    --debug         Enable debug logging
-    --install       Install shell completions and man pages
+    -h, --help        Show help information
+    -v, --version     Display version information
+    --reset           Reset all configuration and start fresh
+    --debug           Enable debug logging
+    --install         Install shell completions and man pages
+    --config <path>   Use a different config file
+    --profile <name>  Use a named profile from the config file
 
 Examples:
     # Start Den's interactive UI
@@ -104,9 +199,29 @@ Examples:
     # Enable debug mode
     den --debug
 
+    # Use a separate config file
+    den --config ~/work/den.toml
+
+    # Create or attach the tmux session of a project
+    den tmux api
+
+    # Open the editor, terminal and dev server of a project
+    den start api
+
+    # Use the projects and settings of the "work" profile
+    den --profile work
+
+    # Diagnose configuration problems
+    den doctor
+
 Configuration:
-    Den stores its configuration in ~/.config/den/config.yaml
-    Cache is stored in ~/.cache/den/
+    Den stores its configuration in $XDG_CONFIG_HOME/den/config.toml
+    (~/.config/den/config.toml by default). Set $DEN_CONFIG or pass
+    --config to use another file.
+    Profiles are defined as [profiles.<name>] tables and selected with
+    --profile, $DEN_PROFILE, the profile key or P in the UI.
+    Projects and usage history are stored in $XDG_STATE_HOME/den/den.db
+    (~/.local/state/den/den.db), next to the debug log.
 
 For more information, visit: https://github.com/raidel-a/den
 Report bugs at: https://github.com/raidel-a/den/issues
@@ -126,7 +241,14 @@ func (c *CLI) printVersion() {
 // enableDebugMode sets up debug logging
 func (c *CLI) enableDebugMode() error {
 	c.debugMode = true
-	f, err := tea.LogToFile("debug.log", "debug")
+	logPath, err := paths.StateFile("debug.log")
+	if err != nil {
+		return fmt.Errorf("failed to setup logging: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return fmt.Errorf("failed to setup logging: %v", err)
+	}
+	f, err := tea.LogToFile(logPath, "debug")
 	if err != nil {
 		return fmt.Errorf("failed to setup logging: %v", err)
 	}
@@ -225,47 +347,43 @@ func (c *CLI) startUI() error {
 
 	// Initialize empty project list for first run
 	var projects []project.Project
+	var lastRefreshed time.Time
 
 	// Create themed delegate
 	delegate := ui.CreateThemedDelegate(activeTheme)
 
 	// Create key bindings
-	keyMap := tui.DefaultKeyMap()
+	keyMap := tui.NewKeyMap(cfg)
 
 	// Initialize list with empty items (height will be set by WindowSizeMsg)
 	projectList := list.New([]list.Item{}, delegate, 0, 0)
 	projectList.SetShowTitle(false) // We'll render a custom title in the view
 	projectList.Styles.Title = styles.ListTitle
-	projectList.AdditionalFullHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			keyMap.AddDirectory,
-			keyMap.ShowContext,
-			keyMap.OpenConfig,
-		}
-	}
+	// The help overlay replaces the list's full help
+	projectList.KeyMap.ShowFullHelp = keyMap.Help
 	projectList.SetShowHelp(true)
 	projectList.SetFilteringEnabled(true)
 	projectList.SetShowFilter(true)
+	projectList.KeyMap.Filter = keyMap.Filter
 	projectList.KeyMap.Filter.SetEnabled(true)
 	projectList.KeyMap.ShowFullHelp.SetEnabled(true)
 	projectList.KeyMap.CancelWhileFiltering.SetEnabled(true)
 	projectList.KeyMap.AcceptWhileFiltering.SetEnabled(true)
 
 	if !isFirstRun {
-		// Only try to load cache and scan for projects if we have configured directories
-		projectCache, err := cache.LoadCache()
+		// Show cached projects right away, from a running daemon, which
+		// has the freshest index, or else from the cache. The model
+		// refreshes them in the background once it starts.
+		projectCache, err := daemon.Index(cfg.ActiveProfile(), false)
+		if err != nil {
+			projectCache, err = cache.LoadCache(cfg.ActiveProfile())
+		}
 		if err != nil && c.debugMode {
 			fmt.Printf("Error loading cache: %v\n", err)
 		}
-
-		if projectCache != nil && projectCache.IsCacheValid(cfg) {
+		if projectCache != nil {
 			projects = project.ConvertCacheToProjects(projectCache.Projects)
-			if c.debugMode {
-				fmt.Printf("Using cached projects (%d items)\n", len(projects))
-			}
-		} else {
-			projects = project.ScanForProjects(cfg.ProjectDirs, cfg)
-			// Update cache logic...
+			lastRefreshed = projectCache.LastUpdated
 		}
 
 		items := make([]list.Item, len(projects))
@@ -281,12 +399,34 @@ func (c *CLI) startUI() error {
 		List:          projectList,
 		Projects:      projects,
 		TabState:      nil,
-		ShowContext:   false,
-		ContextCursor: 0,
-		AddingDir:     isFirstRun, // Set to true for first run
-		InputMode:     isFirstRun, // Set to true for first run
 		Styles:        styles,
-		KeyMap:        tui.DefaultKeyMap(),
+		KeyMap:        keyMap,
+		Spinner:       tui.NewSpinner(),
+		Status:        tui.NewStatusBar(),
+		LastRefreshed: lastRefreshed,
+	}
+	if isFirstRun {
+		// Ask for a project directory before anything else
+		model.Overlay = &tui.AddDirState{Required: true}
+	}
+
+	// Keep the list up to date while den is open; without inotify the
+	// list is still refreshed on startup
+	if watcher, err := watch.New(watch.DefaultLimit()); err == nil {
+		model.Watcher = watcher
+		defer watcher.Close()
+	} else if c.debugMode {
+		fmt.Printf("Error starting watcher: %v\n", err)
+	}
+
+	// Reload the config when it is edited outside of den
+	if configPath, err := config.GetConfigPath(); err == nil {
+		if configWatcher, err := watch.NewFile(configPath); err == nil {
+			model.ConfigWatcher = configWatcher
+			defer configWatcher.Close()
+		} else if c.debugMode {
+			fmt.Printf("Error watching config: %v\n", err)
+		}
 	}
 
 	p := tea.NewProgram(model)
@@ -18,6 +18,10 @@ _den() {
         'version:Show version information'
         'reset:Reset configuration'
         'debug:Enable debug mode'
+        'doctor:Check configuration and environment'
+        'daemon:Manage the background daemon'
+        'tmux:Open a project in a tmux session'
+        'start:Run the launch profile of a project'
     )
 
     _arguments -C \
@@ -25,11 +29,19 @@ _den() {
         '--version[Show version information]' \
         '--reset[Reset configuration]' \
         '--debug[Enable debug mode]' \
+        '--config[Use a different config file]:config file:_files' \
+        '--profile[Use a named profile]:profile name:' \
         '*:: :->args'
 
     case $state in
         args)
-            _describe -t commands 'den commands' commands
+            if [[ $words[1] == daemon ]]; then
+                _values 'daemon command' start stop status run
+            elif [[ $words[1] == tmux || $words[1] == start ]]; then
+                _files -/
+            else
+                _describe -t commands 'den commands' commands
+            fi
             ;;
     esac
 }
@@ -41,7 +53,22 @@ _den "$@"`
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
-    opts="--help --version --reset --debug"
+    opts="--help --version --reset --debug --config --profile doctor daemon tmux start"
+
+    if [[ ${prev} == --config ]] ; then
+        COMPREPLY=( $(compgen -f -- ${cur}) )
+        return 0
+    fi
+
+    if [[ ${prev} == tmux || ${prev} == start ]] ; then
+        COMPREPLY=( $(compgen -d -- ${cur}) )
+        return 0
+    fi
+
+    if [[ ${prev} == daemon ]] ; then
+        COMPREPLY=( $(compgen -W "start stop status run" -- ${cur}) )
+        return 0
+    fi
 
     if [[ ${cur} == -* ]] ; then
         COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
@@ -53,7 +80,16 @@ complete -F _den den`
 	fishCompletion = `complete -c den -l help -d 'Show help information'
 complete -c den -l version -d 'Show version information'
 complete -c den -l reset -d 'Reset configuration'
-complete -c den -l debug -d 'Enable debug mode'`
+complete -c den -l debug -d 'Enable debug mode'
+complete -c den -l config -r -F -d 'Use a different config file'
+complete -c den -l profile -x -d 'Use a named profile'
+complete -c den -f -n '__fish_use_subcommand' -a doctor -d 'Check configuration and environment'
+complete -c den -f -n '__fish_use_subcommand' -a daemon -d 'Manage the background daemon'
+complete -c den -f -n '__fish_seen_subcommand_from daemon' -a 'start stop status run'
+complete -c den -f -n '__fish_use_subcommand' -a tmux -d 'Open a project in a tmux session'
+complete -c den -n '__fish_seen_subcommand_from tmux' -a '(__fish_complete_directories)'
+complete -c den -f -n '__fish_use_subcommand' -a start -d 'Run the launch profile of a project'
+complete -c den -n '__fish_seen_subcommand_from start' -a '(__fish_complete_directories)'`
 
 	// Shell function for directory changing
 	zshFunction = `
@@ -208,17 +244,66 @@ func installZshCompletions(home string) error {
 	return nil
 }
 
+// IntegrationState describes the shell integration found in a shell's rc file
+type IntegrationState int
+
+const (
+	// IntegrationMissing means the rc file has no den integration
+	IntegrationMissing IntegrationState = iota
+	// IntegrationOutdated means an older version of the integration is installed
+	IntegrationOutdated
+	// IntegrationCurrent means the installed integration matches this version
+	IntegrationCurrent
+)
+
+// IntegrationStatus reports whether the shell integration is installed in the
+// rc file of shell ("Bash", "Zsh" or "Fish") and returns the file checked
+func IntegrationStatus(home, shell string) (string, IntegrationState, error) {
+	var rcPath, function string
+	switch shell {
+	case "Bash":
+		rcPath, function = filepath.Join(home, ".bashrc"), bashFunction
+	case "Zsh":
+		rcPath, function = zshrcPath(home), zshFunction
+	case "Fish":
+		rcPath, function = filepath.Join(home, ".config", "fish", "config.fish"), fishFunction
+	default:
+		return "", IntegrationMissing, fmt.Errorf("unsupported shell: %s", shell)
+	}
+
+	data, err := os.ReadFile(rcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rcPath, IntegrationMissing, nil
+		}
+		return rcPath, IntegrationMissing, err
+	}
+
+	content := string(data)
+	switch {
+	case strings.Contains(content, function):
+		return rcPath, IntegrationCurrent, nil
+	case strings.Contains(content, "Den shell integration"):
+		return rcPath, IntegrationOutdated, nil
+	}
+	return rcPath, IntegrationMissing, nil
+}
+
 func installBashIntegration(home string) error {
 	bashrcPath := filepath.Join(home, ".bashrc")
 	return appendToFileIfNotExists(bashrcPath, bashFunction)
 }
 
 func installZshIntegration(home string) error {
-	zshrcPath := filepath.Join(home, ".config/zsh/.zshrc")
-	if _, err := os.Stat(zshrcPath); os.IsNotExist(err) {
-		zshrcPath = filepath.Join(home, ".zshrc")
+	return appendToFileIfNotExists(zshrcPath(home), zshFunction)
+}
+
+func zshrcPath(home string) string {
+	path := filepath.Join(home, ".config/zsh/.zshrc")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = filepath.Join(home, ".zshrc")
 	}
-	return appendToFileIfNotExists(zshrcPath, zshFunction)
+	return path
 }
 
 func installFishIntegration(home string) error {
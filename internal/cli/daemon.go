@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"den/internal/config"
+	"den/internal/daemon"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// runDaemon handles "den daemon start|stop|status|run"
+func (c *CLI) runDaemon(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: den daemon start|stop|status|run")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	profile := cfg.ActiveProfile()
+
+	switch args[0] {
+	case "start":
+		status, err := daemon.Start(profile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("den daemon started (pid %d)\n", status.PID)
+		return nil
+
+	case "stop":
+		resp, err := daemon.Call(profile, daemon.MethodStop)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("den daemon stopped (pid %d)\n", resp.Status.PID)
+		return nil
+
+	case "status":
+		resp, err := daemon.Call(profile, daemon.MethodStatus)
+		if err != nil {
+			return err
+		}
+		printDaemonStatus(resp.Status)
+		return nil
+
+	case "run":
+		// Runs in the foreground, "start" runs this in the background
+		logger := log.New(os.Stderr, "den daemon: ", log.LstdFlags)
+		return daemon.Serve(cfg, logger)
+
+	default:
+		return fmt.Errorf("unknown daemon command %q, expected start, stop, status or run", args[0])
+	}
+}
+
+func printDaemonStatus(status *daemon.Status) {
+	socket, _ := daemon.SocketPath(status.Profile)
+	fmt.Printf("den daemon is running (pid %d)\n", status.PID)
+	fmt.Printf("  profile:  %s\n", profileName(status.Profile))
+	fmt.Printf("  socket:   %s\n", socket)
+	fmt.Printf("  up:       %s\n", time.Since(status.Started).Round(time.Second))
+	fmt.Printf("  projects: %d, refreshed %s ago\n", status.Projects, time.Since(status.LastUpdated).Round(time.Second))
+	if status.Watching {
+		fmt.Println("  watching for changes")
+	}
+}
+
+// profileName is the display name of a profile
+func profileName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
@@ -0,0 +1,237 @@
+package doctor
+
+import (
+	"den/internal/cache"
+	"den/internal/cli/completion"
+	"den/internal/config"
+	"den/internal/daemon"
+	"den/internal/store"
+	"den/internal/tui"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	sectionStyle = lipgloss.NewStyle().Bold(true)
+	okStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// shells maps shell binary names to the names used by the completion package
+var shells = map[string]string{
+	"bash": "Bash",
+	"zsh":  "Zsh",
+	"fish": "Fish",
+}
+
+// checker prints check results and counts errors
+type checker struct {
+	w       io.Writer
+	errors  int
+	profile string
+}
+
+func (c *checker) section(title string) {
+	fmt.Fprintln(c.w, sectionStyle.Render(title))
+}
+
+func (c *checker) ok(format string, args ...any) {
+	fmt.Fprintf(c.w, "  %s %s\n", okStyle.Render("✓"), fmt.Sprintf(format, args...))
+}
+
+func (c *checker) warn(format string, args ...any) {
+	fmt.Fprintf(c.w, "  %s %s\n", warnStyle.Render("!"), fmt.Sprintf(format, args...))
+}
+
+func (c *checker) fail(format string, args ...any) {
+	c.errors++
+	fmt.Fprintf(c.w, "  %s %s\n", errStyle.Render("✗"), fmt.Sprintf(format, args...))
+}
+
+// Run checks the config, shell integration, cache, daemon and git and prints the
+// results to w. It returns an error if any check failed.
+func Run(w io.Writer) error {
+	c := &checker{w: w}
+
+	c.checkConfig()
+	c.checkShellIntegration()
+	c.checkCache()
+	c.checkDaemon()
+	c.checkGit()
+
+	if c.errors > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", c.errors)
+	}
+	fmt.Fprintln(w, "\nEverything looks good.")
+	return nil
+}
+
+func (c *checker) checkConfig() {
+	path, err := config.GetConfigPath()
+	if err != nil {
+		c.section("Config")
+		c.fail("could not locate config file: %v", err)
+		return
+	}
+	c.section("Config " + path)
+
+	cfg, report := config.ValidateFile(path)
+	if cfg != nil {
+		tui.ValidateKeys(cfg, report)
+		if name := config.SelectedProfile(cfg); name != "" {
+			if err := cfg.UseProfile(name); err != nil {
+				// An unknown profile key is already in the report
+				if name != cfg.Profile {
+					c.fail("%v", err)
+				}
+			} else {
+				c.ok("using profile %s", name)
+				c.profile = name
+			}
+		}
+	}
+
+	for _, p := range report.Problems {
+		if p.Severity == config.SeverityError {
+			c.fail("%s", p.Error())
+		} else {
+			c.warn("%s", p.Error())
+		}
+	}
+	if len(report.Problems) == 0 {
+		c.ok("no problems found")
+	}
+}
+
+func (c *checker) checkShellIntegration() {
+	c.section("Shell integration")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		c.fail("could not get home directory: %v", err)
+		return
+	}
+
+	// Check the login shell, and any other shell that has den installed
+	current := shells[filepath.Base(os.Getenv("SHELL"))]
+	checked := 0
+	for _, name := range []string{"bash", "zsh", "fish"} {
+		shell := shells[name]
+		rcPath, state, err := completion.IntegrationStatus(home, shell)
+		if err != nil {
+			c.fail("%s: could not read %s: %v", name, rcPath, err)
+			continue
+		}
+		switch state {
+		case completion.IntegrationCurrent:
+			c.ok("%s: installed in %s", name, rcPath)
+		case completion.IntegrationOutdated:
+			c.warn("%s: outdated integration in %s, run den --install", name, rcPath)
+		case completion.IntegrationMissing:
+			if shell != current {
+				continue
+			}
+			c.warn("%s: not installed, run den --install to enable changing directories", name)
+		}
+		checked++
+	}
+	if checked == 0 {
+		c.warn("no supported shell found (SHELL=%s)", os.Getenv("SHELL"))
+	}
+}
+
+func (c *checker) checkCache() {
+	storePath, err := cache.GetStorePath(c.profile)
+	if err != nil {
+		c.section("Cache")
+		c.fail("could not locate cache: %v", err)
+		return
+	}
+	c.section("Cache " + storePath)
+
+	projectCache, err := cache.LoadCache(c.profile)
+	if err != nil {
+		c.warn("%v, it will be rebuilt on the next start", err)
+		return
+	}
+	if projectCache.LastUpdated.IsZero() {
+		c.ok("no cache yet")
+		return
+	}
+
+	c.ok("%d projects, updated %s ago", len(projectCache.Projects), time.Since(projectCache.LastUpdated).Round(time.Second))
+
+	missing := 0
+	for _, p := range projectCache.Projects {
+		if _, err := os.Stat(p.Path); err != nil {
+			missing++
+		}
+	}
+	if missing > 0 {
+		c.warn("%d cached projects no longer exist and will be dropped on the next scan", missing)
+	}
+
+	s, err := cache.Open(c.profile)
+	if err != nil {
+		c.warn("could not read languages and history: %v", err)
+		return
+	}
+	defer s.Close()
+	err = s.View(func(tx *store.Tx) error {
+		languages, err := tx.Languages()
+		if err != nil {
+			return err
+		}
+		if len(languages) > 0 {
+			names := make([]string, 0, len(languages))
+			for name, n := range languages {
+				names = append(names, fmt.Sprintf("%s %d", name, n))
+			}
+			sort.Strings(names)
+			c.ok("languages: %s", strings.Join(names, ", "))
+		}
+		c.ok("%d visits in the history", len(tx.History(math.MaxInt)))
+		return nil
+	})
+	if err != nil {
+		c.warn("could not read languages and history: %v", err)
+	}
+}
+
+func (c *checker) checkDaemon() {
+	c.section("Daemon")
+
+	resp, err := daemon.Call(c.profile, daemon.MethodStatus)
+	if err != nil {
+		c.ok("not running, den scans on its own")
+		return
+	}
+	c.ok("running (pid %d), %d projects, refreshed %s ago", resp.Status.PID, resp.Status.Projects, time.Since(resp.Status.LastUpdated).Round(time.Second))
+}
+
+func (c *checker) checkGit() {
+	c.section("Git")
+
+	path, err := exec.LookPath("git")
+	if err != nil {
+		c.fail("git not found in PATH, git status will not be shown")
+		return
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		c.fail("%s --version failed: %v", path, err)
+		return
+	}
+	c.ok("%s (%s)", strings.TrimSpace(string(out)), path)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/daemon"
+	"den/internal/project"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// findProject resolves a project given on the command line: a path, or
+// the name of a known project
+func findProject(cfg *config.Config, query string) (project.Project, error) {
+	if strings.ContainsRune(query, filepath.Separator) || query == "." || query == ".." {
+		path, err := filepath.Abs(query)
+		if err != nil {
+			return project.Project{}, fmt.Errorf("invalid path: %v", err)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return project.Project{}, fmt.Errorf("%s is not a directory", path)
+		}
+		return project.Project{Name: filepath.Base(path), Path: path}, nil
+	}
+
+	// A running daemon has the freshest index
+	index, err := daemon.Index(cfg.ActiveProfile(), false)
+	if err != nil {
+		index, err = cache.LoadCache(cfg.ActiveProfile())
+	}
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	var matches []project.Project
+	for _, p := range project.ConvertCacheToProjects(index.Projects) {
+		if strings.EqualFold(p.Name, query) {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return project.Project{}, fmt.Errorf("no project named %q, run den to scan your project directories", query)
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, p := range matches {
+		paths[i] = "  " + p.Path
+	}
+	return project.Project{}, fmt.Errorf("%d projects are named %q, give a path instead:\n%s",
+		len(matches), query, strings.Join(paths, "\n"))
+}
@@ -12,13 +12,75 @@ const manPageTemplate = `.TH DEN 1 "%s" "den %s" "Den Manual"
 den \- A Cozy Home for Your Repos
 .SH SYNOPSIS
 .B den
+\fBdoctor\fR
+.br
+.B den
+\fBdaemon\fR \fBstart\fR|\fBstop\fR|\fBstatus\fR|\fBrun\fR
+.br
+.B den
+\fBtmux\fR \fIproject\fR
+.br
+.B den
+\fBstart\fR \fIproject\fR
+.br
+.B den
 [\fB\-\-help\fR]
 [\fB\-\-version\fR]
 [\fB\-\-reset\fR]
 [\fB\-\-debug\fR]
+[\fB\-\-config\fR \fIpath\fR]
+[\fB\-\-profile\fR \fIname\fR]
 .SH DESCRIPTION
 .B den
 is a terminal-based repository manager that provides a comfortable interface for managing and navigating your Git repositories.
+Press \fB?\fR in the interface for every key binding, including those remapped in the \fB[keys]\fR table.
+.SH COMMANDS
+.TP
+.B doctor
+Check the config file for errors (with file and line), shell integration, cache health and git availability.
+.TP
+.B daemon start
+Start a background daemon that keeps the project index in memory, watches for changes and detects every project again every two minutes. The UI and other commands ask it for projects over a unix socket and scan on their own when it is not running. Each profile has its own daemon.
+.TP
+.B daemon stop
+Stop the daemon.
+.TP
+.B daemon status
+Show the daemon's pid, profile and number of projects. Fails if it is not running.
+.TP
+.B daemon run
+Run the daemon in the foreground, e.g. from a service manager.
+.TP
+.B tmux \fIproject\fR
+Create a tmux session named after the project, given by name or path, with its windows started in the project directory, then attach to it. Inside tmux the client switches to the session instead. The windows and panes come from the project's
+.I .den.toml
+or the
+.B [[tmux.windows]]
+tables of the config file; an existing session is reused as it is.
+.TP
+.B start \fIproject\fR
+Run the launch profile of a project, given by name or path: the steps of its
+.I .den.toml
+or of the first
+.B [launch.\fIname\fB]
+table listing the project or one of its tags, else
+.BR [launch.default] .
+Steps open the editor or a terminal, run commands in the background with their output in
+.IR $XDG_STATE_HOME/den/logs/<project>-<hash>.log ,
+or set environment variables for the steps after them. The first step that fails stops the launch and is reported.
+.TP
+.B \fIplugin\fR [\fIargs\fR]
+Run the executable
+.BI den- plugin
+from
+.B PATH
+with the remaining arguments. Plugins also show up in the command palette (ctrl+p), where they run in the selected project with
+.B DEN_PROJECT
+and
+.B DEN_PROJECT_PATH
+set, like the custom commands of the
+.B [commands.\fIname\fB]
+tables.
 .SH OPTIONS
 .TP
 .BR \-h ", " \-\-help
@@ -32,13 +94,78 @@ Reset all configuration and start fresh.
 .TP
 .BR \-\-debug
 Enable debug logging.
+.TP
+.BR \-\-config " " \fIpath\fR
+Use the config file at \fIpath\fR instead of the default location.
+.TP
+.BR \-\-profile " " \fIname\fR
+Use the project directories, favorites, theme and editor of the
+\fB[profiles.\fIname\fB]\fR table in the config file. Each profile has its own cache.
+.SH ENVIRONMENT
+.TP
+.B DEN_CONFIG
+Path of the config file. Overridden by \fB\-\-config\fR.
+.TP
+.B DEN_PROFILE
+Name of the profile to use. Overridden by \fB\-\-profile\fR.
+.TP
+.B XDG_CONFIG_HOME
+Base directory for the config file. Defaults to \fI~/.config\fR.
+.TP
+.B XDG_CACHE_HOME
+Base directory for caches. Defaults to \fI~/.cache\fR.
+.TP
+.B XDG_STATE_HOME
+Base directory for the project store, logs and other state. Defaults to \fI~/.local/state\fR.
 .SH FILES
 .TP
-.I ~/.config/den/config.yaml
-Configuration file
+.I $XDG_CONFIG_HOME/den/config.toml
+Configuration file. Older files are upgraded on startup and the original is kept as
+.IR config.toml.v<N>.bak .
 .TP
-.I ~/.cache/den/
+.I $XDG_CACHE_HOME/den/
 Cache directory
+.TP
+.I $XDG_STATE_HOME/den/den.db
+Project store with the scanned projects, tags, languages and usage history. Each profile uses
+.IR den-<profile>.db .
+An older
+.I $XDG_CACHE_HOME/den/projects.json
+is imported on first run and kept as
+.IR projects.json.bak .
+.TP
+.I $XDG_STATE_HOME/den/daemon.sock
+Socket of the daemon, with its log in
+.IR daemon.log .
+Other profiles use
+.I daemon-<profile>.sock
+and
+.IR daemon-<profile>.log .
+.TP
+.I $XDG_STATE_HOME/den/processes.json
+Background processes started by launch profiles, so that later den processes can show, stop and restart them. Their output is in
+.IR logs/<project>.log .
+.TP
+.I $XDG_STATE_HOME/den/selection.code-workspace
+Multi-root workspace written when the selected projects are opened together.
+.TP
+.I <projects>/.archive/
+Projects archived from the UI. Scans skip it.
+.TP
+.I $XDG_STATE_HOME/den/
+State directory
+.TP
+.I <project>/.den.toml
+Optional per-project settings, such as the
+.B [[tmux.windows]]
+of its tmux session and its
+.BR [[launch.steps]] .
+It comes with the repository and can run commands, so den shows it and asks before using it, and again whenever it changes.
+.TP
+.I $XDG_STATE_HOME/den/trusted-projects.json
+The
+.I .den.toml
+files you trusted, by path, with a hash of their content.
 .SH EXAMPLES
 .TP
 Start Den's interactive UI:
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"den/internal/runner"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runPlugin runs a den-* executable with the remaining arguments, like
+// git runs git-* commands
+func runPlugin(plugin runner.Command, args []string) error {
+	cmd := exec.Command(plugin.Plugin, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v", plugin.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/launch"
+	"fmt"
+	"os"
+)
+
+// runStart handles "den start <project>"
+func (c *CLI) runStart(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: den start <project>")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	p, err := findProject(cfg, args[0])
+	if err != nil {
+		return err
+	}
+	l, err := withTrust(func() (*launch.Launch, error) { return launch.For(cfg, p.Name, p.Path) })
+	if err != nil {
+		return err
+	}
+	// The history is best effort
+	cache.RecordVisit(cfg.ActiveProfile(), p.Path)
+
+	fmt.Printf("Starting %s (%s)\n", p.Name, l.Profile)
+	results, foreground, err := l.Run(cfg)
+	for _, r := range results {
+		mark := "✓"
+		if r.Err != nil {
+			mark = "✗"
+		}
+		line := fmt.Sprintf("  %s %s", mark, r.Step)
+		if r.Detail != "" {
+			line += " (" + r.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+	if err != nil {
+		return err
+	}
+
+	if foreground != nil {
+		foreground.Stdin = os.Stdin
+		foreground.Stdout = os.Stdout
+		foreground.Stderr = os.Stderr
+		return foreground.Run()
+	}
+	return nil
+}
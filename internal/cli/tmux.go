@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/tmux"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runTmux handles "den tmux <project>"
+func (c *CLI) runTmux(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: den tmux <project>")
+	}
+	if !tmux.Available() {
+		return fmt.Errorf("tmux is not installed")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	p, err := findProject(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd, err := withTrust(func() (*exec.Cmd, error) {
+		return tmux.Server{}.Open(p.Name, p.Path, cfg)
+	})
+	if err != nil {
+		return err
+	}
+	// The history is best effort
+	cache.RecordVisit(cfg.ActiveProfile(), p.Path)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
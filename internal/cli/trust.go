@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bufio"
+	"den/internal/config"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// withTrust runs fn, and runs it again once the user trusted the project
+// file it needed. Without a terminal to ask on, the error is returned.
+func withTrust[T any](fn func() (T, error)) (T, error) {
+	v, err := fn()
+	var untrusted *config.UntrustedError
+	if !errors.As(err, &untrusted) || !term.IsTerminal(os.Stdin.Fd()) {
+		return v, err
+	}
+	if !askTrust(untrusted) {
+		return v, fmt.Errorf("did not trust %s", untrusted.Path)
+	}
+	// Trust what was shown, a file that changed since stays untrusted
+	if err := config.TrustProjectFile(untrusted.Dir, untrusted.Data); err != nil {
+		return v, fmt.Errorf("could not trust %s: %v", untrusted.Path, err)
+	}
+	return fn()
+}
+
+// askTrust shows an untrusted project file and asks whether to trust it
+func askTrust(untrusted *config.UntrustedError) bool {
+	if untrusted.Changed {
+		fmt.Printf("%s changed since you trusted it.\n", untrusted.Path)
+	}
+	fmt.Printf("%s can run commands in tmux panes, launch steps and custom commands:\n\n", untrusted.Path)
+	fmt.Println(strings.TrimRight(string(untrusted.Data), "\n"))
+	fmt.Print("\nTrust it? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,35 @@
+package config
+
+import "errors"
+
+// CommandConfig is a custom command that runs in a project, from the
+// command palette or on several projects at once
+type CommandConfig struct {
+	// Run is a shell command, run with sh -c in the project directory
+	Run string `toml:"run"`
+	// Description is shown next to the name
+	Description string `toml:"description,omitempty"`
+}
+
+// ProjectCommands returns the custom commands of the project at dir: those
+// of the [commands] table, and those of its ProjectFile once trusted, which
+// take precedence. With an untrusted ProjectFile it returns the commands of
+// the table along with the *UntrustedError.
+func (c *Config) ProjectCommands(dir string) (map[string]CommandConfig, error) {
+	commands := make(map[string]CommandConfig, len(c.Commands))
+	for name, cmd := range c.Commands {
+		commands[name] = cmd
+	}
+	project, err := LoadTrustedProjectConfig(dir)
+	if err != nil {
+		var untrusted *UntrustedError
+		if errors.As(err, &untrusted) {
+			return commands, err
+		}
+		return nil, err
+	}
+	for name, cmd := range project.Commands {
+		commands[name] = cmd
+	}
+	return commands, nil
+}
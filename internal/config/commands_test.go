@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectCommands(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Commands = map[string]CommandConfig{
+		"test": {Run: "make test"},
+		"lint": {Run: "make lint", Description: "Lint the code"},
+	}
+
+	dir := t.TempDir()
+	project := "[commands.test]\nrun = \"go test ./...\"\n\n[commands.serve]\nrun = \"go run .\"\n"
+	if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Until the file is trusted, only the config's commands are returned
+	commands, err := cfg.ProjectCommands(dir)
+	if !errors.As(err, new(*UntrustedError)) {
+		t.Fatalf("expected an untrusted error, got %v", err)
+	}
+	if len(commands) != 2 || commands["test"].Run != "make test" {
+		t.Errorf("expected the commands of the config, got %v", commands)
+	}
+	if err := TrustProjectFile(dir, []byte(project)); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err = cfg.ProjectCommands(dir)
+	if err != nil {
+		t.Fatalf("ProjectCommands failed: %v", err)
+	}
+	if len(commands) != 3 {
+		t.Errorf("expected 3 commands, got %v", commands)
+	}
+	if commands["test"].Run != "go test ./..." {
+		t.Errorf("expected the project's test command to win, got %q", commands["test"].Run)
+	}
+	if commands["lint"].Description != "Lint the code" {
+		t.Errorf("expected the lint command from the config, got %+v", commands["lint"])
+	}
+	if cfg.Commands["test"].Run != "make test" {
+		t.Error("ProjectCommands changed the config")
+	}
+}
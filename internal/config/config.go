@@ -1,11 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"den/internal/fsutil"
+	"den/internal/paths"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 )
@@ -19,16 +21,39 @@ type UserPreferences struct {
 	GitStatusStyle     string   `toml:"gitStatusStyle"`
 	Theme              string   `toml:"theme"`
 	ProjectListTitle   string   `toml:"projectListTitle"`
+	// Terminal is the command that opens a terminal emulator, with {path}
+	// replaced by the project. Empty means the first one installed.
+	Terminal string `toml:"terminal,omitempty"`
 }
 
 type Config struct {
+	// Version is the config format version, see CurrentVersion
+	Version     int             `toml:"version"`
 	ProjectDirs []string        `toml:"projectDirs"`
 	Favorites   []string        `toml:"favorites"`
 	Preferences UserPreferences `toml:"preferences"`
+	// Keys remaps key bindings, e.g. toggleFavorite = ["f", "ctrl+f"]
+	Keys map[string][]string `toml:"keys"`
+	// Editors are named editor profiles
+	Editors map[string]EditorProfile `toml:"editors"`
+	// Tmux is the layout of tmux sessions
+	Tmux TmuxConfig `toml:"tmux,omitempty"`
+	// Launch are launch profiles by name
+	Launch map[string]LaunchProfile `toml:"launch"`
+	// Commands are custom commands by name
+	Commands map[string]CommandConfig `toml:"commands"`
+	// Profile is the profile used when neither --profile nor DEN_PROFILE is set
+	Profile  string             `toml:"profile,omitempty"`
+	Profiles map[string]Profile `toml:"profiles"`
+
+	// active is the applied profile and base the values it replaced
+	active string
+	base   *profileBase
 }
 
 func DefaultConfig() *Config {
 	return &Config{
+		Version:     CurrentVersion,
 		ProjectDirs: []string{},
 		Preferences: UserPreferences{
 			DefaultEditor:      "code", // VS Code as default
@@ -43,13 +68,61 @@ func DefaultConfig() *Config {
 	}
 }
 
+// Clone returns a deep copy of the config
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.ProjectDirs = append([]string{}, c.ProjectDirs...)
+	clone.Favorites = append([]string{}, c.Favorites...)
+	clone.Preferences.EditorList = append([]string{}, c.Preferences.EditorList...)
+	if c.Keys != nil {
+		clone.Keys = make(map[string][]string, len(c.Keys))
+		for action, keys := range c.Keys {
+			clone.Keys[action] = append([]string{}, keys...)
+		}
+	}
+	if c.Editors != nil {
+		clone.Editors = make(map[string]EditorProfile, len(c.Editors))
+		for name, ed := range c.Editors {
+			clone.Editors[name] = ed
+		}
+	}
+	clone.Tmux = c.Tmux.clone()
+	if c.Launch != nil {
+		clone.Launch = make(map[string]LaunchProfile, len(c.Launch))
+		for name, lp := range c.Launch {
+			clone.Launch[name] = lp.clone()
+		}
+	}
+	if c.Commands != nil {
+		clone.Commands = make(map[string]CommandConfig, len(c.Commands))
+		for name, cmd := range c.Commands {
+			clone.Commands[name] = cmd
+		}
+	}
+	if c.Profiles != nil {
+		clone.Profiles = make(map[string]Profile, len(c.Profiles))
+		for name, p := range c.Profiles {
+			p.ProjectDirs = append([]string{}, p.ProjectDirs...)
+			p.Favorites = append([]string{}, p.Favorites...)
+			clone.Profiles[name] = p
+		}
+	}
+	if c.base != nil {
+		base := *c.base
+		base.projectDirs = append([]string{}, base.projectDirs...)
+		base.favorites = append([]string{}, base.favorites...)
+		clone.base = &base
+	}
+	return &clone
+}
+
 func ensureConfigDir() error {
-	homeDir, err := os.UserHomeDir()
+	configPath, err := GetConfigPath()
 	if err != nil {
-		return fmt.Errorf("could not get home directory: %v", err)
+		return err
 	}
 
-	configDir := filepath.Join(homeDir, ".config", "den")
+	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("could not create config directory: %v", err)
 	}
@@ -92,175 +165,312 @@ func detectSystemPreferences() UserPreferences {
 	return prefs
 }
 
-// MergeWithDefaults merges a loaded config with defaults for any missing fields.
-// Returns the merged config and a boolean indicating if any fields were migrated.
-func MergeWithDefaults(cfg *Config, defaults *Config) (*Config, bool) {
-	migrated := false
-
-	// Merge UserPreferences fields
-	if cfg.Preferences.DefaultEditor == "" {
-		cfg.Preferences.DefaultEditor = defaults.Preferences.DefaultEditor
-		migrated = true
+// fillMissingDefaults sets the preferences the document leaves out to their
+// defaults, in memory only. Like migrateDefaults, it keeps a key the user
+// set, even to an empty value.
+func fillMissingDefaults(cfg *Config, doc *Document) {
+	defaults := DefaultConfig().Preferences
+	prefs := &cfg.Preferences
+	missing := func(key string) bool { return !doc.Has("preferences", key) }
+	if missing("defaultEditor") {
+		prefs.DefaultEditor = defaults.DefaultEditor
 	}
-	if cfg.Preferences.DefaultFileManager == "" {
-		cfg.Preferences.DefaultFileManager = defaults.Preferences.DefaultFileManager
-		migrated = true
+	if missing("editorList") {
+		prefs.EditorList = defaults.EditorList
 	}
-	if cfg.Preferences.Theme == "" {
-		cfg.Preferences.Theme = defaults.Preferences.Theme
-		migrated = true
+	if missing("defaultFileManager") {
+		prefs.DefaultFileManager = defaults.DefaultFileManager
 	}
-	if cfg.Preferences.GitStatusStyle == "" {
-		cfg.Preferences.GitStatusStyle = defaults.Preferences.GitStatusStyle
-		migrated = true
+	if missing("showGitStatus") {
+		prefs.ShowGitStatus = defaults.ShowGitStatus
 	}
-	if cfg.Preferences.ProjectListTitle == "" {
-		cfg.Preferences.ProjectListTitle = defaults.Preferences.ProjectListTitle
-		migrated = true
+	if missing("gitStatusStyle") {
+		prefs.GitStatusStyle = defaults.GitStatusStyle
 	}
-	if len(cfg.Preferences.EditorList) == 0 {
-		cfg.Preferences.EditorList = defaults.Preferences.EditorList
-		migrated = true
+	if missing("theme") {
+		prefs.Theme = defaults.Theme
+	}
+	if missing("projectListTitle") {
+		prefs.ProjectListTitle = defaults.ProjectListTitle
 	}
-
-	return cfg, migrated
 }
 
+// LoadConfig reads, migrates and returns the config, with the selected
+// profile applied
 func LoadConfig() (*Config, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if name := SelectedProfile(cfg); name != "" {
+		if err := cfg.UseProfile(name); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func loadConfig() (*Config, error) {
 	if err := ensureConfigDir(); err != nil {
 		return nil, err
 	}
 
-	homeDir, err := os.UserHomeDir()
+	configPath, err := GetConfigPath()
 	if err != nil {
-		return nil, fmt.Errorf("could not get home directory: %v", err)
+		return nil, err
 	}
 
-	configPath := filepath.Join(homeDir, ".config", "den", "config.toml")
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// Create default config if file doesn't exist
-			return DefaultConfig(), nil
+			return importLegacyConfig(configPath)
 		}
 		return nil, fmt.Errorf("could not read config file: %v", err)
 	}
 
-	var cfg Config
-	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("could not parse config file: %v", err)
+	doc := ParseDocument(data)
+	result, err := Migrate(doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not migrate config file: %v", err)
 	}
 
-	// Merge with defaults to ensure all fields are set
-	defaultCfg := DefaultConfig()
-	mergedCfg, didMigrate := MergeWithDefaults(&cfg, defaultCfg)
-
-	// If new fields were added, persist the updated config to disk
-	if didMigrate {
-		if err := SaveConfig(mergedCfg); err != nil {
-			// Don't fail loading if save fails, just continue with merged config
-			// The user will still have a valid config in memory
+	if result.Migrated() {
+		// Keep the file as it was before this migration, per version
+		backup := fmt.Sprintf("%s.v%d.bak", configPath, result.From)
+		if err := writeMigratedConfig(configPath, backup, doc); err != nil {
+			// Don't fail loading if save fails, just continue with the
+			// migrated config in memory
 			fmt.Fprintf(os.Stderr, "Warning: could not save migrated config: %v\n", err)
+		} else {
+			fmt.Fprint(os.Stderr, result.Summary(configPath, backup))
 		}
 	}
 
-	return mergedCfg, nil
+	var cfg Config
+	if err := toml.Unmarshal(doc.Bytes(), &cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config file: %v", err)
+	}
+
+	// Migrations decide what gets written back to the file, this only
+	// covers keys removed from it since
+	fillMissingDefaults(&cfg, doc)
+
+	return &cfg, nil
 }
 
-func SaveConfig(cfg *Config) error {
-	if err := ensureConfigDir(); err != nil {
-		return err
+func writeMigratedConfig(configPath, backup string, doc *Document) error {
+	if err := fsutil.CopyFile(configPath, backup); err != nil {
+		return fmt.Errorf("could not back up config file: %v", err)
 	}
+	if err := fsutil.WriteFileAtomic(configPath, doc.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not write config file: %v", err)
+	}
+	return nil
+}
 
-	homeDir, err := os.UserHomeDir()
+// importLegacyConfig converts the config.json used by early versions of den
+// next to configPath, if there is one. Otherwise the defaults are returned.
+func importLegacyConfig(configPath string) (*Config, error) {
+	legacyPath := filepath.Join(filepath.Dir(configPath), "config.json")
+	data, err := os.ReadFile(legacyPath)
 	if err != nil {
-		return fmt.Errorf("could not get home directory: %v", err)
+		if os.IsNotExist(err) {
+			return DefaultConfig(), nil
+		}
+		return nil, fmt.Errorf("could not read legacy config file: %v", err)
+	}
+
+	doc, err := legacyConfigDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	result, err := Migrate(doc)
+	if err != nil {
+		return nil, fmt.Errorf("could not migrate legacy config file: %v", err)
+	}
+
+	var cfg Config
+	if err := toml.Unmarshal(doc.Bytes(), &cfg); err != nil {
+		return nil, fmt.Errorf("could not parse legacy config file: %v", err)
+	}
+	fillMissingDefaults(&cfg, doc)
+
+	if err := SaveConfig(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save migrated config: %v\n", err)
+		return &cfg, nil
+	}
+
+	// Move the old file aside so it isn't imported again
+	backup := legacyPath + ".bak"
+	if err := os.Rename(legacyPath, backup); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not move legacy config aside: %v\n", err)
+		backup = ""
 	}
+	result.Changes = append([]string{"converted " + filepath.Base(legacyPath) + " to TOML"}, result.Changes...)
+	fmt.Fprint(os.Stderr, result.Summary(configPath, backup))
 
-	configPath := filepath.Join(homeDir, ".config", "den", "config.toml")
+	return &cfg, nil
+}
 
-	// Create the configuration content with comments
-	content := `# Den Configuration File
+// configTemplate is the commented skeleton written when no config file exists.
+// SaveConfig fills in the values; after that the file belongs to the user and
+// only keys that change are rewritten.
+const configTemplate = `# Den Configuration File
 # This file is automatically generated but can be manually edited
 
+# Config format version, used to upgrade older files. Do not edit.
+version = 0
+
 # List of directories to scan for Git repositories
 # Example: projectDirs = ["/home/user/code", "/home/user/work"]
-projectDirs = %s
+projectDirs = []
+
+# Paths of projects marked as favorites
+favorites = []
 
 [preferences]
 # Default editor to use when opening repositories
 # Common options: "code" (VS Code), "vim", "nano", "emacs", "sublime"
-defaultEditor = %q
+defaultEditor = ""
 
 # List of available editors to choose from in the UI
 # Add or remove editors based on what you have installed
-editorList = %s
+editorList = []
 
 # Default file manager/explorer to use
 # Common options:
 # - macOS: "open", "finder"
 # - Linux: "xdg-open", "nautilus", "dolphin"
 # - Windows: "explorer"
-defaultFileManager = %q
+defaultFileManager = ""
+
+# Command that opens a terminal emulator at a project, {path} is replaced
+# with the project directory. Leave unset to use the first one installed.
+# terminal = "kitty --directory {path}"
 
 # Whether to show hidden files in repository listings
-showHiddenFiles = %v
+showHiddenFiles = false
 
 # Whether to show Git status in repository listings
-showGitStatus = %v
+showGitStatus = true
 
 # Git status indicator style
 # Available options: "text" (e.g., "git (clean)", "git (modified)"), "nerd" (uses nerd font icons)
-gitStatusStyle = %q
+gitStatusStyle = ""
 
 # UI theme to use
-# Available options: "default", "dark", "light"
-theme = %q
+# Available options: "default", "dracula", "nord", "gruvbox", "solarized"
+theme = ""
 
 # Title shown at the top of the project list
-projectListTitle = %q
+projectListTitle = ""
+
+# Named editors, usable as defaultEditor, in editorList and in profiles.
+# command is split like a shell does; {path}, {name}, {file} and {line} are
+# replaced, and the path is appended when there are no placeholders.
+# terminal marks editors that run in the terminal; other editors are
+# detached unless mode = "wait".
+# [editors.nvim]
+# command = "nvim -c \"cd {path}\" {path}"
+# terminal = true
+#
+# [editors.code]
+# command = "code --new-window {path}"
+
+# Windows of the tmux sessions den creates, see "den tmux". Each pane runs
+# a command, "" is a shell; layout is any tmux layout. A project's .den.toml
+# can have its own [[tmux.windows]].
+# [[tmux.windows]]
+# name = "editor"
+# panes = ["nvim"]
+#
+# [[tmux.windows]]
+# name = "dev"
+# panes = ["", "npm run dev"]
+# layout = "even-horizontal"
+
+# Launch profiles start work on a project with "Start working" or
+# "den start": they apply to the listed projects (names or paths), to
+# projects with one of their tags, and [launch.default] to all others. A
+# project's .den.toml can have its own [[launch.steps]]. Steps run in order:
+# action = "editor" (optional editor = "<name>"), "terminal",
+# "run" (command = "..." in the background) or "env" (env = { KEY = "value" }).
+# [launch.web]
+# tags = ["web"]
+# steps = [
+#     { action = "env", env = { NODE_ENV = "development" } },
+#     { action = "run", command = "npm run dev" },
+#     { action = "terminal" },
+#     { action = "editor" },
+# ]
+
+# Custom commands run in a project with sh -c, from the command palette
+# (ctrl+p) or on all selected projects. A project's .den.toml can have its
+# own [commands.<name>]. Executables named den-<name> in PATH show up as
+# well, and run as "den <name>".
+# [commands.test]
+# run = "make test"
+# description = "Run the tests"
+
+# Named profiles, selected with --profile, DEN_PROFILE or the profile key
+# at the top of this file. Each profile has its own projectDirs and
+# favorites; theme and editor are optional.
+# [profiles.work]
+# projectDirs = ["/home/user/work"]
+# favorites = []
+# theme = "nord"
+# editor = "code"
 `
-	// Format the content with the current configuration values
-	projectDirsStr := formatTOMLStringArray(cfg.ProjectDirs)
-	editorListStr := formatTOMLStringArray(cfg.Preferences.EditorList)
-
-	content = fmt.Sprintf(content,
-		projectDirsStr,
-		cfg.Preferences.DefaultEditor,
-		editorListStr,
-		cfg.Preferences.DefaultFileManager,
-		cfg.Preferences.ShowHiddenFiles,
-		cfg.Preferences.ShowGitStatus,
-		cfg.Preferences.GitStatusStyle,
-		cfg.Preferences.Theme,
-		cfg.Preferences.ProjectListTitle,
-	)
-
-	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("could not write config file: %v", err)
+
+// SaveConfig writes cfg to the config file. Existing files are edited in
+// place: only keys whose values changed are rewritten, so comments, ordering
+// and unknown keys are preserved. The previous file is kept as a backup.
+func SaveConfig(cfg *Config) error {
+	if err := ensureConfigDir(); err != nil {
+		return err
 	}
 
-	return nil
-}
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return err
+	}
 
-// formatTOMLStringArray formats a string slice as a TOML array
-func formatTOMLStringArray(arr []string) string {
-	if len(arr) == 0 {
-		return "[]"
+	existing, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read config file: %v", err)
 	}
 
-	items := make([]string, len(arr))
-	for i, s := range arr {
-		items[i] = fmt.Sprintf("%q", s)
+	var doc *Document
+	if existing == nil {
+		doc = ParseDocument([]byte(configTemplate))
+	} else {
+		doc = ParseDocument(existing)
 	}
-	return fmt.Sprintf("[%s]", strings.Join(items, ", "))
-}
 
-func GetConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("could not get home directory: %v", err)
+	if err := doc.SetStruct("", cfg.persisted()); err != nil {
+		return err
+	}
+
+	content := doc.Bytes()
+	if bytes.Equal(content, existing) {
+		return nil
+	}
+
+	if existing != nil {
+		if err := fsutil.CopyFile(configPath, configPath+".bak"); err != nil {
+			return fmt.Errorf("could not back up config file: %v", err)
+		}
 	}
 
-	return filepath.Join(homeDir, ".config", "den", "config.toml"), nil
+	if err := fsutil.WriteFileAtomic(configPath, content, 0644); err != nil {
+		return fmt.Errorf("could not write config file: %v", err)
+	}
+
+	return nil
+}
+
+// GetConfigPath returns the config file location, see paths.ConfigFile
+func GetConfigPath() (string, error) {
+	return paths.ConfigFile()
 }
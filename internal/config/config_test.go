@@ -12,13 +12,12 @@ func TestConfigMigration(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir := t.TempDir()
 
-	// Override the home directory for this test
-	originalHome := os.Getenv("HOME")
-	os.Setenv("HOME", tmpDir)
-	defer os.Setenv("HOME", originalHome)
+	// Point the config directory at the temporary directory
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
 
 	// Create config directory
-	configDir := filepath.Join(tmpDir, ".config", "den")
+	configDir := filepath.Join(tmpDir, "den")
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		t.Fatalf("Failed to create config dir: %v", err)
 	}
@@ -75,64 +74,39 @@ projectListTitle = "My Projects"
 	}
 }
 
-func TestMergeWithDefaults(t *testing.T) {
-	// Test that MergeWithDefaults correctly identifies missing fields
-	cfg := &Config{
-		ProjectDirs: []string{"/test"},
-		Preferences: UserPreferences{
-			DefaultEditor: "vim",
-			Theme:         "dark",
-			// GitStatusStyle is missing
-		},
-	}
-
-	defaults := DefaultConfig()
-	merged, didMigrate := MergeWithDefaults(cfg, defaults)
-
-	if !didMigrate {
-		t.Error("Expected migration to be detected")
+func TestLoadConfigKeepsEmptyValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+	configDir := filepath.Join(tmpDir, "den")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
 	}
 
-	if merged.Preferences.GitStatusStyle != "text" {
-		t.Errorf("Expected GitStatusStyle to be 'text', got '%s'", merged.Preferences.GitStatusStyle)
-	}
+	// An empty title and editor list were set on purpose; the theme was
+	// removed from the file after it was migrated
+	content := `version = 2
+projectDirs = []
 
-	// Verify existing values were preserved
-	if merged.Preferences.DefaultEditor != "vim" {
-		t.Error("Existing DefaultEditor was overwritten")
-	}
-	if merged.Preferences.Theme != "dark" {
-		t.Error("Existing Theme was overwritten")
+[preferences]
+defaultEditor = "vim"
+editorList = []
+showGitStatus = false
+gitStatusStyle = "text"
+projectListTitle = ""
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
 	}
-}
 
-func TestMergeWithDefaults_NoMigrationNeeded(t *testing.T) {
-	// Test that no migration is reported when all fields are present
-	defaults := DefaultConfig()
-
-	// Create a complete config
-	cfg := &Config{
-		ProjectDirs: []string{"/test"},
-		Preferences: UserPreferences{
-			DefaultEditor:      "vim",
-			EditorList:         []string{"vim"},
-			DefaultFileManager: "open",
-			ShowHiddenFiles:    false,
-			ShowGitStatus:      true,
-			GitStatusStyle:     "nerd",
-			Theme:              "dark",
-			ProjectListTitle:   "Projects",
-		},
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
 	}
-
-	merged, didMigrate := MergeWithDefaults(cfg, defaults)
-
-	if didMigrate {
-		t.Error("Expected no migration for complete config")
+	if cfg.Preferences.ProjectListTitle != "" || len(cfg.Preferences.EditorList) != 0 || cfg.Preferences.ShowGitStatus {
+		t.Errorf("expected the values set in the file to be kept, got %+v", cfg.Preferences)
 	}
-
-	// Verify values were preserved
-	if merged.Preferences.GitStatusStyle != "nerd" {
-		t.Error("GitStatusStyle should not have been changed")
+	if cfg.Preferences.Theme != DefaultConfig().Preferences.Theme {
+		t.Errorf("expected the missing theme to default, got %q", cfg.Preferences.Theme)
 	}
 }
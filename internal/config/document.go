@@ -0,0 +1,789 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Document is a TOML file that can be edited in place. Only the values that
+// are set or deleted change; comments, ordering and keys den doesn't know
+// about are kept as the user wrote them.
+type Document struct {
+	lines           []string
+	eol             string
+	trailingNewline bool
+	entries         []docEntry
+	tables          []docTable
+}
+
+// docEntry is the location of a key/value pair in the document
+type docEntry struct {
+	table      string
+	key        string
+	section    string // table of the header the pair is written under
+	start, end int    // first and last line of the pair
+	valueStart int    // offset of the value in the first line
+	valueEnd   int    // offset just past the value in the last line
+}
+
+// docTable is the location of a table header in the document
+type docTable struct {
+	name   string
+	header int
+	array  bool
+}
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ParseDocument indexes the tables and keys of a TOML file. It does not
+// validate the file; use toml.Unmarshal for that.
+func ParseDocument(data []byte) *Document {
+	text := string(data)
+	d := &Document{eol: "\n"}
+	if strings.Contains(text, "\r\n") {
+		d.eol = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	d.trailingNewline = strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text != "" {
+		d.lines = strings.Split(text, "\n")
+	}
+	d.index()
+	return d
+}
+
+// Bytes returns the document contents
+func (d *Document) Bytes() []byte {
+	text := strings.Join(d.lines, d.eol)
+	if d.trailingNewline || len(d.lines) > 0 {
+		text += d.eol
+	}
+	return []byte(text)
+}
+
+// Get returns the raw TOML value of key in table
+func (d *Document) Get(table, key string) (string, bool) {
+	e := d.find(table, key)
+	if e == nil {
+		return "", false
+	}
+	return d.raw(e), true
+}
+
+// Has reports whether key is set in table
+func (d *Document) Has(table, key string) bool {
+	return d.find(table, key) != nil
+}
+
+// Line returns the 1-based line number of key in table, or 0 if it is unset
+func (d *Document) Line(table, key string) int {
+	if e := d.find(table, key); e != nil {
+		return e.start + 1
+	}
+	return 0
+}
+
+// TableLine returns the 1-based line number of a table header, or 0
+func (d *Document) TableLine(table string) int {
+	for _, t := range d.tables {
+		if t.name == table && !t.array {
+			return t.header + 1
+		}
+	}
+	return 0
+}
+
+// isArrayTable reports whether the document has [[table]] headers
+func (d *Document) isArrayTable(table string) bool {
+	name := "[[" + normalizeKey(table) + "]]"
+	for _, t := range d.tables {
+		if t.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns the keys set directly in table, in document order
+func (d *Document) Keys(table string) []string {
+	var keys []string
+	for _, e := range d.entries {
+		if e.table == table {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Tables returns the names of the tables in the document, in order
+func (d *Document) Tables() []string {
+	var names []string
+	for _, t := range d.tables {
+		if !t.array {
+			names = append(names, t.name)
+		}
+	}
+	return names
+}
+
+// Set replaces the value of key in table with raw, which must already be
+// TOML encoded. Missing keys are added to the end of their table, and
+// missing tables to the end of the document.
+func (d *Document) Set(table, key, raw string) {
+	if e := d.find(table, key); e != nil {
+		first := d.lines[e.start][:e.valueStart]
+		rest := d.lines[e.end][e.valueEnd:]
+		d.splice(e.start, e.end+1, first+raw+rest)
+		return
+	}
+
+	if at, prefix, ok := d.insertionPoint(table); ok {
+		line := prefix + quoteKey(key) + " = " + raw
+		if table == "" && at < len(d.lines) && !d.hasEntries("") {
+			// Keep a blank line between root keys and the first table
+			d.splice(at, at, line, "")
+		} else {
+			d.splice(at, at, line)
+		}
+		return
+	}
+
+	var added []string
+	if len(d.lines) > 0 && strings.TrimSpace(d.lines[len(d.lines)-1]) != "" {
+		added = append(added, "")
+	}
+	added = append(added, "["+table+"]", quoteKey(key)+" = "+raw)
+	d.splice(len(d.lines), len(d.lines), added...)
+}
+
+// Delete removes key from table. It reports whether the key was set.
+func (d *Document) Delete(table, key string) bool {
+	e := d.find(table, key)
+	if e == nil {
+		return false
+	}
+	d.splice(e.start, e.end+1)
+	return true
+}
+
+// Rename moves a key to a new table and name, keeping its value and any
+// trailing comment. It reports whether the key was set.
+func (d *Document) Rename(table, key, newTable, newKey string) bool {
+	e := d.find(table, key)
+	if e == nil {
+		return false
+	}
+	line := d.lines[e.start]
+	if normalizeKey(table) == normalizeKey(newTable) && !strings.Contains(normalizeKey(line[:keyEnd(line)]), ".") {
+		// Rewrite the key in place so the value and its comments stay put
+		d.splice(e.start, e.start+1, indentOf(line)+quoteKey(newKey)+" = "+line[e.valueStart:])
+		return true
+	}
+	raw := d.raw(e)
+	d.splice(e.start, e.end+1)
+	d.Set(newTable, newKey, raw)
+	return true
+}
+
+// DeleteTable removes a table header and everything up to the next table
+func (d *Document) DeleteTable(table string) bool {
+	for i, t := range d.tables {
+		if t.name != table || t.array {
+			continue
+		}
+		end := len(d.lines)
+		if i+1 < len(d.tables) {
+			end = d.tables[i+1].header
+		}
+		d.splice(t.header, end)
+		return true
+	}
+	return false
+}
+
+// Decode unmarshals a raw TOML value into v
+func Decode(raw string, v any) error {
+	wrapper := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "V",
+		Type: reflect.TypeOf(v).Elem(),
+		Tag:  `toml:"v"`,
+	}}))
+	if err := toml.Unmarshal([]byte("v = "+raw), wrapper.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(v).Elem().Set(wrapper.Elem().Field(0))
+	return nil
+}
+
+// SetValue encodes v and sets it as key in table unless the document already
+// holds an equal value, so that user formatting of unchanged values survives
+func (d *Document) SetValue(table, key string, v any) error {
+	raw, err := EncodeValue(v)
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %v", joinKey(table, key), err)
+	}
+	if old, ok := d.Get(table, key); ok && sameValue(old, raw) {
+		return nil
+	}
+	d.Set(table, key, raw)
+	return nil
+}
+
+// SetStruct writes every toml-tagged field of a struct under table. Nested
+// structs become sub-tables and maps of structs become one table per key.
+func (d *Document) SetStruct(table string, v any) error {
+	return d.setStruct(table, reflect.Indirect(reflect.ValueOf(v)))
+}
+
+func (d *Document) setStruct(table string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty := tomlName(field)
+		if name == "" {
+			continue
+		}
+		fv := v.Field(i)
+
+		switch {
+		case fv.Kind() == reflect.Struct:
+			if err := d.setStruct(joinKey(table, quoteKey(name)), fv); err != nil {
+				return err
+			}
+			continue
+		case fv.Kind() == reflect.Map && isStructLike(fv.Type().Elem()):
+			if err := d.setStructMap(joinKey(table, quoteKey(name)), fv); err != nil {
+				return err
+			}
+			continue
+		case fv.Kind() == reflect.Map:
+			if err := d.setMap(joinKey(table, quoteKey(name)), fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Arrays of tables are left as the user wrote them
+		if d.isArrayTable(joinKey(table, quoteKey(name))) {
+			continue
+		}
+		if omitempty && fv.IsZero() {
+			d.Delete(table, name)
+			continue
+		}
+		if err := d.SetValue(table, name, fv.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setMap writes a map as a table with one key per entry and drops keys that
+// are no longer in the map
+func (d *Document) setMap(table string, v reflect.Value) error {
+	for _, existing := range d.Keys(normalizeKey(table)) {
+		if !v.MapIndex(reflect.ValueOf(existing)).IsValid() {
+			d.Delete(table, existing)
+		}
+	}
+
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		if err := d.SetValue(table, k.String(), v.MapIndex(k).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setStructMap writes one table per map key and drops tables for keys that
+// are no longer in the map
+func (d *Document) setStructMap(table string, v reflect.Value) error {
+	prefix := table + "."
+	keep := make(map[string]bool)
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		name := prefix + quoteKey(k.String())
+		keep[normalizeKey(name)] = true
+		if err := d.setStruct(name, reflect.Indirect(v.MapIndex(k))); err != nil {
+			return err
+		}
+	}
+
+	for _, existing := range d.Tables() {
+		if !strings.HasPrefix(existing, normalizeKey(prefix)) {
+			continue
+		}
+		owner := existing
+		for !keep[owner] && strings.Contains(strings.TrimPrefix(owner, normalizeKey(prefix)), ".") {
+			owner = owner[:strings.LastIndex(owner, ".")]
+		}
+		if !keep[owner] {
+			d.DeleteTable(existing)
+		}
+	}
+	return nil
+}
+
+// EncodeValue encodes a Go value as an inline TOML value
+func EncodeValue(v any) (string, error) {
+	return encodeValue(reflect.ValueOf(v))
+}
+
+func encodeValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return quoteString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			item, err := encodeValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			item, err := encodeValue(v.MapIndex(k))
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, quoteKey(k.String())+" = "+item)
+		}
+		return inlineTable(pairs), nil
+	case reflect.Struct:
+		var pairs []string
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, omitempty := tomlName(t.Field(i))
+			if name == "" || (omitempty && v.Field(i).IsZero()) {
+				continue
+			}
+			item, err := encodeValue(v.Field(i))
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, quoteKey(name)+" = "+item)
+		}
+		return inlineTable(pairs), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "", fmt.Errorf("cannot encode nil")
+		}
+		return encodeValue(v.Elem())
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func inlineTable(pairs []string) string {
+	if len(pairs) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }"
+}
+
+// quoteString encodes s as a TOML basic string
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteKey returns key as a bare key when possible and quoted otherwise
+func quoteKey(key string) string {
+	if bareKeyPattern.MatchString(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+func joinKey(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "." + key
+}
+
+// tomlName returns the key name of a struct field and whether it is omitempty
+func tomlName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("toml")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, opts == "omitempty"
+}
+
+func isStructLike(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// sameValue reports whether two raw TOML values decode to the same value
+func sameValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb map[string]any
+	if toml.Unmarshal([]byte("v = "+a), &va) != nil || toml.Unmarshal([]byte("v = "+b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func (d *Document) find(table, key string) *docEntry {
+	table = normalizeKey(table)
+	for i := range d.entries {
+		if d.entries[i].table == table && d.entries[i].key == key {
+			return &d.entries[i]
+		}
+	}
+	return nil
+}
+
+func (d *Document) hasEntries(table string) bool {
+	for _, e := range d.entries {
+		if e.table == table {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Document) raw(e *docEntry) string {
+	if e.start == e.end {
+		return d.lines[e.start][e.valueStart:e.valueEnd]
+	}
+	parts := []string{d.lines[e.start][e.valueStart:]}
+	parts = append(parts, d.lines[e.start+1:e.end]...)
+	parts = append(parts, d.lines[e.end][:e.valueEnd])
+	return strings.Join(parts, "\n")
+}
+
+// insertionPoint returns the line a new key in table should be inserted at
+// and the dotted path the key needs there. The path is only set for tables
+// that are defined through dotted keys under another header.
+func (d *Document) insertionPoint(table string) (int, string, bool) {
+	table = normalizeKey(table)
+	var last *docEntry
+	for i, e := range d.entries {
+		if e.table == table {
+			last = &d.entries[i]
+		}
+	}
+	if last != nil {
+		prefix := ""
+		if last.section != table {
+			rel := strings.TrimPrefix(table, last.section)
+			for _, part := range strings.Split(strings.TrimPrefix(rel, "."), ".") {
+				prefix += quoteKey(part) + "."
+			}
+		}
+		return last.end + 1, prefix, true
+	}
+
+	if table == "" {
+		// Before the first table, below any leading comments
+		for _, t := range d.tables {
+			at := t.header
+			for at > 0 && strings.HasPrefix(strings.TrimSpace(d.lines[at-1]), "#") {
+				at--
+			}
+			for at > 0 && strings.TrimSpace(d.lines[at-1]) == "" {
+				at--
+			}
+			return at, "", true
+		}
+		return len(d.lines), "", true
+	}
+
+	for _, t := range d.tables {
+		if t.name == table && !t.array {
+			return t.header + 1, "", true
+		}
+	}
+	return 0, "", false
+}
+
+// splice replaces lines[from:to] with repl and reindexes the document
+func (d *Document) splice(from, to int, repl ...string) {
+	lines := make([]string, 0, len(d.lines)-(to-from)+len(repl))
+	lines = append(lines, d.lines[:from]...)
+	lines = append(lines, repl...)
+	lines = append(lines, d.lines[to:]...)
+	d.lines = lines
+	d.index()
+}
+
+// index locates every table header and key/value pair
+func (d *Document) index() {
+	d.entries = nil
+	d.tables = nil
+	table := ""
+	for i := 0; i < len(d.lines); i++ {
+		line := d.lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			array := strings.HasPrefix(trimmed, "[[")
+			name := strings.TrimLeft(trimmed, "[")
+			if end := closingBracket(name); end >= 0 {
+				name = name[:end]
+			}
+			table = normalizeKey(name)
+			if array {
+				// Keys inside arrays of tables are never edited in place
+				table = "[[" + table + "]]"
+			}
+			d.tables = append(d.tables, docTable{name: table, header: i, array: array})
+			continue
+		}
+
+		eq := keyEnd(line)
+		if eq < 0 {
+			continue
+		}
+		key := normalizeKey(line[:eq])
+		entryTable := table
+		if dot := strings.LastIndex(key, "."); dot >= 0 {
+			entryTable = joinKey(table, key[:dot])
+			key = key[dot+1:]
+		}
+
+		start := eq + 1
+		for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+			start++
+		}
+		endLine, endCol := scanValue(d.lines, i, start)
+		d.entries = append(d.entries, docEntry{
+			table:      entryTable,
+			key:        key,
+			section:    table,
+			start:      i,
+			end:        endLine,
+			valueStart: start,
+			valueEnd:   endCol,
+		})
+		i = endLine
+	}
+}
+
+// keyEnd returns the offset of the '=' separating a key from its value
+func keyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		case c == '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// closingBracket returns the offset of the ']' ending a table header name
+func closingBracket(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeKey unquotes and trims each part of a dotted key
+func normalizeKey(key string) string {
+	var parts []string
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(key) {
+				i++
+				b.WriteByte(key[i])
+			} else if c == quote {
+				quote = 0
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(b.String()))
+			b.Reset()
+		case c == ' ' || c == '\t':
+		default:
+			b.WriteByte(c)
+		}
+	}
+	parts = append(parts, strings.TrimSpace(b.String()))
+	if len(parts) == 1 && parts[0] == "" {
+		return ""
+	}
+	return strings.Join(parts, ".")
+}
+
+// scanValue finds the end of a value starting at lines[line][col]. It returns
+// the last line of the value and the offset just past it, before any
+// trailing whitespace or comment.
+func scanValue(lines []string, line, col int) (int, int) {
+	depth := 0
+	l, c := line, col
+	for l < len(lines) {
+		s := lines[l]
+		for c < len(s) {
+			switch {
+			case strings.HasPrefix(s[c:], `"""`) || strings.HasPrefix(s[c:], `'''`):
+				l, c = skipMultiline(lines, l, c+3, s[c:c+3])
+				s = lines[l]
+				continue
+			case s[c] == '"' || s[c] == '\'':
+				c = skipString(s, c+1, s[c])
+				continue
+			case s[c] == '[' || s[c] == '{':
+				depth++
+			case s[c] == ']' || s[c] == '}':
+				depth--
+			case s[c] == '#':
+				if depth <= 0 {
+					return l, len(strings.TrimRight(s[:c], " \t"))
+				}
+				c = len(s)
+				continue
+			}
+			c++
+		}
+		if depth <= 0 || l == len(lines)-1 {
+			return l, len(strings.TrimRight(s, " \t"))
+		}
+		l++
+		c = 0
+	}
+	return len(lines) - 1, len(lines[len(lines)-1])
+}
+
+// skipString returns the offset just past the string closing quote
+func skipString(s string, c int, quote byte) int {
+	for c < len(s) {
+		if s[c] == '\\' && quote == '"' {
+			c += 2
+			continue
+		}
+		if s[c] == quote {
+			return c + 1
+		}
+		c++
+	}
+	return c
+}
+
+// skipMultiline returns the position just past a multi-line string delimiter
+func skipMultiline(lines []string, l, c int, delim string) (int, int) {
+	for l < len(lines) {
+		s := lines[l]
+		for c < len(s) {
+			if s[c] == '\\' && delim == `"""` {
+				c += 2
+				continue
+			}
+			if strings.HasPrefix(s[c:], delim) {
+				c += len(delim)
+				// Up to two extra quotes may belong to the string content
+				for i := 0; i < 2 && c < len(s) && s[c] == delim[0]; i++ {
+					c++
+				}
+				return l, c
+			}
+			c++
+		}
+		if l == len(lines)-1 {
+			return l, len(s)
+		}
+		l++
+		c = 0
+	}
+	return l, c
+}
+
+func indentOf(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
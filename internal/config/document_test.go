@@ -0,0 +1,234 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const userEditedConfig = `# my den config
+version = 2
+projectDirs = [
+    "/home/me/code", # personal
+    "/home/me/work",
+]
+extraRootKey = 42 # not a den key
+
+[preferences]
+defaultEditor = "vim"  # keep this comment
+editorList = ["vim", "nano"]
+defaultFileManager = "open"
+showHiddenFiles = false
+showGitStatus = true
+gitStatusStyle = "text"
+theme = "nord"
+projectListTitle = "Projects"
+
+[plugins.unknown]
+# den doesn't know about this section
+enabled = true
+
+[[tmux.windows]]
+name = "editor"
+panes = ["vim"] # written as an array of tables
+`
+
+func TestDocumentSetPreservesComments(t *testing.T) {
+	doc := ParseDocument([]byte(userEditedConfig))
+
+	doc.Set("preferences", "defaultEditor", `"nvim"`)
+	doc.Set("", "favorites", `["/home/me/code/den"]`)
+	doc.Set("preferences", "newKey", `"x"`)
+	doc.Set("extra", "answer", `42`)
+
+	got := string(doc.Bytes())
+
+	for _, want := range []string{
+		"# my den config",
+		`"/home/me/code", # personal`,
+		"extraRootKey = 42 # not a den key",
+		`defaultEditor = "nvim"  # keep this comment`,
+		"# den doesn't know about this section",
+		"[plugins.unknown]\n# den doesn't know about this section\nenabled = true",
+		"[extra]\nanswer = 42",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected document to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	// New root keys go after the existing root keys, not into a table
+	if !strings.Contains(got, "extraRootKey = 42 # not a den key\nfavorites = [\"/home/me/code/den\"]") {
+		t.Errorf("favorites not inserted after root keys:\n%s", got)
+	}
+	if !strings.Contains(got, "projectListTitle = \"Projects\"\nnewKey = \"x\"") {
+		t.Errorf("newKey not appended to [preferences]:\n%s", got)
+	}
+
+	var cfg Config
+	if err := toml.Unmarshal(doc.Bytes(), &cfg); err != nil {
+		t.Fatalf("edited document is not valid TOML: %v", err)
+	}
+	if cfg.Preferences.DefaultEditor != "nvim" || len(cfg.Favorites) != 1 {
+		t.Errorf("unexpected config after edit: %+v", cfg)
+	}
+}
+
+func TestDocumentGetMultilineValue(t *testing.T) {
+	doc := ParseDocument([]byte(userEditedConfig))
+
+	raw, ok := doc.Get("", "projectDirs")
+	if !ok {
+		t.Fatal("projectDirs not found")
+	}
+	var dirs []string
+	if err := Decode(raw, &dirs); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(dirs) != 2 || dirs[1] != "/home/me/work" {
+		t.Errorf("unexpected projectDirs: %v", dirs)
+	}
+
+	if line := doc.Line("preferences", "theme"); line != 16 {
+		t.Errorf("expected theme on line 16, got %d", line)
+	}
+}
+
+func TestDocumentDeleteAndRename(t *testing.T) {
+	doc := ParseDocument([]byte(userEditedConfig))
+
+	if !doc.Rename("preferences", "theme", "preferences", "colorScheme") {
+		t.Fatal("Rename reported missing key")
+	}
+	if !doc.Rename("", "extraRootKey", "preferences", "extra") {
+		t.Fatal("Rename across tables reported missing key")
+	}
+	if !doc.Delete("preferences", "showHiddenFiles") {
+		t.Fatal("Delete reported missing key")
+	}
+	if !doc.DeleteTable("plugins.unknown") {
+		t.Fatal("DeleteTable reported missing table")
+	}
+
+	got := string(doc.Bytes())
+	if !strings.Contains(got, `colorScheme = "nord"`) || strings.Contains(got, "theme =") {
+		t.Errorf("rename in place failed:\n%s", got)
+	}
+	if doc.Has("", "extraRootKey") || !doc.Has("preferences", "extra") {
+		t.Errorf("rename across tables failed:\n%s", got)
+	}
+	if strings.Contains(got, "showHiddenFiles") || strings.Contains(got, "plugins") {
+		t.Errorf("delete failed:\n%s", got)
+	}
+}
+
+func TestDocumentSetInDottedKeyTable(t *testing.T) {
+	doc := ParseDocument([]byte("preferences.theme = \"nord\"\n\n[other]\nx = 1\n"))
+	doc.Set("preferences", "terminal", `"kitty"`)
+
+	got := string(doc.Bytes())
+	want := "preferences.theme = \"nord\"\npreferences.terminal = \"kitty\"\n\n[other]\nx = 1\n"
+	if got != want {
+		t.Errorf("unexpected document:\n%s", got)
+	}
+
+	var cfg struct {
+		Terminal    string `toml:"terminal"`
+		Preferences struct {
+			Theme    string `toml:"theme"`
+			Terminal string `toml:"terminal"`
+		} `toml:"preferences"`
+	}
+	if err := toml.Unmarshal(doc.Bytes(), &cfg); err != nil {
+		t.Fatalf("document no longer parses: %v", err)
+	}
+	if cfg.Preferences.Terminal != "kitty" || cfg.Preferences.Theme != "nord" || cfg.Terminal != "" {
+		t.Errorf("key landed in the wrong table: %+v", cfg)
+	}
+}
+
+func TestSaveConfigOnlyRewritesChangedKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+
+	configDir := filepath.Join(tmpDir, "den")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(userEditedConfig), 0600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	cfg.Favorites = append(cfg.Favorites, "/home/me/code/den")
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	want := strings.Replace(userEditedConfig,
+		"extraRootKey = 42 # not a den key\n",
+		"extraRootKey = 42 # not a den key\nfavorites = [\"/home/me/code/den\"]\n", 1)
+	if string(data) != want {
+		t.Errorf("unexpected config after save:\n%s", data)
+	}
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("Failed to stat config: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions to be kept, got %v", info.Mode().Perm())
+	}
+
+	backup, err := os.ReadFile(configPath + ".bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != userEditedConfig {
+		t.Errorf("backup does not hold the previous config:\n%s", backup)
+	}
+}
+
+func TestSaveConfigWritesTemplateForNewFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+
+	cfg := DefaultConfig()
+	cfg.ProjectDirs = []string{"/tmp/projects"}
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	configPath := filepath.Join(tmpDir, "den", "config.toml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	if !strings.Contains(string(data), "# Den Configuration File") {
+		t.Errorf("expected commented template, got:\n%s", data)
+	}
+
+	var reloaded Config
+	if err := toml.Unmarshal(data, &reloaded); err != nil {
+		t.Fatalf("Failed to parse saved config: %v", err)
+	}
+	if reloaded.ProjectDirs[0] != "/tmp/projects" || reloaded.Preferences.Theme != "default" {
+		t.Errorf("unexpected config after save: %+v", reloaded)
+	}
+	if _, err := os.Stat(configPath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("no backup expected for a new file")
+	}
+}
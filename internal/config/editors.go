@@ -0,0 +1,49 @@
+package config
+
+import "sort"
+
+// Editor modes
+const (
+	// EditorWait waits for the editor to exit
+	EditorWait = "wait"
+	// EditorDetach starts the editor and lets it run on its own
+	EditorDetach = "detach"
+)
+
+// EditorModes are the valid values of EditorProfile.Mode
+var EditorModes = []string{EditorWait, EditorDetach}
+
+// EditorProfile describes how to run an editor. It is configured as an
+// [editors.<name>] table, and name can then be used as the default editor,
+// in the editor list and in profiles.
+type EditorProfile struct {
+	// Command is the command line, split into words like a shell does.
+	// {path} and {name} are the project's path and name, {file} and {line}
+	// a file to open and its line; words using them are left out when
+	// there is no file. Without placeholders the path is appended.
+	Command string `toml:"command"`
+	// Terminal editors run in the terminal den was started from, and
+	// always in the foreground
+	Terminal bool `toml:"terminal,omitempty"`
+	// Mode is EditorWait or EditorDetach for GUI editors, which are
+	// detached by default
+	Mode string `toml:"mode,omitempty"`
+}
+
+// EditorNames returns the editors to choose from: the editor list, then
+// the configured editor profiles that are not in it
+func (c *Config) EditorNames() []string {
+	names := append([]string{}, c.Preferences.EditorList...)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	var extra []string
+	for name := range c.Editors {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
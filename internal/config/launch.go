@@ -0,0 +1,135 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Launch step actions
+const (
+	// LaunchEditor opens the project in an editor
+	LaunchEditor = "editor"
+	// LaunchTerminal opens a terminal emulator at the project
+	LaunchTerminal = "terminal"
+	// LaunchRun runs a command in the background
+	LaunchRun = "run"
+	// LaunchEnv sets environment variables for the steps after it
+	LaunchEnv = "env"
+)
+
+// LaunchActions are the valid values of LaunchStep.Action
+var LaunchActions = []string{LaunchEditor, LaunchTerminal, LaunchRun, LaunchEnv}
+
+// DefaultLaunchProfile is the launch profile of projects no other profile
+// applies to
+const DefaultLaunchProfile = "default"
+
+// LaunchProfile is a [launch.<name>] table: the steps that start work on a
+// project. It applies to the listed projects and to projects with one of
+// its tags.
+type LaunchProfile struct {
+	// Projects are project names or paths
+	Projects []string     `toml:"projects,omitempty"`
+	Tags     []string     `toml:"tags,omitempty"`
+	Steps    []LaunchStep `toml:"steps,omitempty"`
+}
+
+// LaunchStep is a single step of a launch profile
+type LaunchStep struct {
+	Action string `toml:"action"`
+	// Editor is the editor to open, the default editor when empty
+	Editor string `toml:"editor,omitempty"`
+	// Command is the command line of a run step, run by the shell in the
+	// project directory
+	Command string `toml:"command,omitempty"`
+	// Port is the port a run step listens on, shown with the process
+	Port int `toml:"port,omitempty"`
+	// Env are the variables set by an env step
+	Env map[string]string `toml:"env,omitempty"`
+}
+
+// String describes the step
+func (s LaunchStep) String() string {
+	switch s.Action {
+	case LaunchEditor:
+		if s.Editor != "" {
+			return "open " + s.Editor
+		}
+		return "open editor"
+	case LaunchTerminal:
+		return "open terminal"
+	case LaunchRun:
+		return "run " + s.Command
+	case LaunchEnv:
+		names := make([]string, 0, len(s.Env))
+		for name := range s.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "set " + strings.Join(names, ", ")
+	}
+	return s.Action
+}
+
+// LaunchSteps returns the steps that start work on a project: those of its
+// ProjectFile once trusted, else of the first launch profile, by name,
+// listing the project, then of the first with one of its tags, then of the
+// default profile. name is "" when no profile applies.
+func (c *Config) LaunchSteps(project, dir string, tags []string) (name string, steps []LaunchStep, err error) {
+	pc, err := LoadTrustedProjectConfig(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pc.Launch.Steps) > 0 {
+		return ProjectFile, pc.Launch.Steps, nil
+	}
+
+	names := make([]string, 0, len(c.Launch))
+	for name := range c.Launch {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, p := range c.Launch[name].Projects {
+			if p == project || filepath.Clean(p) == dir {
+				return name, c.Launch[name].Steps, nil
+			}
+		}
+	}
+	for _, name := range names {
+		for _, tag := range c.Launch[name].Tags {
+			for _, t := range tags {
+				if strings.EqualFold(tag, t) {
+					return name, c.Launch[name].Steps, nil
+				}
+			}
+		}
+	}
+	if p, ok := c.Launch[DefaultLaunchProfile]; ok {
+		return DefaultLaunchProfile, p.Steps, nil
+	}
+	return "", nil, nil
+}
+
+// clone returns a deep copy of the launch profile
+func (p LaunchProfile) clone() LaunchProfile {
+	p.Projects = append([]string(nil), p.Projects...)
+	p.Tags = append([]string(nil), p.Tags...)
+	if p.Steps != nil {
+		steps := make([]LaunchStep, len(p.Steps))
+		for i, s := range p.Steps {
+			if s.Env != nil {
+				env := make(map[string]string, len(s.Env))
+				for k, v := range s.Env {
+					env[k] = v
+				}
+				s.Env = env
+			}
+			steps[i] = s
+		}
+		p.Steps = steps
+	}
+	return p
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLaunchSteps(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := DefaultConfig()
+	cfg.Launch = map[string]LaunchProfile{
+		"api":     {Projects: []string{"api"}, Steps: []LaunchStep{{Action: LaunchTerminal}}},
+		"web":     {Tags: []string{"Web"}, Steps: []LaunchStep{{Action: LaunchRun, Command: "npm run dev"}}},
+		"default": {Steps: []LaunchStep{{Action: LaunchEditor}}},
+	}
+
+	tests := []struct {
+		project string
+		tags    []string
+		want    string
+	}{
+		{"api", []string{"web"}, "api"},
+		{"site", []string{"web"}, "web"},
+		{"other", nil, "default"},
+	}
+	for _, tt := range tests {
+		name, steps, err := cfg.LaunchSteps(tt.project, t.TempDir(), tt.tags)
+		if err != nil {
+			t.Fatalf("LaunchSteps failed: %v", err)
+		}
+		if name != tt.want || len(steps) != 1 {
+			t.Errorf("%s: got profile %q with %d steps, want %q", tt.project, name, len(steps), tt.want)
+		}
+	}
+
+	// A project's own steps come first
+	dir := t.TempDir()
+	project := "[[launch.steps]]\naction = \"env\"\nenv = { PORT = \"3000\" }\n"
+	if err := os.WriteFile(filepath.Join(dir, ProjectFile), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// but only once the file is trusted
+	if _, _, err := cfg.LaunchSteps("api", dir, nil); !errors.As(err, new(*UntrustedError)) {
+		t.Fatalf("expected an untrusted error, got %v", err)
+	}
+	if err := TrustProjectFile(dir, []byte(project)); err != nil {
+		t.Fatal(err)
+	}
+	name, steps, err := cfg.LaunchSteps("api", dir, nil)
+	if err != nil {
+		t.Fatalf("LaunchSteps failed: %v", err)
+	}
+	if name != ProjectFile || len(steps) != 1 || steps[0].Env["PORT"] != "3000" {
+		t.Errorf("expected the steps of %s, got %q %v", ProjectFile, name, steps)
+	}
+}
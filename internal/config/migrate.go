@@ -0,0 +1,219 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CurrentVersion is the config format version written by this build. Bump it
+// and append a migration whenever keys are renamed, retyped or given new
+// defaults that existing files need.
+const CurrentVersion = 2
+
+// migration upgrades a config document to version. It returns a line for
+// each change so the user can see what happened to their file.
+type migration struct {
+	version     int
+	description string
+	apply       func(doc *Document) ([]string, error)
+}
+
+// migrations are applied in order to files older than their version
+var migrations = []migration{
+	{1, "add preferences introduced after the first release", migrateDefaults},
+	{2, "normalize project directories and favorites", migrateProjectPaths},
+}
+
+// MigrationResult describes what Migrate changed
+type MigrationResult struct {
+	From    int
+	To      int
+	Changes []string
+}
+
+// Migrated reports whether the document was upgraded
+func (r *MigrationResult) Migrated() bool {
+	return r.From != r.To
+}
+
+// Summary returns a human readable list of the changes
+func (r *MigrationResult) Summary(path, backup string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Migrated %s from version %d to %d", path, r.From, r.To)
+	if backup != "" {
+		fmt.Fprintf(&b, " (previous file kept as %s)", backup)
+	}
+	b.WriteString(":\n")
+	for _, change := range r.Changes {
+		fmt.Fprintf(&b, "  - %s\n", change)
+	}
+	return b.String()
+}
+
+// Version returns the version recorded in doc. Files written before versions
+// were introduced have no version key and are version 0.
+func Version(doc *Document) (int, error) {
+	raw, ok := doc.Get("", "version")
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := Decode(raw, &version); err != nil {
+		return 0, fmt.Errorf("invalid version %s: %v", raw, err)
+	}
+	return version, nil
+}
+
+// Migrate applies every migration newer than the document's version and
+// records the new version. Files from a newer den are left untouched.
+func Migrate(doc *Document) (*MigrationResult, error) {
+	from, err := Version(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationResult{From: from, To: from}
+	for _, m := range migrations {
+		if m.version <= from {
+			continue
+		}
+		changes, err := m.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("migration to version %d (%s) failed: %v", m.version, m.description, err)
+		}
+		result.Changes = append(result.Changes, changes...)
+		result.To = m.version
+	}
+
+	if result.Migrated() {
+		if err := doc.SetValue("", "version", result.To); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// migrateDefaults adds preferences that older files don't have. Only keys
+// that are missing are filled in; a key the user set, even to an empty
+// value, is kept.
+func migrateDefaults(doc *Document) ([]string, error) {
+	defaults := DefaultConfig().Preferences
+	added := []struct {
+		key   string
+		value any
+	}{
+		{"defaultEditor", defaults.DefaultEditor},
+		{"editorList", defaults.EditorList},
+		{"showGitStatus", defaults.ShowGitStatus},
+		{"gitStatusStyle", defaults.GitStatusStyle},
+		{"theme", defaults.Theme},
+		{"projectListTitle", defaults.ProjectListTitle},
+	}
+
+	var changes []string
+	for _, a := range added {
+		if doc.Has("preferences", a.key) {
+			continue
+		}
+		if err := doc.SetValue("preferences", a.key, a.value); err != nil {
+			return nil, err
+		}
+		raw, _ := doc.Get("preferences", a.key)
+		changes = append(changes, fmt.Sprintf("added preferences.%s = %s", a.key, raw))
+	}
+	return changes, nil
+}
+
+// migrateProjectPaths turns a single directory string into a list and
+// expands a leading ~, which den never did and so never matched anything
+func migrateProjectPaths(doc *Document) ([]string, error) {
+	home, _ := os.UserHomeDir()
+
+	var changes []string
+	for _, key := range []string{"projectDirs", "favorites"} {
+		raw, ok := doc.Get("", key)
+		if !ok {
+			continue
+		}
+
+		var paths []string
+		if err := Decode(raw, &paths); err != nil {
+			var single string
+			if Decode(raw, &single) != nil {
+				return nil, fmt.Errorf("%s must be a list of paths: %v", key, err)
+			}
+			paths = []string{single}
+			changes = append(changes, fmt.Sprintf("converted %s from a string to a list", key))
+		}
+
+		for i, p := range paths {
+			if expanded := expandHome(p, home); expanded != p {
+				paths[i] = expanded
+				changes = append(changes, fmt.Sprintf("expanded %s entry %s to %s", key, p, expanded))
+			}
+		}
+
+		if err := doc.SetValue("", key, paths); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func expandHome(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
+
+// legacyConfigDocument converts the JSON config written by early versions of
+// den into a TOML document with the same keys
+func legacyConfigDocument(data []byte) (*Document, error) {
+	var legacy map[string]any
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("could not parse legacy config: %v", err)
+	}
+
+	doc := ParseDocument(nil)
+	for _, key := range sortedKeys(legacy) {
+		if legacy[key] == nil {
+			continue
+		}
+		table, ok := legacy[key].(map[string]any)
+		if !ok {
+			if err := doc.SetValue("", key, legacy[key]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, sub := range sortedKeys(table) {
+			if table[sub] == nil {
+				continue
+			}
+			if err := doc.SetValue(key, sub, table[sub]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
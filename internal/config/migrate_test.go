@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateDefaultsFillsOnlyMissingKeys(t *testing.T) {
+	doc := ParseDocument([]byte(`[preferences]
+defaultEditor = "vim"
+editorList = []
+showGitStatus = false
+`))
+
+	changes, err := migrateDefaults(doc)
+	if err != nil {
+		t.Fatalf("migrateDefaults failed: %v", err)
+	}
+
+	// Explicit values, including empty and false ones, are kept
+	for key, want := range map[string]string{
+		"defaultEditor": `"vim"`,
+		"editorList":    `[]`,
+		"showGitStatus": `false`,
+	} {
+		if got, _ := doc.Get("preferences", key); got != want {
+			t.Errorf("expected %s to stay %s, got %s", key, want, got)
+		}
+	}
+
+	for key, want := range map[string]string{
+		"gitStatusStyle":   `"text"`,
+		"theme":            `"default"`,
+		"projectListTitle": `"Projects"`,
+	} {
+		if got, _ := doc.Get("preferences", key); got != want {
+			t.Errorf("expected %s to be added as %s, got %q", key, want, got)
+		}
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got %v", changes)
+	}
+}
+
+func TestMigrateDefaultsAddsShowGitStatus(t *testing.T) {
+	doc := ParseDocument([]byte("projectDirs = []\n"))
+
+	if _, err := migrateDefaults(doc); err != nil {
+		t.Fatalf("migrateDefaults failed: %v", err)
+	}
+
+	// An unset showGitStatus used to decode as false
+	if got, _ := doc.Get("preferences", "showGitStatus"); got != "true" {
+		t.Errorf("expected showGitStatus = true, got %q", got)
+	}
+}
+
+func TestMigrateProjectPaths(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+
+	doc := ParseDocument([]byte(`projectDirs = "~/code"
+favorites = ["~/code/den", "/srv/app"]
+`))
+
+	changes, err := migrateProjectPaths(doc)
+	if err != nil {
+		t.Fatalf("migrateProjectPaths failed: %v", err)
+	}
+
+	if got, _ := doc.Get("", "projectDirs"); got != `["/home/me/code"]` {
+		t.Errorf("unexpected projectDirs: %s", got)
+	}
+	if got, _ := doc.Get("", "favorites"); got != `["/home/me/code/den", "/srv/app"]` {
+		t.Errorf("unexpected favorites: %s", got)
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got %v", changes)
+	}
+}
+
+func TestMigrateProjectPathsRejectsOtherTypes(t *testing.T) {
+	doc := ParseDocument([]byte("projectDirs = 42\n"))
+
+	if _, err := migrateProjectPaths(doc); err == nil {
+		t.Error("expected an error for a number")
+	}
+}
+
+func TestMigrateRecordsVersion(t *testing.T) {
+	doc := ParseDocument([]byte("version = 1\nprojectDirs = \"/srv\"\n"))
+
+	result, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if result.From != 1 || result.To != CurrentVersion {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	// Version 1 already has its defaults
+	if doc.Has("preferences", "theme") {
+		t.Error("migration 1 should not run again")
+	}
+	if got, _ := doc.Get("", "version"); got != "2" {
+		t.Errorf("expected version 2, got %s", got)
+	}
+
+	again, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if again.Migrated() {
+		t.Errorf("expected no migration for a current file, got %+v", again)
+	}
+}
+
+func TestMigrateLeavesNewerFilesAlone(t *testing.T) {
+	data := "version = 99\nprojectDirs = \"~/code\"\n"
+	doc := ParseDocument([]byte(data))
+
+	result, err := Migrate(doc)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if result.Migrated() || string(doc.Bytes()) != data {
+		t.Errorf("newer file was changed:\n%s", doc.Bytes())
+	}
+}
+
+func TestLoadConfigBacksUpBeforeMigrating(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+
+	configDir := filepath.Join(tmpDir, "den")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	old := "# mine\nprojectDirs = [\"/srv\"]\n"
+	if err := os.WriteFile(configPath, []byte(old), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("expected version %d, got %d", CurrentVersion, cfg.Version)
+	}
+
+	backup, err := os.ReadFile(configPath + ".v0.bak")
+	if err != nil {
+		t.Fatalf("backup not written: %v", err)
+	}
+	if string(backup) != old {
+		t.Errorf("backup does not hold the original file:\n%s", backup)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read migrated config: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# mine\n") || !strings.Contains(string(data), "version = 2") {
+		t.Errorf("unexpected migrated config:\n%s", data)
+	}
+}
+
+func TestLoadConfigImportsLegacyJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+
+	configDir := filepath.Join(tmpDir, "den")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	// The format written by scripts/test-goto.sh
+	legacy := `{
+    "projectDirs": ["/tmp/den-test-goto"],
+    "preferences": {
+        "defaultEditor": "code",
+        "editorList": ["code", "vim", "nano"],
+        "showHiddenFiles": false,
+        "showGitStatus": true,
+        "theme": "default",
+        "projectListTitle": "Test Projects"
+    }
+}`
+	legacyPath := filepath.Join(configDir, "config.json")
+	if err := os.WriteFile(legacyPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy config: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.ProjectDirs[0] != "/tmp/den-test-goto" || cfg.Preferences.ProjectListTitle != "Test Projects" {
+		t.Errorf("legacy values not imported: %+v", cfg)
+	}
+	if cfg.Preferences.GitStatusStyle != "text" {
+		t.Errorf("expected missing gitStatusStyle to be migrated, got %q", cfg.Preferences.GitStatusStyle)
+	}
+
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Error("legacy config should have been moved aside")
+	}
+	if _, err := os.Stat(legacyPath + ".bak"); err != nil {
+		t.Errorf("legacy config backup missing: %v", err)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig after import failed: %v", err)
+	}
+	if reloaded.Preferences.ProjectListTitle != "Test Projects" || reloaded.Version != CurrentVersion {
+		t.Errorf("imported config not saved as TOML: %+v", reloaded)
+	}
+}
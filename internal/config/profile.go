@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// Profile holds the settings that differ between setups, such as work and
+// personal. Each profile has its own project directories and favorites; an
+// empty theme or editor falls back to the top-level preferences.
+type Profile struct {
+	ProjectDirs []string `toml:"projectDirs"`
+	Favorites   []string `toml:"favorites"`
+	Theme       string   `toml:"theme,omitempty"`
+	Editor      string   `toml:"editor,omitempty"`
+}
+
+// profileBase holds the top-level values replaced by the active profile
+type profileBase struct {
+	projectDirs []string
+	favorites   []string
+	theme       string
+	editor      string
+}
+
+// profileNamePattern keeps profile names usable in cache file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// profileOverride is the profile given with --profile
+var profileOverride string
+
+// SetProfile selects a profile for this run, overriding DEN_PROFILE and the
+// profile key in the config file
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// SelectedProfile returns the profile to use: --profile, then DEN_PROFILE,
+// then the profile key. An empty name means no profile.
+func SelectedProfile(cfg *Config) string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if name := os.Getenv("DEN_PROFILE"); name != "" {
+		return name
+	}
+	return cfg.Profile
+}
+
+// ActiveProfile returns the name of the applied profile, or "" if none
+func (c *Config) ActiveProfile() string {
+	return c.active
+}
+
+// ProfileNames returns the configured profile names, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile applies a profile: its project directories, favorites, theme
+// and editor replace the top-level values until another profile is used.
+// Changes made meanwhile are saved into the profile. An empty name goes
+// back to the top-level values.
+func (c *Config) UseProfile(name string) error {
+	if name != "" {
+		if _, ok := c.Profiles[name]; !ok {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+	}
+
+	c.storeProfile()
+	if name == "" {
+		return nil
+	}
+
+	p := c.Profiles[name]
+	c.base = &profileBase{
+		projectDirs: c.ProjectDirs,
+		favorites:   c.Favorites,
+		theme:       c.Preferences.Theme,
+		editor:      c.Preferences.DefaultEditor,
+	}
+	c.ProjectDirs = append([]string{}, p.ProjectDirs...)
+	c.Favorites = append([]string{}, p.Favorites...)
+	if p.Theme != "" {
+		c.Preferences.Theme = p.Theme
+	}
+	if p.Editor != "" {
+		c.Preferences.DefaultEditor = p.Editor
+	}
+	c.active = name
+	return nil
+}
+
+// storeProfile moves the active values back into the active profile and
+// restores the top-level values
+func (c *Config) storeProfile() {
+	if c.active == "" {
+		return
+	}
+
+	p := c.Profiles[c.active]
+	p.ProjectDirs = c.ProjectDirs
+	p.Favorites = c.Favorites
+	// Only pin theme and editor if the profile had them or they were changed
+	if p.Theme != "" || c.Preferences.Theme != c.base.theme {
+		p.Theme = c.Preferences.Theme
+	}
+	if p.Editor != "" || c.Preferences.DefaultEditor != c.base.editor {
+		p.Editor = c.Preferences.DefaultEditor
+	}
+	c.Profiles[c.active] = p
+
+	c.ProjectDirs = c.base.projectDirs
+	c.Favorites = c.base.favorites
+	c.Preferences.Theme = c.base.theme
+	c.Preferences.DefaultEditor = c.base.editor
+	c.active = ""
+	c.base = nil
+}
+
+// persisted returns the config as it is stored on disk, with the active
+// profile's values in its own table
+func (c *Config) persisted() *Config {
+	out := c.Clone()
+	out.storeProfile()
+	return out
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profileConfig = `version = 2
+projectDirs = ["/home/me/code"]
+favorites = ["/home/me/code/den"]
+
+[preferences]
+defaultEditor = "vim"
+theme = "nord"
+
+[profiles.work]
+projectDirs = ["/home/me/work"]
+favorites = []
+editor = "code"
+`
+
+func writeProfileConfig(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("DEN_CONFIG", "")
+	t.Setenv("DEN_PROFILE", "")
+
+	configDir := filepath.Join(tmpDir, "den")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(profileConfig), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadConfigAppliesProfile(t *testing.T) {
+	writeProfileConfig(t)
+	t.Setenv("DEN_PROFILE", "work")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.ActiveProfile() != "work" {
+		t.Errorf("expected work profile, got %q", cfg.ActiveProfile())
+	}
+	if cfg.ProjectDirs[0] != "/home/me/work" || len(cfg.Favorites) != 0 {
+		t.Errorf("profile directories not applied: %+v", cfg)
+	}
+	if cfg.Preferences.DefaultEditor != "code" {
+		t.Errorf("expected profile editor, got %q", cfg.Preferences.DefaultEditor)
+	}
+	// Theme is not set in the profile, so the top-level one is used
+	if cfg.Preferences.Theme != "nord" {
+		t.Errorf("expected top-level theme, got %q", cfg.Preferences.Theme)
+	}
+
+	// --profile wins over DEN_PROFILE
+	SetProfile("missing")
+	defer SetProfile("")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), `unknown profile "missing"`) {
+		t.Errorf("expected unknown profile error, got %v", err)
+	}
+}
+
+func TestSaveConfigWritesProfileValues(t *testing.T) {
+	configPath := writeProfileConfig(t)
+	t.Setenv("DEN_PROFILE", "work")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	cfg.Favorites = append(cfg.Favorites, "/home/me/work/api")
+	cfg.Preferences.Theme = "dracula"
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read saved config: %v", err)
+	}
+	got := string(data)
+
+	// Top-level values are untouched
+	for _, want := range []string{
+		`projectDirs = ["/home/me/code"]`,
+		`favorites = ["/home/me/code/den"]`,
+		`theme = "nord"`,
+		"[profiles.work]\nprojectDirs = [\"/home/me/work\"]\nfavorites = [\"/home/me/work/api\"]\neditor = \"code\"\ntheme = \"dracula\"",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected saved config to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestUseProfileSwitchesBack(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProjectDirs = []string{"/home/me/code"}
+	cfg.Profiles = map[string]Profile{
+		"work": {ProjectDirs: []string{"/home/me/work"}, Theme: "gruvbox"},
+	}
+
+	if err := cfg.UseProfile("work"); err != nil {
+		t.Fatalf("UseProfile failed: %v", err)
+	}
+	cfg.ProjectDirs = append(cfg.ProjectDirs, "/srv/work")
+
+	if err := cfg.UseProfile(""); err != nil {
+		t.Fatalf("UseProfile failed: %v", err)
+	}
+	if cfg.ActiveProfile() != "" || cfg.Preferences.Theme != "default" || len(cfg.ProjectDirs) != 1 {
+		t.Errorf("top-level values not restored: %+v", cfg)
+	}
+	if dirs := cfg.Profiles["work"].ProjectDirs; len(dirs) != 2 || dirs[1] != "/srv/work" {
+		t.Errorf("profile changes were lost: %v", dirs)
+	}
+}
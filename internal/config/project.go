@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ProjectFile is the name of the optional config file in a project
+const ProjectFile = ".den.toml"
+
+// ProjectConfig is the config of a single project, read from its
+// ProjectFile. Its settings take precedence over the user's config.
+type ProjectConfig struct {
+	Tmux TmuxConfig `toml:"tmux"`
+	// Launch holds the steps that start work on the project
+	Launch LaunchProfile `toml:"launch"`
+	// Commands are custom commands for the project
+	Commands map[string]CommandConfig `toml:"commands"`
+}
+
+// LoadProjectConfig reads the ProjectFile of the project at dir, whether
+// the user trusted it or not, see LoadTrustedProjectConfig. Projects
+// without one have an empty config.
+func LoadProjectConfig(dir string) (*ProjectConfig, error) {
+	path := filepath.Join(dir, ProjectFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ProjectConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseProjectConfig(path, data)
+}
+
+func parseProjectConfig(path string, data []byte) (*ProjectConfig, error) {
+	var cfg ProjectConfig
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// TmuxLayout returns the windows of a tmux session for the project at dir:
+// from its ProjectFile, once trusted, else from the [tmux] table
+func (c *Config) TmuxLayout(dir string) ([]TmuxWindow, error) {
+	project, err := LoadTrustedProjectConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(project.Tmux.Windows) > 0 {
+		return project.Tmux.Windows, nil
+	}
+	return c.Tmux.clone().Windows, nil
+}
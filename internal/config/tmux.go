@@ -0,0 +1,30 @@
+package config
+
+// TmuxConfig is the [tmux] table: the layout of sessions den creates. A
+// project's .den.toml can define its own.
+type TmuxConfig struct {
+	Windows []TmuxWindow `toml:"windows,omitempty"`
+}
+
+// TmuxWindow is a [[tmux.windows]] entry
+type TmuxWindow struct {
+	Name string `toml:"name,omitempty"`
+	// Panes are the commands run in each pane, "" for a plain shell. A
+	// window without panes has a single shell.
+	Panes []string `toml:"panes,omitempty"`
+	// Layout is a tmux layout such as "main-vertical" or "tiled"
+	Layout string `toml:"layout,omitempty"`
+}
+
+// clone returns a deep copy of the tmux config
+func (t TmuxConfig) clone() TmuxConfig {
+	if t.Windows == nil {
+		return t
+	}
+	windows := make([]TmuxWindow, len(t.Windows))
+	for i, w := range t.Windows {
+		w.Panes = append([]string(nil), w.Panes...)
+		windows[i] = w
+	}
+	return TmuxConfig{Windows: windows}
+}
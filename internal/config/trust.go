@@ -0,0 +1,131 @@
+package config
+
+import (
+	"crypto/sha256"
+	"den/internal/fsutil"
+	"den/internal/paths"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// A ProjectFile comes with the repository and can run commands, so den only
+// uses one the user trusted. Trust is kept in the state directory by path,
+// with a hash of the content that was trusted: any change to the file has
+// to be trusted again.
+
+// trustFile holds the hashes of the trusted project files by path
+const trustFile = "trusted-projects.json"
+
+// UntrustedError is returned for a ProjectFile the user did not trust, or
+// that changed since it was trusted
+type UntrustedError struct {
+	// Dir is the project and Path its ProjectFile
+	Dir  string
+	Path string
+	// Data is the content of the file, to show before trusting it
+	Data []byte
+	// Changed is set when another version of the file was trusted
+	Changed bool
+}
+
+func (e *UntrustedError) Error() string {
+	if e.Changed {
+		return fmt.Sprintf("%s changed since it was trusted, review it and trust it again", e.Path)
+	}
+	return fmt.Sprintf("%s is not trusted yet, review it and trust it first", e.Path)
+}
+
+// projectFilePath returns the ProjectFile of the project at dir
+func projectFilePath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Join(dir, ProjectFile)
+}
+
+func hashProjectFile(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadTrustedProjectConfig is LoadProjectConfig for a ProjectFile the user
+// trusted with its current content. It returns an *UntrustedError for any
+// other.
+func LoadTrustedProjectConfig(dir string) (*ProjectConfig, error) {
+	path := projectFilePath(dir)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ProjectConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trusted, err := loadTrust()
+	if err != nil {
+		return nil, err
+	}
+	if hash, ok := trusted[path]; !ok || hash != hashProjectFile(data) {
+		return nil, &UntrustedError{Dir: dir, Path: path, Data: data, Changed: ok}
+	}
+	return parseProjectConfig(path, data)
+}
+
+// TrustProjectFile trusts the ProjectFile of the project at dir with the
+// given content, which is what the user reviewed. A file that changed
+// meanwhile stays untrusted.
+func TrustProjectFile(dir string, data []byte) error {
+	storePath, err := paths.StateFile(trustFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := fsutil.LockFile(storePath, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	trusted, err := readTrust(storePath)
+	if err != nil {
+		return err
+	}
+	trusted[projectFilePath(dir)] = hashProjectFile(data)
+	out, err := json.MarshalIndent(trusted, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(storePath, out, 0600)
+}
+
+// loadTrust returns the hashes of the trusted project files by path
+func loadTrust() (map[string]string, error) {
+	storePath, err := paths.StateFile(trustFile)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := fsutil.LockFile(storePath, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return readTrust(storePath)
+}
+
+func readTrust(storePath string) (map[string]string, error) {
+	trusted := make(map[string]string)
+	data, err := os.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &trusted); err != nil {
+		// Trusting everything again is safer than trusting anything
+		return make(map[string]string), nil
+	}
+	return trusted, nil
+}
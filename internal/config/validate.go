@@ -0,0 +1,413 @@
+package config
+
+import (
+	"bytes"
+	"den/internal/shellwords"
+	"den/internal/theme"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// GitStatusStyles are the supported values for preferences.gitStatusStyle
+var GitStatusStyles = []string{"text", "nerd"}
+
+// Severity is how serious a config problem is
+type Severity int
+
+const (
+	// SeverityWarning marks problems den can work around
+	SeverityWarning Severity = iota
+	// SeverityError marks problems that break a feature
+	SeverityError
+)
+
+// Problem is a single issue found while validating a config
+type Problem struct {
+	Severity Severity
+	File     string
+	Line     int
+	Key      string
+	Message  string
+}
+
+func (p Problem) Error() string {
+	var loc string
+	if p.File != "" {
+		loc = filepath.Base(p.File)
+		if p.Line > 0 {
+			loc += fmt.Sprintf(":%d", p.Line)
+		}
+		loc += ": "
+	}
+	if p.Key != "" {
+		loc += p.Key + ": "
+	}
+	return loc + p.Message
+}
+
+// Report collects the problems found in a config file
+type Report struct {
+	File     string
+	Problems []Problem
+	doc      *Document
+}
+
+// NewReport creates a report for the config file at path. The file is read
+// to locate problems; a missing or unreadable file just means no line numbers.
+func NewReport(path string) *Report {
+	r := &Report{File: path}
+	if data, err := os.ReadFile(path); err == nil {
+		r.doc = ParseDocument(data)
+	}
+	return r
+}
+
+// Errorf records an error for a dotted key such as "preferences.theme"
+func (r *Report) Errorf(key, format string, args ...any) {
+	r.add(SeverityError, key, fmt.Sprintf(format, args...))
+}
+
+// Warnf records a warning for a dotted key such as "preferences.theme"
+func (r *Report) Warnf(key, format string, args ...any) {
+	r.add(SeverityWarning, key, fmt.Sprintf(format, args...))
+}
+
+func (r *Report) add(severity Severity, key, message string) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		File:     r.File,
+		Line:     r.line(key),
+		Key:      key,
+		Message:  message,
+	})
+}
+
+// line finds the line of a dotted key, falling back to its table header
+func (r *Report) line(key string) int {
+	if r.doc == nil || key == "" {
+		return 0
+	}
+	table, name := "", key
+	if dot := strings.LastIndex(key, "."); dot >= 0 {
+		table, name = key[:dot], key[dot+1:]
+	}
+	if line := r.doc.Line(table, name); line > 0 {
+		return line
+	}
+	return r.doc.TableLine(key)
+}
+
+// HasErrors reports whether any problem is an error
+func (r *Report) HasErrors() bool {
+	return r.Err() != nil
+}
+
+// Err returns the first error in the report, or nil if there are only warnings
+func (r *Report) Err() error {
+	for _, p := range r.Problems {
+		if p.Severity == SeverityError {
+			return p
+		}
+	}
+	return nil
+}
+
+// ValidateFile parses the config file at path and validates its contents.
+// Unlike LoadConfig it never rewrites the file.
+func ValidateFile(path string) (*Config, *Report) {
+	r := NewReport(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.Warnf("", "config file does not exist, defaults are used")
+			cfg := DefaultConfig()
+			r.validate(cfg)
+			return cfg, r
+		}
+		r.Errorf("", "could not read config file: %v", err)
+		return nil, r
+	}
+
+	var cfg Config
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		p := Problem{Severity: SeverityError, File: path, Message: err.Error()}
+		var derr *toml.DecodeError
+		if errors.As(err, &derr) {
+			p.Line, _ = derr.Position()
+		}
+		r.Problems = append(r.Problems, p)
+		return nil, r
+	}
+
+	// Unknown keys are kept on save, but they are often typos
+	dec := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+	var strict Config
+	var missing *toml.StrictMissingError
+	if err := dec.Decode(&strict); errors.As(err, &missing) {
+		for _, e := range missing.Errors {
+			row, _ := e.Position()
+			r.Problems = append(r.Problems, Problem{
+				Severity: SeverityWarning,
+				File:     path,
+				Line:     row,
+				Key:      strings.Join(e.Key(), "."),
+				Message:  "unknown key",
+			})
+		}
+	}
+
+	if cfg.Version > CurrentVersion {
+		r.Warnf("version", "config was written by a newer den (version %d, this build knows %d)", cfg.Version, CurrentVersion)
+	} else if cfg.Version < CurrentVersion {
+		r.Warnf("version", "config is version %d and will be migrated to %d the next time den runs", cfg.Version, CurrentVersion)
+	}
+
+	fillMissingDefaults(&cfg, ParseDocument(data))
+	r.validate(&cfg)
+	return &cfg, r
+}
+
+// Validate checks a config and returns the problems found. Line numbers
+// refer to the config file on disk.
+func Validate(cfg *Config) *Report {
+	path, _ := GetConfigPath()
+	r := NewReport(path)
+	r.validate(cfg)
+	return r
+}
+
+func (r *Report) validate(cfg *Config) {
+	prefs := cfg.Preferences
+
+	if strings.TrimSpace(prefs.DefaultEditor) == "" {
+		r.Errorf("preferences.defaultEditor", "editor cannot be empty")
+	} else if program := editorProgram(cfg, prefs.DefaultEditor); program != "" {
+		if _, err := exec.LookPath(program); err != nil {
+			r.Errorf("preferences.defaultEditor", "editor %q not found in PATH", program)
+		}
+	}
+
+	for _, ed := range prefs.EditorList {
+		if program := editorProgram(cfg, ed); program != "" {
+			if _, err := exec.LookPath(program); err != nil {
+				r.Warnf("preferences.editorList", "editor %q not found in PATH", program)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Editors))
+	for name := range cfg.Editors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ed := cfg.Editors[name]
+		table := "editors." + name
+		words, err := shellwords.Split(ed.Command)
+		switch {
+		case err != nil:
+			r.Errorf(table+".command", "cannot parse command: %v", err)
+		case len(words) == 0:
+			r.Errorf(table+".command", "command cannot be empty")
+		default:
+			if _, err := exec.LookPath(words[0]); err != nil {
+				r.Warnf(table+".command", "editor %q not found in PATH", words[0])
+			}
+		}
+		if ed.Mode != "" && !contains(EditorModes, ed.Mode) {
+			r.Errorf(table+".mode", "unknown mode %q%s", ed.Mode, suggest(ed.Mode, EditorModes))
+		}
+	}
+
+	launchNames := make([]string, 0, len(cfg.Launch))
+	for name := range cfg.Launch {
+		launchNames = append(launchNames, name)
+	}
+	sort.Strings(launchNames)
+	for _, name := range launchNames {
+		r.validateLaunchSteps("launch."+name+".steps", cfg, cfg.Launch[name].Steps)
+	}
+
+	commandNames := make([]string, 0, len(cfg.Commands))
+	for name := range cfg.Commands {
+		commandNames = append(commandNames, name)
+	}
+	sort.Strings(commandNames)
+	for _, name := range commandNames {
+		if strings.TrimSpace(cfg.Commands[name].Run) == "" {
+			r.Errorf("commands."+name+".run", "command cannot be empty")
+		}
+	}
+
+	if prefs.DefaultFileManager != "" {
+		if _, err := exec.LookPath(prefs.DefaultFileManager); err != nil {
+			r.Errorf("preferences.defaultFileManager", "file manager %q not found in PATH", prefs.DefaultFileManager)
+		}
+	}
+
+	if prefs.Terminal != "" {
+		words, err := shellwords.Split(prefs.Terminal)
+		switch {
+		case err != nil:
+			r.Errorf("preferences.terminal", "invalid command: %v", err)
+		case len(words) == 0:
+			r.Errorf("preferences.terminal", "command cannot be empty")
+		default:
+			if _, err := exec.LookPath(words[0]); err != nil {
+				r.Errorf("preferences.terminal", "terminal %q not found in PATH", words[0])
+			}
+		}
+	}
+
+	if _, ok := theme.Themes[prefs.Theme]; !ok {
+		r.Errorf("preferences.theme", "unknown theme %q%s", prefs.Theme, suggest(prefs.Theme, theme.ListThemes()))
+	}
+
+	if !contains(GitStatusStyles, prefs.GitStatusStyle) {
+		r.Errorf("preferences.gitStatusStyle", "unknown git status style %q%s", prefs.GitStatusStyle, suggest(prefs.GitStatusStyle, GitStatusStyles))
+	}
+
+	if strings.TrimSpace(prefs.ProjectListTitle) == "" {
+		r.Errorf("preferences.projectListTitle", "list title cannot be empty")
+	}
+
+	r.validateDirs("projectDirs", cfg.ProjectDirs)
+
+	if cfg.Profile != "" {
+		if _, ok := cfg.Profiles[cfg.Profile]; !ok {
+			r.Errorf("profile", "unknown profile %q%s", cfg.Profile, suggest(cfg.Profile, cfg.ProfileNames()))
+		}
+	}
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		table := "profiles." + name
+		if !profileNamePattern.MatchString(name) {
+			r.Errorf(table, "profile names may only contain letters, digits, - and _")
+		}
+		if p.Theme != "" {
+			if _, ok := theme.Themes[p.Theme]; !ok {
+				r.Errorf(table+".theme", "unknown theme %q%s", p.Theme, suggest(p.Theme, theme.ListThemes()))
+			}
+		}
+		if program := editorProgram(cfg, p.Editor); program != "" {
+			if _, err := exec.LookPath(program); err != nil {
+				r.Errorf(table+".editor", "editor %q not found in PATH", program)
+			}
+		}
+		r.validateDirs(table+".projectDirs", p.ProjectDirs)
+	}
+}
+
+func (r *Report) validateDirs(key string, dirs []string) {
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			r.Warnf(key, "directory is not an absolute path: %s", dir)
+		}
+		if info, err := os.Stat(dir); err != nil {
+			r.Warnf(key, "cannot access directory: %v", err)
+		} else if !info.IsDir() {
+			r.Errorf(key, "not a directory: %s", dir)
+		}
+		if seen[dir] {
+			r.Warnf(key, "directory listed twice: %s", dir)
+		}
+		seen[dir] = true
+	}
+}
+
+// validateLaunchSteps reports steps that can't run
+func (r *Report) validateLaunchSteps(key string, cfg *Config, steps []LaunchStep) {
+	if len(steps) == 0 {
+		r.Warnf(key, "launch profile has no steps")
+	}
+	for i, step := range steps {
+		switch step.Action {
+		case LaunchEditor:
+			if program := editorProgram(cfg, step.Editor); step.Editor != "" && program != "" {
+				if _, err := exec.LookPath(program); err != nil {
+					r.Warnf(key, "step %d: editor %q not found in PATH", i+1, program)
+				}
+			}
+		case LaunchRun:
+			if strings.TrimSpace(step.Command) == "" {
+				r.Errorf(key, "step %d: run needs a command", i+1)
+			}
+		case LaunchEnv:
+			if len(step.Env) == 0 {
+				r.Errorf(key, "step %d: env needs variables to set", i+1)
+			}
+		case LaunchTerminal:
+		default:
+			r.Errorf(key, "step %d: unknown action %q%s", i+1, step.Action, suggest(step.Action, LaunchActions))
+		}
+	}
+}
+
+// editorProgram returns the program an editor setting runs: the first word
+// of an editor profile's command, or of the setting itself. It is "" when
+// there is nothing to check, including profiles with a broken command,
+// which are reported on their own.
+func editorProgram(cfg *Config, editor string) string {
+	command := editor
+	if ed, ok := cfg.Editors[editor]; ok {
+		command = ed.Command
+	}
+	words, err := shellwords.Split(command)
+	if err != nil || len(words) == 0 {
+		return ""
+	}
+	return words[0]
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns a "did you mean" hint for the closest candidate
+func suggest(s string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(s), c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
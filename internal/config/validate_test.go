@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFileReportsLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	data := `projectDirs = []
+favorit = []
+
+[preferences]
+defaultEditor = "sh"
+theme = "drakula"
+gitStatusStyle = "text"
+projectListTitle = "Projects"
+`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	_, report := ValidateFile(configPath)
+
+	var themeErr, unknownKey *Problem
+	for i, p := range report.Problems {
+		switch p.Key {
+		case "preferences.theme":
+			themeErr = &report.Problems[i]
+		case "favorit":
+			unknownKey = &report.Problems[i]
+		}
+	}
+
+	if themeErr == nil {
+		t.Fatalf("expected a theme problem, got %v", report.Problems)
+	}
+	if themeErr.Severity != SeverityError || themeErr.Line != 6 {
+		t.Errorf("unexpected theme problem: %+v", themeErr)
+	}
+	if want := `config.toml:6: preferences.theme: unknown theme "drakula" (did you mean "dracula"?)`; themeErr.Error() != want {
+		t.Errorf("expected %q, got %q", want, themeErr.Error())
+	}
+
+	if unknownKey == nil {
+		t.Fatalf("expected an unknown key warning, got %v", report.Problems)
+	}
+	if unknownKey.Severity != SeverityWarning || unknownKey.Line != 2 {
+		t.Errorf("unexpected unknown key problem: %+v", unknownKey)
+	}
+	if !report.HasErrors() {
+		t.Error("expected report to have errors")
+	}
+}
+
+func TestValidateFileParseError(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("projectDirs = []\n[preferences\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, report := ValidateFile(configPath)
+	if cfg != nil {
+		t.Error("expected no config for a file that does not parse")
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Line != 2 {
+		t.Errorf("expected one problem on line 2, got %+v", report.Problems)
+	}
+}
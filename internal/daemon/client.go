@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"bufio"
+	"den/internal/cache"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+// dialTimeout is how long a client waits for the daemon to accept
+const dialTimeout = 200 * time.Millisecond
+
+// requestTimeouts bound how long a client waits for an answer. A refresh
+// may have to detect many projects.
+var requestTimeouts = map[string]time.Duration{
+	MethodRefresh: 2 * time.Minute,
+}
+
+const defaultRequestTimeout = 5 * time.Second
+
+// Call sends a request to the daemon serving profile. It fails quickly when
+// no daemon is running, so callers can fall back to doing the work.
+func Call(profile, method string) (*Response, error) {
+	socket, err := SocketPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", socket, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("daemon is not running")
+	}
+	defer conn.Close()
+
+	timeout, ok := requestTimeouts[method]
+	if !ok {
+		timeout = defaultRequestTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(Request{Version: ProtocolVersion, Method: method}); err != nil {
+		return nil, fmt.Errorf("could not send request: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %v", err)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	if resp.Version != ProtocolVersion {
+		return nil, fmt.Errorf("daemon speaks protocol version %d, expected %d", resp.Version, ProtocolVersion)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("%s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// Index returns the projects held by the daemon, refreshed first if
+// refresh is set
+func Index(profile string, refresh bool) (*cache.ProjectCache, error) {
+	method := MethodProjects
+	if refresh {
+		method = MethodRefresh
+	}
+	resp, err := Call(profile, method)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Index == nil {
+		return nil, fmt.Errorf("daemon sent no projects")
+	}
+	return resp.Index, nil
+}
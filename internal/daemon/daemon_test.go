@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"bufio"
+	"den/internal/config"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemonServesIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("DEN_PROFILE", "")
+
+	root := filepath.Join(tmpDir, "code")
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("version = 2\nprojectDirs = [\""+root+"\"]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv("DEN_CONFIG", configPath)
+
+	if _, err := Call("", MethodStatus); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Fatalf("expected no daemon to be running, got %v", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(cfg, log.New(io.Discard, "", 0))
+	}()
+
+	var status *Status
+	for i := 0; i < 100 && status == nil; i++ {
+		if resp, err := Call("", MethodStatus); err == nil {
+			status = resp.Status
+		} else {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if status == nil {
+		select {
+		case err := <-served:
+			t.Fatalf("daemon did not start: %v", err)
+		default:
+			t.Fatal("daemon did not start")
+		}
+	}
+	if status.PID != os.Getpid() || status.Projects != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	// New projects show up on refresh
+	if err := os.Mkdir(filepath.Join(root, "web"), 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	index, err := Index("", true)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if len(index.Projects) != 2 {
+		t.Errorf("expected 2 projects after refresh, got %d", len(index.Projects))
+	}
+
+	// Requests of another protocol version are refused
+	socket, _ := SocketPath("")
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	json.NewEncoder(conn).Encode(Request{Version: ProtocolVersion + 1, Method: MethodStatus})
+	line, _ := bufio.NewReader(conn).ReadBytes('\n')
+	conn.Close()
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil || !strings.Contains(resp.Error, "unsupported protocol version") {
+		t.Errorf("expected a protocol version error, got %q, %v", line, err)
+	}
+
+	if _, err := Call("", MethodStop); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("expected the socket to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"den/internal/cache"
+	"den/internal/paths"
+	"time"
+)
+
+// ProtocolVersion is the version of the requests and responses. Bump it
+// on incompatible changes; the daemon refuses requests of other versions
+// and clients then fall back to scanning themselves.
+const ProtocolVersion = 1
+
+// Methods a client can call
+const (
+	MethodStatus   = "status"   // describe the daemon
+	MethodProjects = "projects" // return the index
+	MethodRefresh  = "refresh"  // rescan, then return the index
+	MethodStop     = "stop"     // shut the daemon down
+)
+
+// Request is sent by a client as a single line of JSON. Each connection
+// carries one request and one response.
+type Request struct {
+	Version int    `json:"version"`
+	Method  string `json:"method"`
+}
+
+// Response answers a Request, also as a single line of JSON
+type Response struct {
+	Version int                 `json:"version"`
+	Error   string              `json:"error,omitempty"`
+	Status  *Status             `json:"status,omitempty"`
+	Index   *cache.ProjectCache `json:"index,omitempty"`
+}
+
+// Status describes a running daemon
+type Status struct {
+	PID         int       `json:"pid"`
+	Profile     string    `json:"profile"`
+	Started     time.Time `json:"started"`
+	Projects    int       `json:"projects"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	Watching    bool      `json:"watching"`
+}
+
+// SocketPath returns the socket of the daemon serving a config profile.
+// Each profile has its own daemon.
+func SocketPath(profile string) (string, error) {
+	if profile != "" {
+		return paths.StateFile("daemon-" + profile + ".sock")
+	}
+	return paths.StateFile("daemon.sock")
+}
+
+// LogPath returns the log file of the daemon serving a config profile
+func LogPath(profile string) (string, error) {
+	if profile != "" {
+		return paths.StateFile("daemon-" + profile + ".log")
+	}
+	return paths.StateFile("daemon.log")
+}
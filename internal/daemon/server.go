@@ -0,0 +1,281 @@
+package daemon
+
+import (
+	"bufio"
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/project"
+	"den/internal/watch"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// GitInterval is how often the daemon detects every project again, which
+// catches changes to the working tree that don't touch .git
+const GitInterval = 2 * time.Minute
+
+// readTimeout bounds how long a client may take to send its request
+const readTimeout = 5 * time.Second
+
+// Server keeps the project index of one config profile in memory and
+// serves it over a unix socket
+type Server struct {
+	profile string
+	socket  string
+	started time.Time
+	log     *log.Logger
+	watcher *watch.Watcher
+
+	// scanMu makes rescans run one at a time
+	scanMu sync.Mutex
+
+	mu    sync.Mutex
+	cfg   *config.Config
+	index *cache.ProjectCache
+
+	listener net.Listener
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Serve runs the daemon for the config's active profile until it is
+// stopped by a client or a signal
+func Serve(cfg *config.Config, logger *log.Logger) error {
+	profile := cfg.ActiveProfile()
+	socket, err := SocketPath(profile)
+	if err != nil {
+		return err
+	}
+	if _, err := Call(profile, MethodStatus); err == nil {
+		return fmt.Errorf("daemon is already running")
+	}
+	// Left behind by a daemon that crashed
+	os.Remove(socket)
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s: %v", socket, err)
+	}
+	os.Chmod(socket, 0600)
+
+	s := &Server{
+		profile:  profile,
+		socket:   socket,
+		started:  time.Now(),
+		log:      logger,
+		cfg:      cfg,
+		listener: listener,
+		done:     make(chan struct{}),
+	}
+
+	index, err := cache.LoadCache(profile)
+	if err != nil {
+		s.log.Printf("rebuilding cache: %v", err)
+	}
+	s.index = index
+
+	if w, err := watch.New(watch.DefaultLimit()); err == nil {
+		s.watcher = w
+		defer w.Close()
+	} else {
+		s.log.Printf("not watching for changes: %v", err)
+	}
+
+	s.rescan(false)
+	go s.loop()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case sig := <-signals:
+			s.log.Printf("stopping on %v", sig)
+			s.stop()
+		case <-s.done:
+		}
+	}()
+
+	s.log.Printf("serving profile %q on %s", profile, socket)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				os.Remove(socket)
+				return nil
+			default:
+				s.stop()
+				os.Remove(socket)
+				return fmt.Errorf("could not accept connections: %v", err)
+			}
+		}
+		go s.handle(conn)
+	}
+}
+
+// loop refreshes the index when files change, and all projects every
+// GitInterval
+func (s *Server) loop() {
+	ticker := time.NewTicker(GitInterval)
+	defer ticker.Stop()
+
+	var changes <-chan struct{}
+	if s.watcher != nil {
+		changes = s.watcher.Changes()
+	}
+	for {
+		select {
+		case <-changes:
+			s.rescan(false)
+		case <-ticker.C:
+			s.rescan(true)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// rescan brings the index up to date and saves it. A full rescan detects
+// every project again instead of reusing unchanged ones.
+func (s *Server) rescan(full bool) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	// Favorites and directories are changed by the UI, pick them up
+	if cfg, err := s.loadConfig(); err == nil {
+		s.mu.Lock()
+		s.cfg = cfg
+		s.mu.Unlock()
+	} else {
+		s.log.Printf("keeping the previous config: %v", err)
+	}
+
+	s.mu.Lock()
+	cfg, prev := s.cfg, s.index
+	s.mu.Unlock()
+	if full {
+		prev = nil
+	}
+
+	start := time.Now()
+	updated, stats := project.Rescan(prev, cfg.ProjectDirs, cfg)
+	if err := updated.SaveCache(s.profile); err != nil {
+		s.log.Printf("could not save cache: %v", err)
+	}
+
+	s.mu.Lock()
+	s.index = updated
+	s.mu.Unlock()
+
+	if s.watcher != nil {
+		paths := make([]string, len(updated.Projects))
+		for i, p := range updated.Projects {
+			paths[i] = p.Path
+		}
+		s.watcher.Sync(cfg.ProjectDirs, paths)
+	}
+	s.log.Printf("rescanned %d projects in %v (%d detected, %d reused)",
+		len(updated.Projects), time.Since(start).Round(time.Millisecond), stats.Detected, stats.Reused)
+}
+
+// loadConfig reads the config with the profile the daemon serves, even if
+// the default profile changed since it started
+func (s *Server) loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ActiveProfile() != s.profile {
+		if err := cfg.UseProfile(s.profile); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// stop makes Serve return
+func (s *Server) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.listener.Close()
+	})
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+	resp, stop := s.respond(conn)
+	resp.Version = ProtocolVersion
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.log.Printf("could not send response: %v", err)
+	}
+	// Stop after answering, Serve returns once the listener is closed
+	if stop {
+		s.log.Printf("stopping on request")
+		s.stop()
+	}
+}
+
+// respond reads a request and answers it. It reports whether the daemon
+// should stop.
+func (s *Server) respond(conn net.Conn) (Response, bool) {
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return Response{Error: fmt.Sprintf("could not read request: %v", err)}, false
+	}
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return Response{Error: fmt.Sprintf("invalid request: %v", err)}, false
+	}
+	if req.Version != ProtocolVersion {
+		return Response{Error: fmt.Sprintf("unsupported protocol version %d, expected %d", req.Version, ProtocolVersion)}, false
+	}
+	// Refreshing may take a while
+	conn.SetDeadline(time.Time{})
+
+	switch req.Method {
+	case MethodStatus:
+		return Response{Status: s.status()}, false
+	case MethodProjects:
+		return Response{Index: s.snapshot()}, false
+	case MethodRefresh:
+		s.rescan(false)
+		return Response{Index: s.snapshot()}, false
+	case MethodStop:
+		return Response{Status: s.status()}, true
+	default:
+		return Response{Error: fmt.Sprintf("unknown method %q", req.Method)}, false
+	}
+}
+
+func (s *Server) snapshot() *cache.ProjectCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.index
+}
+
+func (s *Server) status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Status{
+		PID:         os.Getpid(),
+		Profile:     s.profile,
+		Started:     s.started,
+		Projects:    len(s.index.Projects),
+		LastUpdated: s.index.LastUpdated,
+		Watching:    s.watcher != nil,
+	}
+}
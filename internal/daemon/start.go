@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"den/internal/paths"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// startTimeout is how long Start waits for the daemon to answer
+const startTimeout = 5 * time.Second
+
+// Start runs "den daemon run" for profile in the background, detached from
+// the terminal, and waits until it answers. Its output goes to LogPath.
+func Start(profile string) (*Status, error) {
+	if resp, err := Call(profile, MethodStatus); err == nil {
+		return resp.Status, fmt.Errorf("daemon is already running (pid %d)", resp.Status.PID)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("could not find the den executable: %v", err)
+	}
+	configPath, err := paths.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	logPath, err := LogPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open log: %v", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "daemon", "run")
+	// The daemon must use the same config and profile as this process
+	cmd.Env = append(os.Environ(), "DEN_CONFIG="+configPath, "DEN_PROFILE="+profile)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedAttr()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start daemon: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		if resp, err := Call(profile, MethodStatus); err == nil {
+			return resp.Status, nil
+		}
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("daemon exited: %v, see %s", err, logPath)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return nil, fmt.Errorf("daemon did not start in time, see %s", logPath)
+}
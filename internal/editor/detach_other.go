@@ -0,0 +1,10 @@
+//go:build !unix
+
+package editor
+
+import "syscall"
+
+// detachedAttr has nothing to set on this platform
+func detachedAttr() *syscall.SysProcAttr {
+	return nil
+}
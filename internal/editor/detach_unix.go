@@ -0,0 +1,11 @@
+//go:build unix
+
+package editor
+
+import "syscall"
+
+// detachedAttr starts GUI editors in their own session, so that they keep
+// running after den exits and don't get signals meant for den
+func detachedAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
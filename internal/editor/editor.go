@@ -2,10 +2,14 @@ package editor
 
 import (
 	"den/internal/config"
+	"den/internal/shellwords"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 var defaultEditors = map[string][]string{
@@ -14,33 +18,200 @@ var defaultEditors = map[string][]string{
 	"windows": {"code.exe", "notepad.exe"}, // Windows
 }
 
-// OpenInEditor opens the given path in the configured editor
-func OpenInEditor(path string, config *config.Config) error {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		// Use configured default editor
-		editor = config.Preferences.DefaultEditor
-		if editor == "" {
-			// Try to find a default editor from the configured list
-			for _, ed := range config.Preferences.EditorList {
-				if _, err := exec.LookPath(ed); err == nil {
-					editor = ed
-					break
-				}
+// terminalEditors are editors known to run in the terminal, for editors
+// that are not configured as a profile
+var terminalEditors = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "nano": true, "micro": true,
+	"hx": true, "helix": true, "kak": true, "joe": true, "mg": true, "ne": true,
+	"vis": true, "mcedit": true, "zile": true,
+}
+
+// terminalFlags are the flags that keep editors with a GUI in the terminal
+var terminalFlags = map[string][]string{
+	"emacs":       {"-nw", "--no-window-system"},
+	"emacsclient": {"-t", "-nw", "--tty"},
+}
+
+// Target is what an editor opens: a project, and optionally a file in it
+type Target struct {
+	Path string
+	Name string
+	File string
+	Line int
+}
+
+// ProjectTarget returns the target for opening a project directory
+func ProjectTarget(path string) Target {
+	return Target{Path: path, Name: filepath.Base(path)}
+}
+
+// Editor is an editor profile ready to run
+type Editor struct {
+	Name    string
+	Profile config.EditorProfile
+}
+
+// Resolve returns the editor called name: a profile from the [editors]
+// table, or else a command line such as "code --wait". Editors that are
+// not configured are terminal editors if they are known to be, or are told
+// to stay in the terminal by their flags, as with "emacs -nw".
+func Resolve(name string, cfg *config.Config) Editor {
+	if profile, ok := cfg.Editors[name]; ok {
+		return Editor{Name: name, Profile: profile}
+	}
+	return Editor{Name: name, Profile: config.EditorProfile{Command: name, Terminal: runsInTerminal(name)}}
+}
+
+// runsInTerminal reports whether a command line runs an editor in the
+// terminal
+func runsInTerminal(command string) bool {
+	words, _ := shellwords.Split(command)
+	if len(words) == 0 {
+		return false
+	}
+	base := strings.TrimSuffix(filepath.Base(words[0]), ".exe")
+	if terminalEditors[base] {
+		return true
+	}
+	for _, word := range words[1:] {
+		for _, flag := range terminalFlags[base] {
+			if word == flag {
+				return true
 			}
-			if editor == "" {
-				return fmt.Errorf("no editor found")
+		}
+	}
+	return false
+}
+
+// DefaultEditor returns the editor to use: $EDITOR, the default editor, or
+// the first editor of the editor list that is installed. $EDITOR runs in the
+// foreground, as git and other programs run it, unless it names an editor
+// profile.
+func DefaultEditor(cfg *config.Config) (Editor, error) {
+	if name := os.Getenv("EDITOR"); name != "" {
+		ed := Resolve(name, cfg)
+		if _, ok := cfg.Editors[name]; !ok {
+			ed.Profile.Terminal = true
+		}
+		return ed, nil
+	}
+	name := cfg.Preferences.DefaultEditor
+	if name == "" {
+		for _, ed := range cfg.Preferences.EditorList {
+			words, _ := shellwords.Split(Resolve(ed, cfg).Profile.Command)
+			if len(words) == 0 {
+				continue
+			}
+			if _, err := exec.LookPath(words[0]); err == nil {
+				name = ed
+				break
+			}
+		}
+	}
+	if name == "" {
+		return Editor{}, fmt.Errorf("no editor found")
+	}
+	return Resolve(name, cfg), nil
+}
+
+// Waits reports whether den waits for the editor to exit. Terminal editors
+// always run in the foreground.
+func (e Editor) Waits() bool {
+	return e.Profile.Terminal || e.Profile.Mode == config.EditorWait
+}
+
+// Command returns the command that opens target. Placeholders are replaced
+// within words, so paths with spaces stay a single argument.
+func (e Editor) Command(t Target) (*exec.Cmd, error) {
+	words, err := shellwords.Split(e.Profile.Command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command for editor %s: %v", e.Name, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("editor %s has no command", e.Name)
+	}
+
+	if !hasPlaceholder(words) {
+		words = append(words, "{path}")
+	}
+	args := expand(words, t)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("editor %s has no command", e.Name)
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// expand replaces the placeholders in the words of a command
+func expand(words []string, t Target) []string {
+	line := ""
+	if t.Line > 0 {
+		line = strconv.Itoa(t.Line)
+	}
+	replacer := strings.NewReplacer("{path}", t.Path, "{name}", t.Name, "{file}", t.File, "{line}", line)
+
+	args := make([]string, 0, len(words))
+	for _, word := range words {
+		// Leave out e.g. "+{line}" when there is no line to go to
+		if (t.File == "" && strings.Contains(word, "{file}")) || (line == "" && strings.Contains(word, "{line}")) {
+			continue
+		}
+		args = append(args, replacer.Replace(word))
+	}
+	return args
+}
+
+func hasPlaceholder(words []string) bool {
+	for _, word := range words {
+		for _, p := range []string{"{path}", "{name}", "{file}", "{line}"} {
+			if strings.Contains(word, p) {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	cmd := exec.Command(editor, path)
+// Open runs the editor on target. Editors that wait get the terminal,
+// others are started detached.
+func (e Editor) Open(t Target) error {
+	cmd, err := e.Command(t)
+	if err != nil {
+		return err
+	}
+	if !e.Waits() {
+		return Detach(cmd)
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+// Detach starts cmd in its own session without the terminal, and doesn't
+// wait for it
+func Detach(cmd *exec.Cmd) error {
+	if err := StartDetached(cmd); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// StartDetached starts cmd in its own session, so that it keeps running
+// after den exits. The caller may still wait for it.
+func StartDetached(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = detachedAttr()
+	return cmd.Start()
+}
+
+// OpenInEditor opens the given path in the configured editor
+func OpenInEditor(path string, config *config.Config) error {
+	editor, err := DefaultEditor(config)
+	if err != nil {
+		return err
+	}
+	return editor.Open(ProjectTarget(path))
+}
+
 // OpenInFileExplorer opens the given path in the system's file explorer
 func OpenInFileExplorer(path string, config *config.Config) error {
 	var cmd *exec.Cmd
@@ -85,6 +256,8 @@ func DetectSystemPreferences() config.UserPreferences {
 		}
 	}
 
+	prefs.Terminal = DetectTerminal()
+
 	// Detect default file manager
 	switch runtime.GOOS {
 	case "darwin":
@@ -0,0 +1,134 @@
+package editor
+
+import (
+	"den/internal/config"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEditorCommand(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Editors = map[string]config.EditorProfile{
+		"nvim": {Command: `nvim -c "cd {path}" +{line} {file}`, Terminal: true},
+		"idea": {Command: "idea {path}"},
+	}
+	project := Target{Path: "/home/me/my code", Name: "my code"}
+
+	tests := []struct {
+		editor string
+		target Target
+		want   []string
+	}{
+		// Words with {file} or {line} are left out without a file
+		{"nvim", project, []string{"nvim", "-c", "cd /home/me/my code"}},
+		{"nvim", Target{Path: "/p", File: "/p/main.go", Line: 12}, []string{"nvim", "-c", "cd /p", "+12", "/p/main.go"}},
+		{"idea", project, []string{"idea", "/home/me/my code"}},
+		// Plain command lines get the path appended
+		{"code --wait", project, []string{"code", "--wait", "/home/me/my code"}},
+	}
+	for _, tt := range tests {
+		cmd, err := Resolve(tt.editor, cfg).Command(tt.target)
+		if err != nil {
+			t.Errorf("%s: Command failed: %v", tt.editor, err)
+			continue
+		}
+		if !reflect.DeepEqual(cmd.Args, tt.want) {
+			t.Errorf("%s: got args %q, want %q", tt.editor, cmd.Args, tt.want)
+		}
+	}
+
+	if !Resolve("nvim", cfg).Waits() || Resolve("idea", cfg).Waits() {
+		t.Error("expected terminal editors to wait and GUI editors to detach")
+	}
+	if !Resolve("vim", cfg).Profile.Terminal {
+		t.Error("expected vim to be known as a terminal editor")
+	}
+	for _, name := range []string{"emacs -nw", "emacsclient -t", "/usr/bin/kak"} {
+		if !Resolve(name, cfg).Waits() {
+			t.Errorf("expected %s to run in the terminal", name)
+		}
+	}
+	if Resolve("emacs", cfg).Waits() || Resolve("emacsclient -c", cfg).Waits() {
+		t.Error("expected emacs without -nw to detach")
+	}
+	if _, err := Resolve(`code "--wait`, cfg).Command(project); err == nil {
+		t.Error("expected an unterminated quote to fail")
+	}
+}
+
+func TestDefaultEditorRunsEDITORInForeground(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Editors = map[string]config.EditorProfile{"idea": {Command: "idea {path}"}}
+
+	t.Setenv("EDITOR", "my-editor --flag")
+	ed, err := DefaultEditor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed.Waits() {
+		t.Error("expected an unknown $EDITOR to run in the foreground")
+	}
+
+	// A profile keeps its own settings
+	t.Setenv("EDITOR", "idea")
+	if ed, err = DefaultEditor(cfg); err != nil || ed.Waits() {
+		t.Errorf("expected the idea profile to detach, got %+v, %v", ed, err)
+	}
+}
+
+func TestOpenInTerminal(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	// Stands in for a terminal, started in the project directory
+	cfg.Preferences.Terminal = "sh -c 'touch started && touch {path}/with-path'"
+
+	if err := OpenInTerminal(dir, cfg); err != nil {
+		t.Fatalf("OpenInTerminal failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, errStarted := os.Stat(filepath.Join(dir, "started"))
+		_, errPath := os.Stat(filepath.Join(dir, "with-path"))
+		if errStarted == nil && errPath == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("terminal command did not run in the project directory")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cfg.Preferences.Terminal = "{file}"
+	if err := OpenInTerminal(dir, cfg); err == nil {
+		t.Error("expected a command without words to fail")
+	}
+}
+
+func TestWriteWorkspace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "den", "selection.code-workspace")
+	if err := WriteWorkspace(file, []string{"/code/api", "/code/web"}); err != nil {
+		t.Fatalf("WriteWorkspace failed: %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read workspace: %v", err)
+	}
+	var workspace struct {
+		Folders []struct{ Path string }
+	}
+	if err := json.Unmarshal(data, &workspace); err != nil {
+		t.Fatalf("Invalid workspace: %v", err)
+	}
+	if len(workspace.Folders) != 2 || workspace.Folders[1].Path != "/code/web" {
+		t.Errorf("unexpected folders: %+v", workspace.Folders)
+	}
+
+	cfg := config.DefaultConfig()
+	if !Resolve("codium --new-window", cfg).OpensWorkspaces() || Resolve("vim", cfg).OpensWorkspaces() {
+		t.Error("expected only VS Code compatible editors to open workspaces")
+	}
+}
@@ -0,0 +1,89 @@
+package editor
+
+import (
+	"den/internal/config"
+	"den/internal/shellwords"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Terminal is a terminal emulator and the command that opens it at {path}
+type Terminal struct {
+	Name    string
+	Command string
+}
+
+// Terminals are common Linux terminal emulators, in order of preference
+var Terminals = []Terminal{
+	{"alacritty", "alacritty --working-directory {path}"},
+	{"kitty", "kitty --directory {path}"},
+	{"wezterm", "wezterm start --cwd {path}"},
+	{"foot", "foot --working-directory={path}"},
+	{"gnome-terminal", "gnome-terminal --working-directory={path}"},
+	{"konsole", "konsole --workdir {path}"},
+	{"xfce4-terminal", "xfce4-terminal --working-directory={path}"},
+	{"tilix", "tilix --working-directory={path}"},
+	{"x-terminal-emulator", "x-terminal-emulator"},
+	{"xterm", "xterm"},
+}
+
+// InstalledTerminals returns the commands of the terminals that are
+// installed
+func InstalledTerminals() []string {
+	var commands []string
+	for _, t := range Terminals {
+		if _, err := exec.LookPath(t.Name); err == nil {
+			commands = append(commands, t.Command)
+		}
+	}
+	return commands
+}
+
+// DetectTerminal returns the command of the system's terminal emulator, or
+// "" if none is found
+func DetectTerminal() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open -a Terminal {path}"
+	case "windows":
+		return "wt -d {path}"
+	}
+	if installed := InstalledTerminals(); len(installed) > 0 {
+		return installed[0]
+	}
+	return ""
+}
+
+// OpenInTerminal opens a terminal emulator in the given directory
+func OpenInTerminal(path string, cfg *config.Config) error {
+	cmd, err := TerminalCommand(path, cfg)
+	if err != nil {
+		return err
+	}
+	return Detach(cmd)
+}
+
+// TerminalCommand returns the command that opens a terminal emulator in the
+// given directory. Terminals without a {path} argument still start there,
+// as it is their working directory.
+func TerminalCommand(path string, cfg *config.Config) (*exec.Cmd, error) {
+	command := cfg.Preferences.Terminal
+	if command == "" {
+		command = DetectTerminal()
+	}
+	if command == "" {
+		return nil, fmt.Errorf("no terminal found, set terminal in the config")
+	}
+	words, err := shellwords.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid terminal command: %v", err)
+	}
+	args := expand(words, ProjectTarget(path))
+	if len(args) == 0 {
+		return nil, fmt.Errorf("terminal command is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = path
+	return cmd, nil
+}
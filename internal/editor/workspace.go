@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"den/internal/config"
+	"den/internal/shellwords"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// workspaceEditors open VS Code workspace files, which hold several folders
+var workspaceEditors = map[string]bool{
+	"code": true, "code.exe": true, "code-insiders": true, "codium": true,
+	"cursor": true, "windsurf": true,
+}
+
+// workspaceFolder is a folder of a workspace file
+type workspaceFolder struct {
+	Path string `json:"path"`
+}
+
+// OpensWorkspaces reports whether the editor opens multi-root workspace
+// files
+func (e Editor) OpensWorkspaces() bool {
+	words, err := shellwords.Split(e.Profile.Command)
+	return err == nil && len(words) > 0 && workspaceEditors[filepath.Base(words[0])]
+}
+
+// WorkspaceEditor returns the default editor if it opens workspace files,
+// or else the first editor of the editor list that is installed and does
+func WorkspaceEditor(cfg *config.Config) (Editor, error) {
+	if ed, err := DefaultEditor(cfg); err == nil && ed.OpensWorkspaces() {
+		return ed, nil
+	}
+	for _, name := range cfg.Preferences.EditorList {
+		ed := Resolve(name, cfg)
+		if !ed.OpensWorkspaces() {
+			continue
+		}
+		words, _ := shellwords.Split(ed.Profile.Command)
+		if _, err := exec.LookPath(words[0]); err == nil {
+			return ed, nil
+		}
+	}
+	return Editor{}, fmt.Errorf("no editor that opens multi-root workspaces, such as code, codium or cursor")
+}
+
+// WriteWorkspace writes a workspace file with a folder for each path
+func WriteWorkspace(file string, paths []string) error {
+	folders := make([]workspaceFolder, len(paths))
+	for i, path := range paths {
+		folders[i] = workspaceFolder{Path: path}
+	}
+	data, err := json.MarshalIndent(map[string]any{"folders": folders}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode workspace: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("could not create workspace: %v", err)
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write workspace: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout is how long LockFile waits for another process
+const lockTimeout = 5 * time.Second
+
+// LockFile takes an advisory lock for path, shared for readers and
+// exclusive for writers, waiting for other processes to release theirs. The
+// lock is held on a separate path+".lock" file so that it survives files
+// being replaced by WriteFileAtomic. Call the returned function to unlock.
+func LockFile(path string, exclusive bool) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f, exclusive)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("timed out waiting for lock on %s", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+// tryLock always succeeds where flock is not available; atomic writes
+// still keep files from being corrupted
+func tryLock(f *os.File, exclusive bool) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) {}
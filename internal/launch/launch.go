@@ -0,0 +1,164 @@
+// Package launch runs the launch profile of a project: the editors,
+// terminals and background commands that start work on it
+package launch
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/editor"
+	"den/internal/supervisor"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Launch is the launch profile of a single project
+type Launch struct {
+	Project string
+	Dir     string
+	// Profile is the name of the launch profile, or config.ProjectFile
+	Profile string
+	Steps   []config.LaunchStep
+}
+
+// Result is the outcome of a step that ran
+type Result struct {
+	Step config.LaunchStep
+	// Detail says what the step did, e.g. where output is logged
+	Detail string
+	Err    error
+}
+
+// For returns the launch profile of the project at dir
+func For(cfg *config.Config, project, dir string) (*Launch, error) {
+	// Without tags only profiles by project or the default apply
+	tags, _ := cache.Tags(cfg.ActiveProfile(), dir)
+	name, steps, err := cfg.LaunchSteps(project, dir, tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no launch profile for %s, add one to the config as [launch.default] or to %s", project, config.ProjectFile)
+	}
+	return &Launch{Project: project, Dir: dir, Profile: name, Steps: steps}, nil
+}
+
+// Run runs the steps in order and stops at the first one that fails, with
+// an error naming it. Editors that need the terminal can't run while other
+// steps do; the command of such an editor is returned to be run in the
+// foreground once the other steps are done.
+func (l *Launch) Run(cfg *config.Config) (results []Result, foreground *exec.Cmd, err error) {
+	var env []string
+	for i, step := range l.Steps {
+		result := Result{Step: step}
+		var cmd *exec.Cmd
+		cmd, result.Detail, result.Err = l.runStep(step, env, cfg)
+		if result.Err == nil && cmd != nil {
+			if foreground != nil {
+				result.Err = fmt.Errorf("another step already uses the terminal")
+			} else {
+				foreground = cmd
+			}
+		}
+		results = append(results, result)
+
+		if result.Err != nil {
+			err = fmt.Errorf("step %d of %d (%s) failed: %v", i+1, len(l.Steps), step, result.Err)
+			if left := len(l.Steps) - i - 1; left > 0 {
+				err = fmt.Errorf("%v; %d more not run", err, left)
+			}
+			return results, nil, err
+		}
+		if step.Action == config.LaunchEnv {
+			env = append(env, envList(step.Env)...)
+		}
+	}
+	return results, foreground, nil
+}
+
+// runStep runs a single step. It returns the command of an editor that
+// needs the terminal instead of running it.
+func (l *Launch) runStep(step config.LaunchStep, env []string, cfg *config.Config) (*exec.Cmd, string, error) {
+	switch step.Action {
+	case config.LaunchEnv:
+		if len(step.Env) == 0 {
+			return nil, "", fmt.Errorf("no variables to set")
+		}
+		return nil, "", nil
+
+	case config.LaunchEditor:
+		ed, err := editor.DefaultEditor(cfg)
+		if step.Editor != "" {
+			ed, err = editor.Resolve(step.Editor, cfg), nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		cmd, err := ed.Command(editor.Target{Path: l.Dir, Name: l.Project})
+		if err != nil {
+			return nil, "", err
+		}
+		cmd.Env = withEnv(env)
+		if ed.Waits() {
+			return cmd, "", nil
+		}
+		return nil, "", editor.Detach(cmd)
+
+	case config.LaunchTerminal:
+		cmd, err := editor.TerminalCommand(l.Dir, cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		cmd.Env = withEnv(env)
+		return nil, "", editor.Detach(cmd)
+
+	case config.LaunchRun:
+		if strings.TrimSpace(step.Command) == "" {
+			return nil, "", fmt.Errorf("no command to run")
+		}
+		detail, err := l.background(step, env)
+		return nil, detail, err
+	}
+	return nil, "", fmt.Errorf("unknown action %q", step.Action)
+}
+
+// background starts a command with the shell in the project directory,
+// supervised so that it can be stopped and restarted later
+func (l *Launch) background(step config.LaunchStep, env []string) (string, error) {
+	p, err := supervisor.Start(supervisor.Spec{
+		Project: l.Dir,
+		Name:    l.Project,
+		Command: step.Command,
+		Dir:     l.Dir,
+		Env:     env,
+		Port:    step.Port,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pid %d, logging to %s", p.PID, p.Log), nil
+}
+
+// withEnv returns the environment of den with the variables set by env
+// steps. Later values win.
+func withEnv(env []string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), env...)
+}
+
+func envList(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]string, len(names))
+	for i, name := range names {
+		list[i] = name + "=" + env[name]
+	}
+	return list
+}
@@ -0,0 +1,72 @@
+package launch
+
+import (
+	"den/internal/config"
+	"den/internal/supervisor"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSteps(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	l := &Launch{Project: "app", Dir: dir, Steps: []config.LaunchStep{
+		{Action: config.LaunchEnv, Env: map[string]string{"GREETING": "hello"}},
+		{Action: config.LaunchRun, Command: `echo "$GREETING" > greeting; sleep 2`},
+	}}
+
+	results, foreground, err := l.Run(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 2 || foreground != nil {
+		t.Fatalf("unexpected results %v, foreground %v", results, foreground)
+	}
+
+	// The command keeps running in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(filepath.Join(dir, "greeting"))
+		if string(data) == "hello\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background command did not see the env step, got %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunStopsAtFailingStep(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	l := &Launch{Project: "app", Dir: dir, Steps: []config.LaunchStep{
+		{Action: config.LaunchRun, Command: "echo broken >&2; exit 3"},
+		{Action: config.LaunchRun, Command: "touch ran"},
+		{Action: config.LaunchTerminal},
+	}}
+
+	results, _, err := l.Run(config.DefaultConfig())
+	if err == nil {
+		t.Fatal("expected the failing step to be reported")
+	}
+	if !strings.Contains(err.Error(), "step 1 of 3 (run echo broken >&2; exit 3)") ||
+		!strings.Contains(err.Error(), "2 more not run") {
+		t.Errorf("error doesn't name the step: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result, got %d", len(results))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("steps after the failure ran")
+	}
+
+	logPath, _ := supervisor.LogPath(dir)
+	data, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(data), "broken") {
+		t.Errorf("command output not logged:\n%s", data)
+	}
+}
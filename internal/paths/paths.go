@@ -0,0 +1,80 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// appName is the directory name den uses under each base directory
+const appName = "den"
+
+// configOverride is the config file given with --config
+var configOverride string
+
+// SetConfigFile overrides the config file location for this process
+func SetConfigFile(path string) {
+	configOverride = path
+}
+
+// ConfigFile returns the config file path. The --config flag takes
+// precedence over $DEN_CONFIG, which takes precedence over the XDG location.
+func ConfigFile() (string, error) {
+	if configOverride != "" {
+		return configOverride, nil
+	}
+	if path := os.Getenv("DEN_CONFIG"); path != "" {
+		return filepath.Abs(path)
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// ConfigDir returns den's config directory, $XDG_CONFIG_HOME/den by default
+func ConfigDir() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// CacheDir returns den's cache directory, $XDG_CACHE_HOME/den by default
+func CacheDir() (string, error) {
+	return baseDir("XDG_CACHE_HOME", ".cache")
+}
+
+// StateDir returns den's state directory, $XDG_STATE_HOME/den by default
+func StateDir() (string, error) {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// CacheFile returns the path of a file in the cache directory
+func CacheFile(name string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// StateFile returns the path of a file in the state directory
+func StateFile(name string) (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// baseDir resolves an XDG base directory. Per the spec, relative values are
+// invalid and ignored in favor of the default under the home directory.
+func baseDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, fallback, appName), nil
+}
@@ -0,0 +1,74 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigFilePrecedence(t *testing.T) {
+	tmpDir := t.TempDir()
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("DEN_CONFIG", "")
+	defer SetConfigFile("")
+
+	// Default location under the home directory
+	got, err := ConfigFile()
+	if err != nil {
+		t.Fatalf("ConfigFile failed: %v", err)
+	}
+	if want := filepath.Join(homeDir, ".config", "den", "config.toml"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// XDG_CONFIG_HOME replaces ~/.config
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+	got, _ = ConfigFile()
+	if want := filepath.Join(tmpDir, "xdg", "den", "config.toml"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// DEN_CONFIG wins over XDG_CONFIG_HOME
+	t.Setenv("DEN_CONFIG", filepath.Join(tmpDir, "env.toml"))
+	got, _ = ConfigFile()
+	if want := filepath.Join(tmpDir, "env.toml"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// --config wins over everything
+	SetConfigFile(filepath.Join(tmpDir, "flag.toml"))
+	got, _ = ConfigFile()
+	if want := filepath.Join(tmpDir, "flag.toml"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestBaseDirsHonorXDG(t *testing.T) {
+	tmpDir := t.TempDir()
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("XDG_STATE_HOME", "relative/state") // invalid, must be ignored
+
+	got, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir failed: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "cache", "den"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	got, err = StateDir()
+	if err != nil {
+		t.Fatalf("StateDir failed: %v", err)
+	}
+	if want := filepath.Join(homeDir, ".local", "state", "den"); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
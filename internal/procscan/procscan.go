@@ -0,0 +1,55 @@
+// Package procscan finds the processes running in project directories and
+// the ports they listen on
+package procscan
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Process is a process whose working directory is in a project
+type Process struct {
+	PID     int
+	Command string
+	// Args is the full command line
+	Args    []string
+	Started time.Time
+	// Ports are the TCP ports the process listens on
+	Ports []int
+}
+
+// CommandLine returns the command line as a single string
+func (p Process) CommandLine() string {
+	if len(p.Args) == 0 {
+		return p.Command
+	}
+	return strings.Join(p.Args, " ")
+}
+
+// shells are left out unless they listen on a port, as every terminal
+// open in a project would count as running
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true, "elvish": true,
+}
+
+// owner returns the project that dir is in, the deepest one when projects
+// are nested
+func owner(dir string, projects []string) string {
+	best := ""
+	for _, p := range projects {
+		if (dir == p || strings.HasPrefix(dir, p+string(filepath.Separator))) && len(p) > len(best) {
+			best = p
+		}
+	}
+	return best
+}
+
+// sortProcesses orders the processes of each project by start time
+func sortProcesses(byProject map[string][]Process) {
+	for _, procs := range byProject {
+		sort.Slice(procs, func(i, j int) bool { return procs[i].Started.Before(procs[j].Started) })
+	}
+}
@@ -0,0 +1,189 @@
+//go:build linux
+
+package procscan
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Supported reports whether processes can be scanned on this platform
+const Supported = true
+
+// clockTicks is USER_HZ, the unit of start times in /proc/<pid>/stat. It
+// is 100 on every Linux architecture den runs on.
+const clockTicks = 100
+
+// tcpListen is the state of listening sockets in /proc/net/tcp
+const tcpListen = "0A"
+
+// Scan returns the processes running in the given project directories, by
+// project. Processes of other users that can't be inspected are skipped.
+func Scan(projects []string) (map[string][]Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	boot := bootTime()
+	listening := listeningSockets()
+	self := os.Getpid()
+
+	byProject := make(map[string][]Process)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		cwd, err := os.Readlink(filepath.Join(dir, "cwd"))
+		if err != nil {
+			continue
+		}
+		project := owner(cwd, projects)
+		if project == "" {
+			continue
+		}
+
+		p, ok := readProcess(pid, dir, boot)
+		if !ok {
+			continue
+		}
+		p.Ports = ports(dir, listening)
+		if shells[p.Command] && len(p.Ports) == 0 {
+			continue
+		}
+		byProject[project] = append(byProject[project], p)
+	}
+	sortProcesses(byProject)
+	return byProject, nil
+}
+
+// readProcess reads the command line and start time of a process. Kernel
+// threads, which have no command line, are skipped.
+func readProcess(pid int, dir string, boot time.Time) (Process, bool) {
+	cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil || len(cmdline) == 0 {
+		return Process{}, false
+	}
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	p := Process{PID: pid, Command: filepath.Base(args[0]), Args: args}
+
+	if ticks, err := startTicks(dir); err == nil && !boot.IsZero() {
+		p.Started = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+	}
+	return p, true
+}
+
+// StartTicks returns when a process started, in clock ticks after boot.
+// Together with the pid it identifies the process, as a pid is only reused
+// by a process that starts later.
+func StartTicks(pid int) (uint64, error) {
+	return startTicks(filepath.Join("/proc", strconv.Itoa(pid)))
+}
+
+// startTicks reads the start time from the stat file of a process
+func startTicks(dir string) (uint64, error) {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name may contain spaces and parentheses, the fields
+	// after it don't
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed %s", filepath.Join(dir, "stat"))
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	// starttime is field 22, the 20th after the command name
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed %s", filepath.Join(dir, "stat"))
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// bootTime returns when the system booted, or the zero time
+func bootTime() time.Time {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return time.Unix(secs, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// listeningSockets maps the inodes of listening TCP sockets to their ports
+func listeningSockets() map[string]int {
+	sockets := make(map[string]int)
+	for _, name := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListen {
+				continue
+			}
+			_, portHex, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			if port, err := strconv.ParseInt(portHex, 16, 32); err == nil {
+				sockets[fields[9]] = int(port)
+			}
+		}
+		f.Close()
+	}
+	return sockets
+}
+
+// ports returns the ports a process listens on, from its open sockets
+func ports(dir string, listening map[string]int) []int {
+	if len(listening) == 0 {
+		return nil
+	}
+	fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return nil
+	}
+	seen := make(map[int]bool)
+	var found []int
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+		if err != nil {
+			continue
+		}
+		inode, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		if port, ok := listening[strings.TrimSuffix(inode, "]")]; ok && !seen[port] {
+			seen[port] = true
+			found = append(found, port)
+		}
+	}
+	sort.Ints(found)
+	return found
+}
+
+// Kill asks a process to terminate
+func Kill(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,76 @@
+//go:build linux
+
+package procscan
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScanFindsProcessesAndPorts(t *testing.T) {
+	project := t.TempDir()
+	sub := filepath.Join(project, "web")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The child inherits a listening socket, as a dev server would own one
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	file, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	server := exec.Command("sleep", "30")
+	server.Dir = sub
+	server.ExtraFiles = []*os.File{file}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Process.Kill()
+
+	// Shells without ports are left out
+	shell := exec.Command("sh", "-c", "sleep 30; true")
+	shell.Dir = project
+	if err := shell.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer shell.Process.Kill()
+	time.Sleep(50 * time.Millisecond)
+
+	byProject, err := Scan([]string{project, "/elsewhere"})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	var found *Process
+	for _, p := range byProject[project] {
+		if p.PID == shell.Process.Pid {
+			t.Errorf("shell without ports was reported: %+v", p)
+		}
+		if p.PID == server.Process.Pid {
+			found = &p
+		}
+	}
+	if found == nil {
+		t.Fatalf("process not found in %+v", byProject)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	if len(found.Ports) != 1 || found.Ports[0] != port {
+		t.Errorf("expected port %d, got %v", port, found.Ports)
+	}
+	if found.CommandLine() != "sleep 30" {
+		t.Errorf("unexpected command line %q", found.CommandLine())
+	}
+	if age := time.Since(found.Started); age < 0 || age > time.Minute {
+		t.Errorf("unexpected start time %v", found.Started)
+	}
+}
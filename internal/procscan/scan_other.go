@@ -0,0 +1,23 @@
+//go:build !linux
+
+package procscan
+
+import "fmt"
+
+// Supported reports whether processes can be scanned on this platform
+const Supported = false
+
+// Scan needs /proc, which this platform doesn't have
+func Scan(projects []string) (map[string][]Process, error) {
+	return nil, nil
+}
+
+// Kill is not supported on this platform
+func Kill(pid int) error {
+	return fmt.Errorf("killing processes is not supported on this platform")
+}
+
+// StartTicks needs /proc, which this platform doesn't have
+func StartTicks(pid int) (uint64, error) {
+	return 0, fmt.Errorf("start times are not supported on this platform")
+}
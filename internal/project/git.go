@@ -0,0 +1,121 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// NetworkTimeout is how long Fetch and Pull wait on a remote
+const NetworkTimeout = 2 * time.Minute
+
+// git runs a git command in the project and returns its trimmed output
+func git(path string, args ...string) (string, error) {
+	return gitContext(context.Background(), path, args...)
+}
+
+// gitContext is git, killing the command when ctx is done. Git and ssh fail
+// instead of asking for credentials or a passphrase, which would end up in
+// the UI or wait forever. A GIT_SSH_COMMAND set by the user is kept.
+func gitContext(ctx context.Context, path string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", path}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	// ssh may hold on to the output after git is killed
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("git %s: timed out", args[0])
+	}
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// RemoteURL returns the URL of the origin remote, or of the first remote
+// when there is no origin
+func RemoteURL(path string) (string, error) {
+	if url, err := git(path, "remote", "get-url", "origin"); err == nil {
+		return url, nil
+	}
+	remotes, err := git(path, "remote")
+	if err != nil {
+		return "", err
+	}
+	if remotes == "" {
+		return "", fmt.Errorf("no remotes")
+	}
+	return git(path, "remote", "get-url", strings.Fields(remotes)[0])
+}
+
+// Branch returns the branch checked out in the project
+func Branch(path string) (string, error) {
+	branch, err := git(path, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		// symbolic-ref fails quietly on a detached HEAD
+		if _, headErr := git(path, "rev-parse", "--verify", "HEAD"); headErr == nil {
+			return "", fmt.Errorf("detached HEAD")
+		}
+		return "", err
+	}
+	return branch, nil
+}
+
+// HeadCommit returns the hash of the latest commit
+func HeadCommit(path string) (string, error) {
+	return git(path, "rev-parse", "HEAD")
+}
+
+// Fetch fetches every remote of the project and returns how far the branch
+// is from its upstream
+func Fetch(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), NetworkTimeout)
+	defer cancel()
+	if _, err := gitContext(ctx, path, "fetch", "--all", "--prune", "--quiet"); err != nil {
+		return "", err
+	}
+	return divergence(path), nil
+}
+
+// Pull fast-forwards the branch to its upstream and returns the last line
+// git printed, e.g. "Already up to date."
+func Pull(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), NetworkTimeout)
+	defer cancel()
+	out, err := gitContext(ctx, path, "pull", "--ff-only")
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(out, "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// divergence describes how many commits the branch is ahead of and behind
+// its upstream
+func divergence(path string) string {
+	counts, err := git(path, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	if err != nil {
+		return "no upstream"
+	}
+	var ahead, behind int
+	fmt.Sscan(counts, &ahead, &behind)
+	switch {
+	case ahead == 0 && behind == 0:
+		return "up to date"
+	case ahead == 0:
+		return fmt.Sprintf("%d behind", behind)
+	case behind == 0:
+		return fmt.Sprintf("%d ahead", ahead)
+	default:
+		return fmt.Sprintf("%d ahead, %d behind", ahead, behind)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -17,8 +18,18 @@ type Project struct {
 	LastMod  string
 	GitState string
 	Favorite bool
+	// Language is the main language, from the project files, or ""
+	Language string
+	// Stamp tells whether the project changed since it was detected
+	Stamp cache.Stamp
+	// Tags are set by the user, detection leaves them empty
+	Tags []string
 }
 
+// ArchiveDir is where Archive moves projects, inside their project
+// directory. Scans skip it.
+const ArchiveDir = ".archive"
+
 // DetectProject attempts to identify a project at the given path
 func DetectProject(path string, config *config.Config) (*Project, error) {
 	info, err := os.Stat(path)
@@ -44,12 +55,12 @@ func DetectProject(path string, config *config.Config) (*Project, error) {
 	}
 
 	// Check if project is in favorites
-	favorite := false
-	for _, favPath := range config.Favorites {
-		if favPath == path {
-			favorite = true
-			break
-		}
+	favorite := isFavorite(path, config)
+
+	// Stamp after running git, which may refresh the index
+	stamp, err := ReadStamp(path)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Project{
@@ -58,63 +69,205 @@ func DetectProject(path string, config *config.Config) (*Project, error) {
 		LastMod:  lastMod,
 		GitState: gitState,
 		Favorite: favorite,
+		Language: DetectLanguage(path),
+		Stamp:    stamp,
 	}, nil
 }
 
+// ReadStamp returns the modification times of a project directory and of
+// its .git/HEAD and .git/index, which are zero when missing. A commit only
+// moves the current branch, so Head is the later of HEAD and the branch.
+func ReadStamp(path string) (cache.Stamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cache.Stamp{}, err
+	}
+	stamp := cache.Stamp{Dir: info.ModTime()}
+	headPath := filepath.Join(path, ".git", "HEAD")
+	if info, err := os.Stat(headPath); err == nil {
+		stamp.Head = info.ModTime()
+		if head, err := os.ReadFile(headPath); err == nil {
+			if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: "); ok {
+				if info, err := os.Stat(filepath.Join(path, ".git", filepath.FromSlash(ref))); err == nil && info.ModTime().After(stamp.Head) {
+					stamp.Head = info.ModTime()
+				}
+			}
+		}
+	}
+	if info, err := os.Stat(filepath.Join(path, ".git", "index")); err == nil {
+		stamp.Index = info.ModTime()
+	}
+	return stamp, nil
+}
+
+// Archive moves the project at path into the archive of its project
+// directory and returns where it went
+func Archive(path string) (string, error) {
+	archive := filepath.Join(filepath.Dir(path), ArchiveDir)
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return "", fmt.Errorf("could not create archive: %v", err)
+	}
+	target := filepath.Join(archive, filepath.Base(path))
+	if _, err := os.Lstat(target); err == nil {
+		return "", fmt.Errorf("%s is already in the archive", filepath.Base(path))
+	}
+	if err := os.Rename(path, target); err != nil {
+		return "", fmt.Errorf("could not archive: %v", err)
+	}
+	return target, nil
+}
+
 // ScanForProjects scans directories for projects
 func ScanForProjects(dirs []string, config *config.Config) []Project {
-	var projects []Project
+	scanned, _ := Rescan(nil, dirs, config)
+	return ConvertCacheToProjects(scanned.Projects)
+}
 
-	for _, dir := range dirs {
-		// Check if directory exists and is accessible
-		if _, err := os.Stat(dir); err != nil {
-			continue
+// RescanStats counts the work done by Rescan
+type RescanStats struct {
+	RootsListed int // project directories whose entries were read
+	Reused      int // projects taken from the cache
+	Detected    int // projects detected again
+}
+
+// Rescan brings a cache up to date with the project directories. A
+// directory whose mtime is unchanged is not listed again, and a cached
+// project whose Stamp is unchanged is reused instead of being detected
+// again, so a warm start costs a few stat calls per project. prev may be nil.
+func Rescan(prev *cache.ProjectCache, dirs []string, cfg *config.Config) (*cache.ProjectCache, RescanStats) {
+	return RescanStream(prev, dirs, cfg, nil)
+}
+
+// RescanStream is Rescan, calling detected with every project that had to
+// be detected again as soon as it is done. detected may be nil.
+func RescanStream(prev *cache.ProjectCache, dirs []string, cfg *config.Config, detected func(Project)) (*cache.ProjectCache, RescanStats) {
+	var stats RescanStats
+	options := detectOptions(cfg)
+
+	cached := make(map[string]cache.Project)
+	var cachedRoots map[string]time.Time
+	if prev != nil && prev.DetectOptions == options {
+		for _, p := range prev.Projects {
+			cached[p.Path] = p
 		}
+		cachedRoots = prev.Roots
+	}
+
+	next := &cache.ProjectCache{
+		Projects:      []cache.Project{},
+		LastUpdated:   time.Now(),
+		Roots:         make(map[string]time.Time, len(dirs)),
+		DetectOptions: options,
+	}
 
-		// Read directory entries
-		entries, err := os.ReadDir(dir)
+	for _, dir := range dirs {
+		// Check if directory exists and is accessible
+		info, err := os.Stat(dir)
 		if err != nil {
 			continue
 		}
 
-		// Check each entry
-		for _, entry := range entries {
-			if !entry.IsDir() {
+		var paths []string
+		if mod, ok := cachedRoots[dir]; ok && mod.Equal(info.ModTime()) {
+			// No entries were added or removed since the last listing
+			for _, p := range prev.Projects {
+				if filepath.Dir(p.Path) == filepath.Clean(dir) {
+					paths = append(paths, p.Path)
+				}
+			}
+		} else {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
 				continue
 			}
+			stats.RootsListed++
+			for _, entry := range entries {
+				if entry.IsDir() && entry.Name() != ArchiveDir {
+					paths = append(paths, filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+		next.Roots[dir] = info.ModTime()
+
+		for _, path := range paths {
+			if old, ok := cached[path]; ok {
+				if stamp, err := ReadStamp(path); err == nil && stamp.Equal(old.Stamp) {
+					old.Favorite = isFavorite(path, cfg)
+					next.Projects = append(next.Projects, old)
+					stats.Reused++
+					continue
+				}
+			}
 
-			fullPath := filepath.Join(dir, entry.Name())
-			project, err := DetectProject(fullPath, config)
+			project, err := DetectProject(path, cfg)
 			if err != nil {
 				continue
 			}
+			if old, ok := cached[path]; ok {
+				project.Tags = old.Tags
+			}
+			next.Projects = append(next.Projects, ConvertProjectsToCache([]Project{*project})...)
+			stats.Detected++
+			if detected != nil {
+				detected(*project)
+			}
+		}
+	}
+
+	next.DirectoryMap = countCachedByRoot(next.Projects, dirs)
+	return next, stats
+}
+
+// detectVersion is bumped when DetectProject fills in more fields, so that
+// cached projects are detected again
+const detectVersion = 2
 
-			projects = append(projects, *project)
+// detectOptions describes the settings that change what DetectProject returns
+func detectOptions(cfg *config.Config) string {
+	return fmt.Sprintf("v%d git=%t style=%s", detectVersion, cfg.Preferences.ShowGitStatus, cfg.Preferences.GitStatusStyle)
+}
+
+func isFavorite(path string, cfg *config.Config) bool {
+	for _, favPath := range cfg.Favorites {
+		if favPath == path {
+			return true
 		}
 	}
+	return false
+}
 
-	return projects
+// projectFiles are common project files and the language they belong to,
+// more specific files first
+var projectFiles = []struct {
+	name     string
+	language string
+}{
+	{"tsconfig.json", "TypeScript"},
+	{"package.json", "JavaScript"},
+	{"Cargo.toml", "Rust"},
+	{"go.mod", "Go"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"Gemfile", "Ruby"},
+	{"composer.json", "PHP"},
 }
 
 // HasProjectFile checks if the directory contains common project files
 func HasProjectFile(path string) bool {
-	projectFiles := []string{
-		"package.json",     // Node.js
-		"Cargo.toml",       // Rust
-		"go.mod",           // Go
-		"requirements.txt", // Python
-		"pom.xml",          // Java/Maven
-		"build.gradle",     // Java/Gradle
-		"Gemfile",          // Ruby
-		"composer.json",    // PHP
-	}
+	return DetectLanguage(path) != ""
+}
 
+// DetectLanguage returns the language of the first project file found in
+// the directory, or ""
+func DetectLanguage(path string) string {
 	for _, file := range projectFiles {
-		if _, err := os.Stat(filepath.Join(path, file)); err == nil {
-			return true
+		if _, err := os.Stat(filepath.Join(path, file.name)); err == nil {
+			return file.language
 		}
 	}
-	return false
+	return ""
 }
 
 func getGitStatus(path string, cfg *config.Config) string {
@@ -147,6 +300,56 @@ func formatGitStatus(rawStatus string, style string) string {
 	return rawStatus
 }
 
+// RootOf returns the project directory from roots that contains path, or ""
+func RootOf(path string, roots []string) string {
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return ""
+}
+
+// CountByRoot returns the number of projects in each project directory
+func CountByRoot(projects []Project, roots []string) map[string]int {
+	counts := make(map[string]int, len(roots))
+	for _, root := range roots {
+		counts[root] = 0
+	}
+	for _, p := range projects {
+		if root := RootOf(p.Path, roots); root != "" {
+			counts[root]++
+		}
+	}
+	return counts
+}
+
+// countCachedByRoot is CountByRoot for cached projects
+func countCachedByRoot(projects []cache.Project, roots []string) map[string]int {
+	counts := make(map[string]int, len(roots))
+	for _, root := range roots {
+		counts[root] = 0
+	}
+	for _, p := range projects {
+		if root := RootOf(p.Path, roots); root != "" {
+			counts[root]++
+		}
+	}
+	return counts
+}
+
+// BuildCache creates a project cache for projects found in the config's
+// project directories. The directories are listed again on the next
+// Rescan, but unchanged projects are reused.
+func BuildCache(projects []Project, cfg *config.Config) *cache.ProjectCache {
+	return &cache.ProjectCache{
+		Projects:      ConvertProjectsToCache(projects),
+		LastUpdated:   time.Now(),
+		DirectoryMap:  CountByRoot(projects, cfg.ProjectDirs),
+		DetectOptions: detectOptions(cfg),
+	}
+}
+
 // ConvertCacheToProjects converts cached projects to Project structs
 func ConvertCacheToProjects(cached []cache.Project) []Project {
 	projects := make([]Project, len(cached))
@@ -157,6 +360,9 @@ func ConvertCacheToProjects(cached []cache.Project) []Project {
 			LastMod:  p.LastMod.Format("2006-01-02 15:04:05"),
 			GitState: p.GitState,
 			Favorite: p.Favorite,
+			Language: p.Language,
+			Stamp:    p.Stamp,
+			Tags:     p.Tags,
 		}
 	}
 	return projects
@@ -173,6 +379,9 @@ func ConvertProjectsToCache(projects []Project) []cache.Project {
 			LastMod:  lastMod,
 			GitState: p.GitState,
 			Favorite: p.Favorite,
+			Language: p.Language,
+			Stamp:    p.Stamp,
+			Tags:     p.Tags,
 		}
 	}
 	return cached
@@ -0,0 +1,174 @@
+package project
+
+import (
+	"den/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRescanReusesUnchangedProjects(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"api", "web"} {
+		if err := os.MkdirAll(filepath.Join(root, name, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+			t.Fatalf("Failed to write HEAD: %v", err)
+		}
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ProjectDirs = []string{root}
+	cfg.Preferences.ShowGitStatus = false
+
+	cold, stats := Rescan(nil, cfg.ProjectDirs, cfg)
+	if len(cold.Projects) != 2 || stats.Detected != 2 || stats.RootsListed != 1 {
+		t.Fatalf("unexpected cold scan: %d projects, %+v", len(cold.Projects), stats)
+	}
+	if cold.DirectoryMap[root] != 2 {
+		t.Errorf("expected DirectoryMap to count 2 projects, got %v", cold.DirectoryMap)
+	}
+
+	warm, stats := Rescan(cold, cfg.ProjectDirs, cfg)
+	if len(warm.Projects) != 2 || stats.Reused != 2 || stats.Detected != 0 || stats.RootsListed != 0 {
+		t.Errorf("expected a warm scan to reuse everything, got %+v", stats)
+	}
+
+	// Moving HEAD, e.g. on checkout, re-detects only that project
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(root, "api", ".git", "HEAD"), later, later); err != nil {
+		t.Fatalf("Failed to touch HEAD: %v", err)
+	}
+	_, stats = Rescan(warm, cfg.ProjectDirs, cfg)
+	if stats.Reused != 1 || stats.Detected != 1 || stats.RootsListed != 0 {
+		t.Errorf("expected one project to be re-detected, got %+v", stats)
+	}
+
+	// A new project changes the root's mtime, so the root is listed again
+	if err := os.Mkdir(filepath.Join(root, "cli"), 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if err := os.Chtimes(root, later, later); err != nil {
+		t.Fatalf("Failed to touch root: %v", err)
+	}
+	added, stats := Rescan(warm, cfg.ProjectDirs, cfg)
+	if len(added.Projects) != 3 || stats.RootsListed != 1 || stats.Detected != 2 {
+		t.Errorf("expected the new project to be found, got %d projects, %+v", len(added.Projects), stats)
+	}
+
+	// Changing how projects are detected invalidates everything
+	cfg.Preferences.GitStatusStyle = "nerd"
+	_, stats = Rescan(added, cfg.ProjectDirs, cfg)
+	if stats.Reused != 0 || stats.Detected != 3 {
+		t.Errorf("expected all projects to be re-detected, got %+v", stats)
+	}
+}
+
+func TestGitInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"remote", "add", "upstream", "https://example.com/den.git"},
+		{"-c", "user.name=den", "-c", "user.email=den@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	if url, err := RemoteURL(dir); err != nil || url != "https://example.com/den.git" {
+		t.Errorf("expected the only remote without an origin, got %q, %v", url, err)
+	}
+	if branch, err := Branch(dir); err != nil || branch != "main" {
+		t.Errorf("expected branch main, got %q, %v", branch, err)
+	}
+	hash, err := HeadCommit(dir)
+	if err != nil || len(hash) != 40 {
+		t.Fatalf("expected a commit hash, got %q, %v", hash, err)
+	}
+
+	if out, err := exec.Command("git", "-C", dir, "checkout", "-q", "--detach").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout: %v\n%s", err, out)
+	}
+	if _, err := Branch(dir); err == nil || err.Error() != "detached HEAD" {
+		t.Errorf("expected a detached HEAD error, got %v", err)
+	}
+	if _, err := RemoteURL(t.TempDir()); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"api", "web"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0755); err != nil {
+			t.Fatalf("Failed to create project: %v", err)
+		}
+	}
+
+	target, err := Archive(filepath.Join(root, "api"))
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if target != filepath.Join(root, ArchiveDir, "api") {
+		t.Errorf("expected api in the archive, got %s", target)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected the project to be moved: %v", err)
+	}
+
+	// Archiving a project of the same name again keeps the first one
+	if err := os.Mkdir(filepath.Join(root, "api"), 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	if _, err := Archive(filepath.Join(root, "api")); err == nil {
+		t.Error("expected an error for a project already in the archive")
+	}
+	if err := os.Remove(filepath.Join(root, "api")); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Preferences.ShowGitStatus = false
+	scanned, _ := Rescan(nil, []string{root}, cfg)
+	if len(scanned.Projects) != 1 || scanned.Projects[0].Name != "web" {
+		t.Errorf("expected the archive to be skipped, got %+v", scanned.Projects)
+	}
+}
+
+func TestFetchAndPull(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	run := func(dir string, args ...string) {
+		t.Helper()
+		args = append([]string{"-C", dir, "-c", "user.name=den", "-c", "user.email=den@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	upstream, clone := t.TempDir(), filepath.Join(t.TempDir(), "clone")
+	run(upstream, "init", "-q", "-b", "main")
+	run(upstream, "commit", "-q", "--allow-empty", "-m", "init")
+	run(upstream, "clone", "-q", upstream, clone)
+	run(upstream, "commit", "-q", "--allow-empty", "-m", "second")
+
+	if status, err := Fetch(clone); err != nil || status != "1 behind" {
+		t.Errorf("expected the clone to be 1 behind, got %q, %v", status, err)
+	}
+	if _, err := Pull(clone); err != nil {
+		t.Errorf("expected a fast-forward, got %v", err)
+	}
+	if status, err := Fetch(clone); err != nil || status != "up to date" {
+		t.Errorf("expected the clone to be up to date, got %q, %v", status, err)
+	}
+	if _, err := Pull(upstream); err == nil {
+		t.Error("expected pulling without an upstream to fail")
+	}
+}
@@ -0,0 +1,119 @@
+// Package runner runs custom commands and den-* plugins in projects
+package runner
+
+import (
+	"den/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginPrefix starts the names of executables that extend den. A plugin
+// den-foo runs as "den foo" and shows up in the command palette.
+const PluginPrefix = "den-"
+
+// Command is a custom command from the config, or a plugin
+type Command struct {
+	Name        string
+	Description string
+	// Run is the shell command of a custom command
+	Run string
+	// Plugin is the path of a plugin executable
+	Plugin string
+}
+
+// IsPlugin reports whether c is a plugin
+func (c Command) IsPlugin() bool {
+	return c.Plugin != ""
+}
+
+// Plugins returns the plugins in PATH, by name. Like the shell, the first
+// executable of a name wins.
+func Plugins() []Command {
+	seen := make(map[string]bool)
+	var plugins []Command
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			file := entry.Name()
+			if !strings.HasPrefix(file, PluginPrefix) || entry.IsDir() {
+				continue
+			}
+			name := strings.TrimPrefix(file, PluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if name == "" || seen[name] {
+				continue
+			}
+			// LookPath checks that the file is executable
+			path, err := exec.LookPath(filepath.Join(dir, file))
+			if err != nil {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Command{Name: name, Description: "plugin", Plugin: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// FindPlugin returns the plugin called name
+func FindPlugin(name string) (Command, bool) {
+	// Only look in PATH, never at a relative path
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, `/\`) {
+		return Command{}, false
+	}
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil {
+		return Command{}, false
+	}
+	return Command{Name: name, Description: "plugin", Plugin: path}, true
+}
+
+// Custom returns the custom commands of the project at dir, by name. Like
+// config.ProjectCommands, it returns the commands of the config along with
+// the error for an untrusted project file.
+func Custom(cfg *config.Config, dir string) ([]Command, error) {
+	configured, err := cfg.ProjectCommands(dir)
+	if configured == nil {
+		return nil, err
+	}
+	commands := make([]Command, 0, len(configured))
+	for name, c := range configured {
+		commands = append(commands, Command{Name: name, Description: c.Description, Run: c.Run})
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands, err
+}
+
+// ForProject returns the custom commands of the project at dir, then the
+// plugins, with the error of Custom
+func ForProject(cfg *config.Config, dir string) ([]Command, error) {
+	commands, err := Custom(cfg, dir)
+	if commands == nil && err != nil {
+		return nil, err
+	}
+	return append(commands, Plugins()...), err
+}
+
+// Cmd returns c ready to run in the project at dir. Commands find the
+// project in DEN_PROJECT and DEN_PROJECT_PATH.
+func (c Command) Cmd(project, dir string, args ...string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if c.IsPlugin() {
+		cmd = exec.Command(c.Plugin, args...)
+	} else {
+		cmd = exec.Command("sh", append([]string{"-c", c.Run, c.Name}, args...)...)
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "DEN_PROJECT="+project, "DEN_PROJECT_PATH="+dir)
+	return cmd
+}
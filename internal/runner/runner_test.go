@@ -0,0 +1,69 @@
+//go:build unix
+
+package runner
+
+import (
+	"den/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	script := "#!/bin/sh\necho \"$DEN_PROJECT $*\"\n"
+	for _, f := range []struct {
+		dir, name string
+		mode      os.FileMode
+	}{
+		{first, "den-hello", 0755},
+		{second, "den-hello", 0755},
+		{second, "den-bye", 0755},
+		{second, "den-notes", 0644},
+		{second, "other", 0755},
+	} {
+		if err := os.WriteFile(filepath.Join(f.dir, f.name), []byte(script), f.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+
+	plugins := Plugins()
+	if len(plugins) != 2 || plugins[0].Name != "bye" || plugins[1].Name != "hello" {
+		t.Fatalf("expected the bye and hello plugins, got %+v", plugins)
+	}
+	if plugins[1].Plugin != filepath.Join(first, "den-hello") {
+		t.Errorf("expected the first hello in PATH, got %s", plugins[1].Plugin)
+	}
+
+	if _, ok := FindPlugin("../hello"); ok {
+		t.Error("expected relative paths to be rejected")
+	}
+	plugin, ok := FindPlugin("hello")
+	if !ok {
+		t.Fatal("expected to find the hello plugin")
+	}
+	out, err := plugin.Cmd("api", t.TempDir(), "there").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "api there" {
+		t.Errorf("unexpected plugin output %q, %v", out, err)
+	}
+}
+
+func TestCustomCommand(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Commands = map[string]config.CommandConfig{"where": {Run: "pwd; echo $DEN_PROJECT"}}
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := Custom(cfg, dir)
+	if err != nil || len(commands) != 1 {
+		t.Fatalf("expected one command, got %v, %v", commands, err)
+	}
+	out, err := commands[0].Cmd("api", dir).Output()
+	if err != nil || string(out) != dir+"\napi\n" {
+		t.Errorf("unexpected output %q, %v", out, err)
+	}
+}
@@ -0,0 +1,91 @@
+package shellwords
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Split splits s into words the way a POSIX shell does, without expanding
+// anything: words are separated by unquoted whitespace, single quotes keep
+// everything literally, and in double quotes and bare words a backslash
+// escapes the next character. In double quotes only \", \\, \$ and \` are
+// escapes, like in sh.
+func Split(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	const (
+		bare = iota
+		single
+		double
+	)
+	state := bare
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch state {
+		case single:
+			if r == '\'' {
+				state = bare
+			} else {
+				word.WriteRune(r)
+			}
+		case double:
+			switch {
+			case r == '"':
+				state = bare
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		default:
+			switch {
+			case r == ' ' || r == '\t' || r == '\n':
+				if inWord {
+					words = append(words, word.String())
+					word.Reset()
+					inWord = false
+				}
+			case r == '\'':
+				state = single
+				inWord = true
+			case r == '"':
+				state = double
+				inWord = true
+			case r == '\\':
+				if i+1 == len(runes) {
+					return nil, fmt.Errorf("trailing backslash")
+				}
+				i++
+				word.WriteRune(runes[i])
+				inWord = true
+			default:
+				word.WriteRune(r)
+				inWord = true
+			}
+		}
+	}
+
+	switch state {
+	case single:
+		return nil, fmt.Errorf("unterminated single quote")
+	case double:
+		return nil, fmt.Errorf("unterminated double quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Quote returns s quoted so that Split reads it back as a single word
+func Quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,47 @@
+package shellwords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"code --wait", []string{"code", "--wait"}},
+		{`  nvim   -c "cd {path}" `, []string{"nvim", "-c", "cd {path}"}},
+		{`idea '{path}'`, []string{"idea", "{path}"}},
+		{`a\ b "c\"d" 'e\f'`, []string{"a b", `c"d`, `e\f`}},
+		{`"\n"`, []string{`\n`}},
+		{`x"y"'z'`, []string{"xyz"}},
+		{`"" ''`, []string{"", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.in)
+		if err != nil {
+			t.Errorf("Split(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{`"open`, `'open`, `trailing\`} {
+		if _, err := Split(bad); err == nil {
+			t.Errorf("Split(%q) should fail", bad)
+		}
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	for _, s := range []string{"plain", "with space", "it's", `back\slash`, ""} {
+		got, err := Split(Quote(s))
+		if err != nil || len(got) != 1 || got[0] != s {
+			t.Errorf("Split(Quote(%q)) = %q, %v", s, got, err)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+// SchemaVersion is the layout of the buckets. Bump it when the layout
+// changes; stores of another version are reset.
+const SchemaVersion = 1
+
+// openTimeout is how long Open waits for another process holding the store
+const openTimeout = 5 * time.Second
+
+// historyLimit is the number of visits kept in the history
+const historyLimit = 1000
+
+var (
+	metaBucket     = []byte("meta")
+	projectsBucket = []byte("projects")
+	historyBucket  = []byte("history")
+	statsBucket    = []byte("stats")
+	rootIndex      = []byte("idx_root")
+	tagIndex       = []byte("idx_tag")
+	languageIndex  = []byte("idx_language")
+
+	allBuckets = [][]byte{metaBucket, projectsBucket, historyBucket, statsBucket, rootIndex, tagIndex, languageIndex}
+
+	schemaKey = []byte("schema")
+)
+
+// Project is a project record. Root, Tags and Language are indexed.
+type Project struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Root     string    `json:"root,omitempty"`
+	LastMod  time.Time `json:"lastMod"`
+	GitState string    `json:"gitState"`
+	Favorite bool      `json:"favorite"`
+	Stamp    Stamp     `json:"stamp"`
+	Language string    `json:"language,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+// Stamp holds the modification times that show whether a project changed
+// since it was detected: its directory, and .git/HEAD and .git/index for
+// git repositories
+type Stamp struct {
+	Dir   time.Time `json:"dir"`
+	Head  time.Time `json:"head"`
+	Index time.Time `json:"index"`
+}
+
+// Equal reports whether both stamps hold the same times
+func (s Stamp) Equal(other Stamp) bool {
+	return s.Dir.Equal(other.Dir) && s.Head.Equal(other.Head) && s.Index.Equal(other.Index)
+}
+
+// Visit is an entry of the usage history
+type Visit struct {
+	Path string
+	Time time.Time
+}
+
+// Usage is the cached usage statistics of a project
+type Usage struct {
+	Visits    int       `json:"visits"`
+	LastVisit time.Time `json:"lastVisit"`
+}
+
+// Store is den's persistent state, kept in a single bbolt file. Only one
+// process can have it open for writing, so open it for short operations.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store at path, creating it if needed. It waits for other
+// processes to close it, and fails with a *SchemaError if the file was
+// written with another SchemaVersion.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{db: db}
+	var version int
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if data := meta.Get(schemaKey); data != nil {
+			version = int(binary.BigEndian.Uint64(data))
+			if version != SchemaVersion {
+				return nil
+			}
+		} else {
+			version = SchemaVersion
+			if err := meta.Put(schemaKey, encodeUint(SchemaVersion)); err != nil {
+				return err
+			}
+		}
+		return createBuckets(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if version != SchemaVersion {
+		return s, &SchemaError{Version: version}
+	}
+	return s, nil
+}
+
+// SchemaError is returned by Open for a store of another SchemaVersion.
+// The store is still open, and Reset makes it usable.
+type SchemaError struct {
+	Version int
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("store has format version %d, expected %d", e.Version, SchemaVersion)
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Path returns the file of the store
+func (s *Store) Path() string {
+	return s.db.Path()
+}
+
+// Reset removes everything from the store and sets the current schema
+func (s *Store) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolterrors.ErrBucketNotFound) {
+				return err
+			}
+		}
+		meta, err := tx.CreateBucket(metaBucket)
+		if err != nil {
+			return err
+		}
+		if err := meta.Put(schemaKey, encodeUint(SchemaVersion)); err != nil {
+			return err
+		}
+		return createBuckets(tx)
+	})
+}
+
+func createBuckets(tx *bolt.Tx) error {
+	for _, name := range allBuckets {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// View runs fn in a read-only transaction
+func (s *Store) View(fn func(*Tx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// Update runs fn in a read-write transaction. Nothing is written if fn
+// returns an error.
+func (s *Store) Update(fn func(*Tx) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// Tx is a transaction on the store
+type Tx struct {
+	tx *bolt.Tx
+}
+
+// Meta decodes the metadata stored under key into v. It reports whether the
+// key was found.
+func (t *Tx) Meta(key string, v any) (bool, error) {
+	data := t.tx.Bucket(metaBucket).Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("corrupt metadata %s: %v", key, err)
+	}
+	return true, nil
+}
+
+// SetMeta stores v as the metadata under key
+func (t *Tx) SetMeta(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(metaBucket).Put([]byte(key), data)
+}
+
+// Project returns the project at path. It reports whether it was found.
+func (t *Tx) Project(path string) (Project, bool, error) {
+	data := t.tx.Bucket(projectsBucket).Get([]byte(path))
+	if data == nil {
+		return Project{}, false, nil
+	}
+	var p Project
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Project{}, false, fmt.Errorf("corrupt project %s: %v", path, err)
+	}
+	return p, true, nil
+}
+
+// Projects returns all projects, ordered by path
+func (t *Tx) Projects() ([]Project, error) {
+	projects := []Project{}
+	err := t.tx.Bucket(projectsBucket).ForEach(func(k, v []byte) error {
+		var p Project
+		if err := json.Unmarshal(v, &p); err != nil {
+			return fmt.Errorf("corrupt project %s: %v", k, err)
+		}
+		projects = append(projects, p)
+		return nil
+	})
+	return projects, err
+}
+
+// PutProject adds or replaces a project and updates the indexes. Root
+// defaults to the parent directory of the project. Unchanged projects are
+// not written.
+func (t *Tx) PutProject(p Project) error {
+	if p.Path == "" {
+		return fmt.Errorf("project has no path")
+	}
+	if p.Root == "" {
+		p.Root = filepath.Dir(p.Path)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, t.tx.Bucket(projectsBucket).Get([]byte(p.Path))) {
+		return nil
+	}
+	if err := t.DeleteProject(p.Path); err != nil {
+		return err
+	}
+	if err := t.tx.Bucket(projectsBucket).Put([]byte(p.Path), data); err != nil {
+		return err
+	}
+	return t.index(p, (*bolt.Bucket).Put)
+}
+
+// DeleteProject removes a project and its index entries. Removing a
+// missing project is not an error.
+func (t *Tx) DeleteProject(path string) error {
+	old, ok, err := t.Project(path)
+	if err != nil || !ok {
+		return err
+	}
+	if err := t.index(old, func(b *bolt.Bucket, key, _ []byte) error {
+		return b.Delete(key)
+	}); err != nil {
+		return err
+	}
+	return t.tx.Bucket(projectsBucket).Delete([]byte(path))
+}
+
+// index applies op to every index entry of p
+func (t *Tx) index(p Project, op func(b *bolt.Bucket, key, value []byte) error) error {
+	if err := op(t.tx.Bucket(rootIndex), indexKey(p.Root, p.Path), []byte{}); err != nil {
+		return err
+	}
+	if p.Language != "" {
+		if err := op(t.tx.Bucket(languageIndex), indexKey(strings.ToLower(p.Language), p.Path), []byte{}); err != nil {
+			return err
+		}
+	}
+	for _, tag := range p.Tags {
+		if err := op(t.tx.Bucket(tagIndex), indexKey(strings.ToLower(tag), p.Path), []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the project at path
+func (t *Tx) SetTags(path string, tags []string) error {
+	p, ok, err := t.Project(path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("unknown project %s", path)
+	}
+	p.Tags = tags
+	return t.PutProject(p)
+}
+
+// ProjectsByRoot returns the projects found in the project directory root
+func (t *Tx) ProjectsByRoot(root string) ([]Project, error) {
+	return t.lookup(rootIndex, filepath.Clean(root))
+}
+
+// ProjectsByTag returns the projects with a tag, ignoring case
+func (t *Tx) ProjectsByTag(tag string) ([]Project, error) {
+	return t.lookup(tagIndex, strings.ToLower(tag))
+}
+
+// ProjectsByLanguage returns the projects in a language, ignoring case
+func (t *Tx) ProjectsByLanguage(language string) ([]Project, error) {
+	return t.lookup(languageIndex, strings.ToLower(language))
+}
+
+// Languages counts the projects of each language
+func (t *Tx) Languages() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := t.tx.Bucket(projectsBucket).ForEach(func(_, v []byte) error {
+		var p Project
+		if err := json.Unmarshal(v, &p); err != nil {
+			return err
+		}
+		if p.Language != "" {
+			counts[p.Language]++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// lookup returns the projects listed in an index under value
+func (t *Tx) lookup(index []byte, value string) ([]Project, error) {
+	prefix := indexKey(value, "")
+	projects := []Project{}
+	c := t.tx.Bucket(index).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, _ = c.Next() {
+		p, ok, err := t.Project(string(k[len(prefix):]))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			projects = append(projects, p)
+		}
+	}
+	return projects, nil
+}
+
+// indexKey joins an indexed value and a project path. Paths never contain
+// a NUL byte, so values don't run into each other.
+func indexKey(value, path string) []byte {
+	return []byte(value + "\x00" + path)
+}
+
+// RecordVisit adds a visit of path to the history and updates its usage.
+// The oldest visits are dropped once the history is full.
+func (t *Tx) RecordVisit(path string, at time.Time) error {
+	history := t.tx.Bucket(historyBucket)
+	key := encodeUint(uint64(at.UnixNano()))
+	// Visits in the same nanosecond get distinct keys
+	for history.Get(key) != nil {
+		key = encodeUint(binary.BigEndian.Uint64(key) + 1)
+	}
+	if err := history.Put(key, []byte(path)); err != nil {
+		return err
+	}
+	c := history.Cursor()
+	n := 0
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		n++
+	}
+	for k, _ := c.First(); k != nil && n > historyLimit; k, _ = c.First() {
+		if err := history.Delete(k); err != nil {
+			return err
+		}
+		n--
+	}
+
+	usage, err := t.Usage(path)
+	if err != nil {
+		return err
+	}
+	usage.Visits++
+	usage.LastVisit = at
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	return t.tx.Bucket(statsBucket).Put([]byte(path), data)
+}
+
+// History returns up to limit visits, most recent first
+func (t *Tx) History(limit int) []Visit {
+	var visits []Visit
+	c := t.tx.Bucket(historyBucket).Cursor()
+	for k, v := c.Last(); k != nil && len(visits) < limit; k, v = c.Prev() {
+		visits = append(visits, Visit{
+			Path: string(v),
+			Time: time.Unix(0, int64(binary.BigEndian.Uint64(k))),
+		})
+	}
+	return visits
+}
+
+// Usage returns the usage statistics of the project at path
+func (t *Tx) Usage(path string) (Usage, error) {
+	var usage Usage
+	data := t.tx.Bucket(statsBucket).Get([]byte(path))
+	if data == nil {
+		return usage, nil
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return usage, fmt.Errorf("corrupt usage of %s: %v", path, err)
+	}
+	return usage, nil
+}
+
+func encodeUint(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// IsCorrupt reports whether err means the store file is damaged or not a
+// store at all
+func IsCorrupt(err error) bool {
+	return errors.Is(err, bolterrors.ErrInvalid) || errors.Is(err, bolterrors.ErrChecksum) || errors.Is(err, bolterrors.ErrVersionMismatch)
+}
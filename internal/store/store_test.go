@@ -0,0 +1,138 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "den.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func paths(projects []Project) []string {
+	var out []string
+	for _, p := range projects {
+		out = append(out, p.Path)
+	}
+	return out
+}
+
+func TestIndexedQueries(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Update(func(tx *Tx) error {
+		for _, p := range []Project{
+			{Name: "api", Path: "/code/api", Language: "Go", Tags: []string{"work"}},
+			{Name: "web", Path: "/code/web", Language: "TypeScript", Tags: []string{"work", "Frontend"}},
+			{Name: "dots", Path: "/home/me/dots"},
+		} {
+			if err := tx.PutProject(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PutProject failed: %v", err)
+	}
+
+	s.View(func(tx *Tx) error {
+		if got, _ := tx.ProjectsByRoot("/code/"); len(got) != 2 {
+			t.Errorf("expected 2 projects in /code, got %v", paths(got))
+		}
+		if got, _ := tx.ProjectsByLanguage("go"); len(got) != 1 || got[0].Path != "/code/api" {
+			t.Errorf("expected the Go project, got %v", paths(got))
+		}
+		if got, _ := tx.ProjectsByTag("frontend"); len(got) != 1 || got[0].Path != "/code/web" {
+			t.Errorf("expected the frontend project, got %v", paths(got))
+		}
+		// A value must not match values it is a prefix of
+		if got, _ := tx.ProjectsByTag("wor"); len(got) != 0 {
+			t.Errorf("expected no projects for a partial tag, got %v", paths(got))
+		}
+		return nil
+	})
+
+	// Changing a project moves its index entries
+	err = s.Update(func(tx *Tx) error {
+		if err := tx.SetTags("/code/web", nil); err != nil {
+			return err
+		}
+		return tx.DeleteProject("/code/api")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	s.View(func(tx *Tx) error {
+		if got, _ := tx.ProjectsByTag("work"); len(got) != 0 {
+			t.Errorf("expected stale tag entries to be removed, got %v", paths(got))
+		}
+		if got, _ := tx.ProjectsByLanguage("go"); len(got) != 0 {
+			t.Errorf("expected deleted project to be unindexed, got %v", paths(got))
+		}
+		return nil
+	})
+}
+
+func TestUpdateIsTransactional(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.Update(func(tx *Tx) error {
+		if err := tx.PutProject(Project{Name: "api", Path: "/code/api"}); err != nil {
+			return err
+		}
+		return tx.PutProject(Project{Name: "broken"})
+	})
+	if err == nil {
+		t.Fatal("expected a project without a path to fail")
+	}
+	s.View(func(tx *Tx) error {
+		if projects, _ := tx.Projects(); len(projects) != 0 {
+			t.Errorf("expected the failed update to be rolled back, got %v", paths(projects))
+		}
+		return nil
+	})
+}
+
+func TestRecordVisit(t *testing.T) {
+	s := openTestStore(t)
+	start := time.Now()
+
+	err := s.Update(func(tx *Tx) error {
+		for i := 0; i < historyLimit+5; i++ {
+			path := "/code/api"
+			if i%2 == 1 {
+				path = "/code/web"
+			}
+			if err := tx.RecordVisit(path, start.Add(time.Duration(i)*time.Second)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RecordVisit failed: %v", err)
+	}
+
+	s.View(func(tx *Tx) error {
+		history := tx.History(historyLimit * 2)
+		if len(history) != historyLimit {
+			t.Errorf("expected the history to be capped at %d, got %d", historyLimit, len(history))
+		}
+		if history[0].Path != "/code/api" || !history[0].Time.After(history[1].Time) {
+			t.Errorf("expected the most recent visit first, got %+v", history[:2])
+		}
+		usage, _ := tx.Usage("/code/web")
+		if usage.Visits != (historyLimit+5)/2 {
+			t.Errorf("expected usage to count every visit, got %+v", usage)
+		}
+		return nil
+	})
+}
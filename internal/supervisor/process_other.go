@@ -0,0 +1,24 @@
+//go:build !unix
+
+package supervisor
+
+import "os"
+
+// alive can't be checked on this platform without waiting for the process,
+// so processes are assumed to run until they are stopped
+func alive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
+
+func terminate(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+func kill(pid int) {
+	terminate(pid)
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package supervisor
+
+import "syscall"
+
+// Processes are started in their own session, so their pid is also the id
+// of the process group holding everything they started
+
+// alive reports whether any process of the group is still running
+func alive(pid int) bool {
+	err := syscall.Kill(-pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func terminate(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+func kill(pid int) {
+	syscall.Kill(-pid, syscall.SIGKILL)
+}
@@ -0,0 +1,273 @@
+// Package supervisor keeps track of the background processes den starts,
+// such as dev servers, so that they can be listed, stopped and restarted,
+// also by den processes started later
+package supervisor
+
+import (
+	"crypto/sha256"
+	"den/internal/editor"
+	"den/internal/fsutil"
+	"den/internal/paths"
+	"den/internal/procscan"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// startupGrace is how long a process is watched after it starts, so that
+// commands that fail right away are reported
+const startupGrace = 500 * time.Millisecond
+
+// stopTimeout is how long Stop waits before killing a process
+const stopTimeout = 5 * time.Second
+
+// Process is a background process started for a project
+type Process struct {
+	PID int `json:"pid"`
+	// Project is the path of the project, and Dir the working directory
+	Project string    `json:"project"`
+	Name    string    `json:"name"`
+	Command string    `json:"command"`
+	Dir     string    `json:"dir"`
+	Env     []string  `json:"env,omitempty"`
+	Port    int       `json:"port,omitempty"`
+	Log     string    `json:"log"`
+	Started time.Time `json:"started"`
+	// StartTicks is when the process started after boot, from /proc, so
+	// that a process that reused the pid is not taken for it. It is 0
+	// where it can't be read.
+	StartTicks uint64 `json:"startTicks,omitempty"`
+}
+
+// Spec describes a process to start
+type Spec struct {
+	Project string
+	Name    string
+	Command string
+	Dir     string
+	// Env are variables set in addition to den's environment
+	Env  []string
+	Port int
+}
+
+// StatePath returns the file the processes are recorded in
+func StatePath() (string, error) {
+	return paths.StateFile("processes.json")
+}
+
+// LogPath returns the file the processes of the project at path log to.
+// It is named after the project, and a hash of the path tells projects of
+// the same name apart.
+func LogPath(project string) (string, error) {
+	sum := sha256.Sum256([]byte(filepath.Clean(project)))
+	name := filepath.Base(project) + "-" + hex.EncodeToString(sum[:])[:12] + ".log"
+	return paths.StateFile(filepath.Join("logs", name))
+}
+
+// Start runs a command with the shell in its own session and records it.
+// It fails if the command exits with an error right away.
+func Start(spec Spec) (*Process, error) {
+	logPath, err := LogPath(spec.Project)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+	fmt.Fprintf(log, "==> %s: %s\n", time.Now().Format(time.RFC3339), spec.Command)
+
+	cmd := exec.Command("sh", "-c", spec.Command)
+	cmd.Dir = spec.Dir
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), spec.Env...)
+	}
+	cmd.Stdout = log
+	cmd.Stderr = log
+	if err := editor.StartDetached(cmd); err != nil {
+		return nil, err
+	}
+
+	p := &Process{
+		PID:     cmd.Process.Pid,
+		Project: spec.Project,
+		Name:    spec.Name,
+		Command: spec.Command,
+		Dir:     spec.Dir,
+		Env:     spec.Env,
+		Port:    spec.Port,
+		Log:     logPath,
+		Started: time.Now(),
+	}
+	if ticks, err := procscan.StartTicks(p.PID); err == nil {
+		p.StartTicks = ticks
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		if err != nil {
+			return nil, fmt.Errorf("%v, see %s", err, logPath)
+		}
+		// Done already, e.g. a build step, or a command that forked
+		if !alive(p.PID) {
+			return p, nil
+		}
+	case <-time.After(startupGrace):
+		// Still running, as a dev server should
+	}
+
+	err = update(func(procs []Process) []Process {
+		return append(procs, *p)
+	})
+	return p, err
+}
+
+// List returns the recorded processes that are still running, oldest
+// first. Processes that exited, or whose pid now belongs to another
+// process, are forgotten.
+func List() ([]Process, error) {
+	var running []Process
+	err := update(func(procs []Process) []Process {
+		running = running[:0]
+		for _, p := range procs {
+			if p.running() {
+				running = append(running, p)
+			}
+		}
+		return running
+	})
+	return running, err
+}
+
+// running reports whether the recorded process still runs. Where start
+// times can be read, a process whose start time differs took over the pid
+// and a record without one can't be trusted. Once the process itself
+// exited the pid can't be reused while its group lives on.
+func (p Process) running() bool {
+	if !alive(p.PID) {
+		return false
+	}
+	if !procscan.Supported {
+		return true
+	}
+	ticks, err := procscan.StartTicks(p.PID)
+	if err != nil {
+		return os.IsNotExist(err) && p.StartTicks != 0
+	}
+	return ticks == p.StartTicks
+}
+
+// find returns the running process recorded with pid
+func find(pid int) (Process, error) {
+	procs, err := List()
+	if err != nil {
+		return Process{}, err
+	}
+	for _, p := range procs {
+		if p.PID == pid {
+			return p, nil
+		}
+	}
+	return Process{}, fmt.Errorf("process %d is not running", pid)
+}
+
+// ByProject returns the running processes grouped by project path
+func ByProject() (map[string][]Process, error) {
+	procs, err := List()
+	if err != nil {
+		return nil, err
+	}
+	byProject := make(map[string][]Process)
+	for _, p := range procs {
+		byProject[p.Project] = append(byProject[p.Project], p)
+	}
+	return byProject, nil
+}
+
+// Stop terminates a process and everything it started, killing it if it
+// doesn't exit in time. Only recorded processes that still run are
+// signalled.
+func Stop(pid int) error {
+	if _, err := find(pid); err != nil {
+		return err
+	}
+	if err := terminate(pid); err != nil && alive(pid) {
+		return fmt.Errorf("could not stop process %d: %v", pid, err)
+	}
+	deadline := time.Now().Add(stopTimeout)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			kill(pid)
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return update(func(procs []Process) []Process {
+		return remove(procs, pid)
+	})
+}
+
+// Restart stops a process and starts its command again
+func Restart(pid int) (*Process, error) {
+	p, err := find(pid)
+	if err != nil {
+		return nil, err
+	}
+	if err := Stop(pid); err != nil {
+		return nil, err
+	}
+	return Start(Spec{Project: p.Project, Name: p.Name, Command: p.Command, Dir: p.Dir, Env: p.Env, Port: p.Port})
+}
+
+func remove(procs []Process, pid int) []Process {
+	kept := procs[:0]
+	for _, p := range procs {
+		if p.PID != pid {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// update changes the recorded processes under a lock, so that concurrent
+// den processes don't lose each other's changes
+func update(fn func([]Process) []Process) error {
+	path, err := StatePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := fsutil.LockFile(path, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var procs []Process
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		// A damaged file only loses track of processes
+		json.Unmarshal(data, &procs)
+	}
+
+	procs = fn(procs)
+	sort.SliceStable(procs, func(i, j int) bool { return procs[i].Started.Before(procs[j].Started) })
+	data, err = json.MarshalIndent(procs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
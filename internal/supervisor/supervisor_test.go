@@ -0,0 +1,120 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"den/internal/procscan"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSupervisedProcess(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	p, err := Start(Spec{Project: dir, Name: "app", Command: "exec sleep 30", Dir: dir, Port: 3000})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { kill(p.PID) })
+
+	// A record of a process that is gone is forgotten
+	if err := update(func(procs []Process) []Process {
+		return append(procs, Process{PID: 1 << 22, Project: dir, Name: "gone"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	byProject, err := ByProject()
+	if err != nil {
+		t.Fatalf("ByProject failed: %v", err)
+	}
+	if procs := byProject[dir]; len(procs) != 1 || procs[0].PID != p.PID || procs[0].Port != 3000 {
+		t.Fatalf("expected the running process only, got %+v", procs)
+	}
+
+	restarted, err := Restart(p.PID)
+	if err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	t.Cleanup(func() { kill(restarted.PID) })
+	if alive(p.PID) || !alive(restarted.PID) {
+		t.Errorf("expected pid %d to be replaced by %d", p.PID, restarted.PID)
+	}
+
+	if err := Stop(restarted.PID); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if procs, _ := List(); len(procs) != 0 || alive(restarted.PID) {
+		t.Errorf("expected no processes after stopping, got %+v", procs)
+	}
+}
+
+func TestStartReportsEarlyFailure(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	_, err := Start(Spec{Project: dir, Name: "app", Command: "exit 2", Dir: dir})
+	if err == nil || !strings.Contains(err.Error(), "exit status 2") {
+		t.Errorf("expected the exit status to be reported, got %v", err)
+	}
+	if procs, _ := List(); len(procs) != 0 {
+		t.Errorf("failed process was recorded: %+v", procs)
+	}
+}
+
+func TestReusedPidIsNotSignalled(t *testing.T) {
+	if !procscan.Supported {
+		t.Skip("start times can't be read on this platform")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	p, err := Start(Spec{Project: dir, Name: "app", Command: "exec sleep 30", Dir: dir})
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { kill(p.PID) })
+	if p.StartTicks == 0 {
+		t.Fatal("expected the start time to be recorded")
+	}
+
+	// Pretend that another process got the pid since
+	if err := update(func(procs []Process) []Process {
+		for i := range procs {
+			procs[i].StartTicks++
+		}
+		return procs
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Stop(p.PID); err == nil {
+		t.Error("expected Stop to refuse a process that doesn't match its record")
+	}
+	if !alive(p.PID) {
+		t.Error("expected the process to be left alone")
+	}
+	if procs, _ := List(); len(procs) != 0 {
+		t.Errorf("expected the stale record to be dropped, got %+v", procs)
+	}
+}
+
+func TestLogPathIsPerProjectPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	work, err := LogPath("/home/me/work/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	personal, _ := LogPath("/home/me/personal/api")
+	if work == personal {
+		t.Errorf("expected projects of the same name to log apart, both log to %s", work)
+	}
+	if again, _ := LogPath("/home/me/work/api/"); again != work {
+		t.Errorf("expected the same project to keep its log, got %s and %s", work, again)
+	}
+	if !strings.HasPrefix(filepath.Base(work), "api-") {
+		t.Errorf("expected the log to be named after the project, got %s", work)
+	}
+}
@@ -12,6 +12,7 @@ type Theme struct {
 	SelectedText lipgloss.Color
 	Border       lipgloss.Color
 	Error        lipgloss.Color
+	Warning      lipgloss.Color
 	Success      lipgloss.Color
 }
 
@@ -27,6 +28,7 @@ var (
 			SelectedText: lipgloss.Color("255"), // White
 			Border:       lipgloss.Color("205"), // Pink
 			Error:        lipgloss.Color("196"), // Red
+			Warning:      lipgloss.Color("214"), // Orange
 			Success:      lipgloss.Color("46"),  // Green
 		},
 		"dracula": {
@@ -39,6 +41,7 @@ var (
 			SelectedText: lipgloss.Color("255"), // White
 			Border:       lipgloss.Color("141"), // Purple
 			Error:        lipgloss.Color("203"), // Red
+			Warning:      lipgloss.Color("228"), // Yellow
 			Success:      lipgloss.Color("84"),  // Green
 		},
 		"nord": {
@@ -51,6 +54,7 @@ var (
 			SelectedText: lipgloss.Color("255"), // White
 			Border:       lipgloss.Color("110"), // Light blue
 			Error:        lipgloss.Color("167"), // Red
+			Warning:      lipgloss.Color("222"), // Yellow
 			Success:      lipgloss.Color("108"), // Green
 		},
 		"gruvbox": {
@@ -63,6 +67,7 @@ var (
 			SelectedText: lipgloss.Color("229"), // Light yellow
 			Border:       lipgloss.Color("214"), // Orange
 			Error:        lipgloss.Color("167"), // Red
+			Warning:      lipgloss.Color("172"), // Yellow
 			Success:      lipgloss.Color("142"), // Green
 		},
 		"solarized": {
@@ -75,6 +80,7 @@ var (
 			SelectedText: lipgloss.Color("254"), // Light gray
 			Border:       lipgloss.Color("136"), // Yellow
 			Error:        lipgloss.Color("160"), // Red
+			Warning:      lipgloss.Color("136"), // Yellow
 			Success:      lipgloss.Color("64"),  // Green
 		},
 	}
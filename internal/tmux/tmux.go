@@ -0,0 +1,162 @@
+// Package tmux creates and attaches tmux sessions for projects
+package tmux
+
+import (
+	"crypto/sha256"
+	"den/internal/config"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Server is the tmux server den talks to
+type Server struct {
+	// Socket is the path of a private server socket, "" for the default
+	// server
+	Socket string
+}
+
+// Available reports whether tmux is installed
+func Available() bool {
+	_, err := exec.LookPath("tmux")
+	return err == nil
+}
+
+// Inside reports whether den runs inside a tmux client
+func Inside() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// SessionName returns the session name for a project in dir. A short hash
+// of dir tells apart projects with the same name, and tmux doesn't allow "."
+// and ":" in session names.
+func SessionName(project, dir string) string {
+	name := strings.NewReplacer(".", "_", ":", "_").Replace(project)
+	if name == "" {
+		name = "den"
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(dir)))
+	return name + "-" + hex.EncodeToString(sum[:])[:6]
+}
+
+// Command returns a tmux command on the server
+func (s Server) Command(args ...string) *exec.Cmd {
+	if s.Socket != "" {
+		args = append([]string{"-S", s.Socket}, args...)
+	}
+	return exec.Command("tmux", args...)
+}
+
+// run runs a tmux command and returns its trimmed output
+func (s Server) run(args ...string) (string, error) {
+	out, err := s.Command(args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("tmux %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// HasSession reports whether a session called name exists
+func (s Server) HasSession(name string) bool {
+	// "=" matches the name exactly instead of as a prefix
+	return s.Command("has-session", "-t", "="+name).Run() == nil
+}
+
+// Ensure creates the session called name in dir with the given windows,
+// unless it already exists. It reports whether the session was created.
+func (s Server) Ensure(name, dir string, windows []config.TmuxWindow) (bool, error) {
+	if s.HasSession(name) {
+		return false, nil
+	}
+	if len(windows) == 0 {
+		windows = []config.TmuxWindow{{}}
+	}
+
+	for i, w := range windows {
+		// Windows and panes are addressed by id, which doesn't depend on
+		// the user's base-index
+		var args []string
+		if i == 0 {
+			args = []string{"new-session", "-d", "-s", name, "-c", dir, "-P", "-F", "#{window_id} #{pane_id}"}
+		} else {
+			args = []string{"new-window", "-d", "-t", "=" + name + ":", "-c", dir, "-P", "-F", "#{window_id} #{pane_id}"}
+		}
+		if w.Name != "" {
+			args = append(args, "-n", w.Name)
+		}
+		out, err := s.run(args...)
+		if err != nil {
+			if i > 0 {
+				s.run("kill-session", "-t", "="+name)
+			}
+			return false, err
+		}
+		window, pane, _ := strings.Cut(out, " ")
+
+		if err := s.setUpWindow(window, pane, dir, w); err != nil {
+			s.run("kill-session", "-t", "="+name)
+			return false, err
+		}
+	}
+	// Start in the first window
+	s.run("select-window", "-t", "="+name+":^")
+	return true, nil
+}
+
+// setUpWindow splits a new window into its panes and starts their commands
+func (s Server) setUpWindow(window, first, dir string, w config.TmuxWindow) error {
+	panes := []string{first}
+	for range w.Panes[min(1, len(w.Panes)):] {
+		pane, err := s.run("split-window", "-d", "-t", window, "-c", dir, "-P", "-F", "#{pane_id}")
+		if err != nil {
+			return err
+		}
+		panes = append(panes, pane)
+		// Keep room for the next split
+		s.run("select-layout", "-t", window, "tiled")
+	}
+	if w.Layout != "" {
+		if _, err := s.run("select-layout", "-t", window, w.Layout); err != nil {
+			return err
+		}
+	}
+	for i, command := range w.Panes {
+		if command == "" {
+			continue
+		}
+		if _, err := s.run("send-keys", "-t", panes[i], command, "Enter"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachCommand returns the command that shows session name: it switches
+// the current client when den runs inside tmux, and attaches otherwise.
+func (s Server) AttachCommand(name string) *exec.Cmd {
+	if Inside() {
+		return s.Command("switch-client", "-t", "="+name)
+	}
+	return s.Command("attach-session", "-t", "="+name)
+}
+
+// Open creates the session for a project if needed and returns the command
+// that shows it
+func (s Server) Open(project, dir string, cfg *config.Config) (*exec.Cmd, error) {
+	windows, err := cfg.TmuxLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+	name := SessionName(project, dir)
+	if _, err := s.Ensure(name, dir, windows); err != nil {
+		return nil, err
+	}
+	return s.AttachCommand(name), nil
+}
@@ -0,0 +1,116 @@
+package tmux
+
+import (
+	"den/internal/config"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testServer returns a tmux server on a private socket, killed when the
+// test ends
+func testServer(t *testing.T) Server {
+	t.Helper()
+	if !Available() {
+		t.Skip("tmux is not installed")
+	}
+	dir, err := os.MkdirTemp("", "den-tmux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	s := Server{Socket: filepath.Join(dir, "sock")}
+	t.Cleanup(func() { s.Command("kill-server").Run() })
+	return s
+}
+
+func TestEnsureCreatesLayout(t *testing.T) {
+	s := testServer(t)
+	dir := t.TempDir()
+	windows := []config.TmuxWindow{
+		{Name: "editor"},
+		{Name: "dev", Panes: []string{"", "echo started", ""}, Layout: "even-horizontal"},
+	}
+
+	created, err := s.Ensure("my_app", dir, windows)
+	if err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	if !created {
+		t.Fatal("expected the session to be created")
+	}
+
+	out, err := s.run("list-windows", "-t", "=my_app", "-F", "#{window_name} #{window_panes}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "editor 1\ndev 3" {
+		t.Errorf("unexpected windows:\n%s", out)
+	}
+	out, err = s.run("list-panes", "-s", "-t", "=my_app", "-F", "#{pane_start_path}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range strings.Split(out, "\n") {
+		if path != dir {
+			t.Errorf("pane started in %s, want %s", path, dir)
+		}
+	}
+
+	// An existing session is reused as it is
+	created, err = s.Ensure("my_app", dir, nil)
+	if err != nil || created {
+		t.Errorf("expected the session to be reused, got created=%t err=%v", created, err)
+	}
+}
+
+func TestSessionName(t *testing.T) {
+	name := SessionName("den.io", "/home/me/code/den.io")
+	if !strings.HasPrefix(name, "den_io-") || strings.ContainsAny(name, ".:") {
+		t.Errorf("unexpected session name %q", name)
+	}
+	if other := SessionName("den.io", "/home/me/work/den.io"); other == name {
+		t.Errorf("expected projects with the same name to get different sessions, both got %q", name)
+	}
+	if again := SessionName("den.io", "/home/me/code/den.io/"); again != name {
+		t.Errorf("expected the same session for the same directory, got %q and %q", name, again)
+	}
+}
+
+func TestOpenUsesProjectLayout(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	s := testServer(t)
+	dir := t.TempDir()
+	project := "[[tmux.windows]]\nname = \"shell\"\n\n[[tmux.windows]]\nname = \"logs\"\n"
+	if err := os.WriteFile(filepath.Join(dir, config.ProjectFile), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Tmux.Windows = []config.TmuxWindow{{Name: "ignored"}}
+
+	// Nothing runs before the file is trusted
+	var untrusted *config.UntrustedError
+	if _, err := s.Open("den.io", dir, cfg); !errors.As(err, &untrusted) {
+		t.Fatalf("expected the layout to need trust, got %v", err)
+	}
+	name := SessionName("den.io", dir)
+	if _, err := s.run("has-session", "-t", "="+name); err == nil {
+		t.Fatal("expected no session before the layout is trusted")
+	}
+
+	if err := config.TrustProjectFile(dir, []byte(project)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open("den.io", dir, cfg); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	out, err := s.run("list-windows", "-t", "="+name, "-F", "#{window_name}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "shell\nlogs" {
+		t.Errorf("expected the .den.toml layout, got:\n%s", out)
+	}
+}
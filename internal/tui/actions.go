@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"den/internal/editor"
+	"den/internal/project"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ContextAction is an entry of the context menu
+type ContextAction struct {
+	Name string
+	// Run acts on the selected project. Actions are nil when they only
+	// close the menu.
+	Run func(m Model, p project.Project) (tea.Model, tea.Cmd)
+	// Key is the shortcut that runs the action from the list, if any
+	Key func(k KeyMap) key.Binding
+}
+
+// contextActions returns the context menu entries, in order
+func contextActions() []ContextAction {
+	return []ContextAction{
+		{Name: "Editor", Run: Model.openInEditor},
+		{Name: "Open With…", Run: Model.openOpenWith},
+		{Name: "Explorer", Run: Model.openInExplorer},
+		{Name: "Terminal", Run: Model.openInTerminal, Key: func(k KeyMap) key.Binding { return k.OpenTerminal }},
+		{Name: "Tmux", Run: Model.openInTmux},
+		{Name: "Start Working", Run: Model.startWorking},
+		{Name: "Processes", Run: Model.openProcesses},
+		{Name: "Copy…", Run: Model.openCopy},
+		{Name: "Toggle Favorite", Run: Model.toggleFavoriteAction, Key: func(k KeyMap) key.Binding { return k.ToggleFavorite }},
+		{Name: "Cancel"},
+	}
+}
+
+func (m Model) openInEditor(p project.Project) (tea.Model, tea.Cmd) {
+	ed, err := editor.DefaultEditor(m.Config)
+	if err != nil {
+		m.Status.Errorf("Error opening editor: %v", err)
+		return m, nil
+	}
+	return m.openWith(ed, p)
+}
+
+// openWith opens a project in an editor
+func (m Model) openWith(ed editor.Editor, p project.Project) (tea.Model, tea.Cmd) {
+	model, cmd := m.runEditor(ed, editor.ProjectTarget(p.Path), false)
+	return model, tea.Batch(recordVisits(m.Config.ActiveProfile(), p.Path), cmd)
+}
+
+func (m Model) openInExplorer(p project.Project) (tea.Model, tea.Cmd) {
+	if err := editor.OpenInFileExplorer(p.Path, m.Config); err != nil {
+		// Stay open so the error can be read
+		m.Status.Errorf("Error opening file explorer: %v", err)
+		return m, nil
+	}
+	// Quit only once the visit is saved
+	return m, tea.Sequence(recordVisits(m.Config.ActiveProfile(), p.Path), tea.Quit)
+}
+
+// openInTerminal opens a terminal emulator at the project and keeps den
+// running
+func (m Model) openInTerminal(p project.Project) (tea.Model, tea.Cmd) {
+	if err := editor.OpenInTerminal(p.Path, m.Config); err != nil {
+		m.Status.Errorf("Error opening terminal: %v", err)
+		return m, nil
+	}
+	m.Status.Successf("Opened a terminal in %s", p.Name)
+	return m, recordVisits(m.Config.ActiveProfile(), p.Path)
+}
+
+func (m Model) toggleFavoriteAction(p project.Project) (tea.Model, tea.Cmd) {
+	cmd, err := m.toggleFavorite(p)
+	if err != nil {
+		m.Status.Errorf("%v", err)
+	}
+	return m, cmd
+}
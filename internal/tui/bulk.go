@@ -0,0 +1,701 @@
+package tui
+
+import (
+	"den/internal/bulk"
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/editor"
+	"den/internal/paths"
+	"den/internal/project"
+	"den/internal/runner"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// toggleSelected selects the highlighted project, or unselects it, and
+// moves on to the next one
+func (m Model) toggleSelected() (tea.Model, tea.Cmd) {
+	i, ok := m.List.SelectedItem().(ListItem)
+	if !ok {
+		return m, nil
+	}
+	m.setSelected(i.Project.Path, !m.Selected[i.Project.Path])
+	m.selectAnchor = i.Project.Path
+
+	visible := m.List.VisibleItems()
+	next := visible[min(m.List.Index()+1, len(visible)-1)].(ListItem).Project.Path
+	cmd := m.refreshListItems()
+	if m.filterApplied() {
+		m.reselect = next
+	} else {
+		m.selectProject(next)
+	}
+	return m, cmd
+}
+
+// selectRange selects the visible projects from the last one toggled to
+// the highlighted one
+func (m Model) selectRange() (tea.Model, tea.Cmd) {
+	i, ok := m.List.SelectedItem().(ListItem)
+	if !ok {
+		return m, nil
+	}
+	visible := m.List.VisibleItems()
+	anchor := -1
+	for idx, item := range visible {
+		if item.(ListItem).Project.Path == m.selectAnchor {
+			anchor = idx
+		}
+	}
+	if anchor < 0 {
+		// Nothing to start from, select just this one
+		return m.toggleSelected()
+	}
+
+	from, to := min(anchor, m.List.Index()), max(anchor, m.List.Index())
+	for _, item := range visible[from : to+1] {
+		m.setSelected(item.(ListItem).Project.Path, true)
+	}
+	m.selectAnchor = i.Project.Path
+	m.Status.Infof("%d selected", len(m.Selected))
+	return m, m.refreshListItems()
+}
+
+// selectAll selects the visible projects, or unselects them when they
+// already are
+func (m Model) selectAll() (tea.Model, tea.Cmd) {
+	visible := m.List.VisibleItems()
+	all := true
+	for _, item := range visible {
+		if !item.(ListItem).Selected {
+			all = false
+			break
+		}
+	}
+	for _, item := range visible {
+		m.setSelected(item.(ListItem).Project.Path, !all)
+	}
+	m.Status.Infof("%d selected", len(m.Selected))
+	return m, m.refreshListItems()
+}
+
+// clearSelection unselects every project
+func (m Model) clearSelection() (tea.Model, tea.Cmd) {
+	m.Selected = nil
+	m.selectAnchor = ""
+	return m, m.refreshListItems()
+}
+
+func (m *Model) setSelected(path string, selected bool) {
+	if !selected {
+		delete(m.Selected, path)
+		return
+	}
+	if m.Selected == nil {
+		m.Selected = make(map[string]bool)
+	}
+	m.Selected[path] = true
+}
+
+// selectedProjects returns the selected projects in list order. Projects
+// that went away since they were selected are left out.
+func (m Model) selectedProjects() []project.Project {
+	var selected []project.Project
+	for _, p := range m.Projects {
+		if m.Selected[p.Path] {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// BulkAction is an entry of the bulk actions menu
+type BulkAction struct {
+	Name string
+	// Run acts on the selected projects. Actions are nil when they only
+	// close the menu.
+	Run func(m Model, projects []project.Project) (tea.Model, tea.Cmd)
+}
+
+// bulkActions returns the bulk actions menu entries, in order
+func bulkActions() []BulkAction {
+	return []BulkAction{
+		{Name: "Fetch", Run: Model.bulkFetch},
+		{Name: "Pull", Run: Model.bulkPull},
+		{Name: "Tag…", Run: Model.openBulkTag},
+		{Name: "Favorite", Run: Model.bulkFavorite},
+		{Name: "Archive…", Run: Model.openBulkArchive},
+		{Name: "Run Command…", Run: Model.openBulkCommand},
+		{Name: "Open as Workspace", Run: Model.openWorkspace},
+		{Name: "Clear Selection", Run: func(m Model, _ []project.Project) (tea.Model, tea.Cmd) {
+			return m.clearSelection()
+		}},
+		{Name: "Cancel"},
+	}
+}
+
+// bulkMenuMode is what the bulk actions menu is asking for
+type bulkMenuMode int
+
+const (
+	bulkMenuActions bulkMenuMode = iota
+	bulkMenuTag
+	bulkMenuCommand
+	bulkMenuArchive
+)
+
+// bulkMenuKeys are the keys of the bulk actions menu prompts
+var bulkMenuKeys = struct {
+	Confirm key.Binding
+}{
+	Confirm: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm the archive")),
+}
+
+// BulkMenuState tracks the bulk actions menu
+type BulkMenuState struct {
+	Projects []project.Project
+	Cursor   int
+	Mode     bulkMenuMode
+	// Input is the tag being typed
+	Input string
+	// Commands are the custom commands and plugins of the projects
+	Commands []runner.Command
+	Err      error
+}
+
+func (st *BulkMenuState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleBulkMenuUpdate(msg)
+}
+
+func (st *BulkMenuState) view(m Model) string {
+	return m.renderBulkMenuView()
+}
+
+// openBulkMenu shows the actions for the selected projects
+func (m Model) openBulkMenu() (tea.Model, tea.Cmd) {
+	projects := m.selectedProjects()
+	if len(projects) == 0 {
+		m.Status.Warnf("No projects selected")
+		return m, nil
+	}
+	m.Overlay = &BulkMenuState{Projects: projects}
+	return m, nil
+}
+
+func (m Model) handleBulkMenuUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*BulkMenuState)
+	switch st.Mode {
+	case bulkMenuTag:
+		return m.handleBulkTagUpdate(msg)
+	case bulkMenuArchive:
+		st.Mode = bulkMenuActions
+		if key.Matches(msg, bulkMenuKeys.Confirm) {
+			m.Overlay = nil
+			return m.bulkArchive(st.Projects)
+		}
+		return m, nil
+	}
+
+	entries := len(bulkActions())
+	if st.Mode == bulkMenuCommand {
+		entries = len(st.Commands)
+	}
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < entries-1 {
+			st.Cursor++
+		}
+	case key.Matches(msg, m.KeyMap.Enter):
+		m.Overlay = nil
+		if st.Mode == bulkMenuCommand {
+			return m.bulkRunCommand(st.Projects, st.Commands[st.Cursor])
+		}
+		if action := bulkActions()[st.Cursor]; action.Run != nil {
+			return action.Run(m, st.Projects)
+		}
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		if st.Mode == bulkMenuCommand {
+			st.Mode = bulkMenuActions
+			st.Cursor = 0
+		} else {
+			m.Overlay = nil
+		}
+	}
+	return m, nil
+}
+
+func (m Model) handleBulkTagUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*BulkMenuState)
+	switch msg.Type {
+	case tea.KeyEnter:
+		tag := strings.TrimSpace(st.Input)
+		if tag == "" || strings.ContainsAny(tag, " \t") {
+			st.Err = fmt.Errorf("tags are a single word")
+			return m, nil
+		}
+		m.Overlay = nil
+		return m.bulkTag(st.Projects, tag)
+	case tea.KeyEsc:
+		st.Mode = bulkMenuActions
+		st.Input = ""
+		st.Err = nil
+	case tea.KeyBackspace:
+		if st.Input != "" {
+			runes := []rune(st.Input)
+			st.Input = string(runes[:len(runes)-1])
+		}
+		st.Err = nil
+	case tea.KeyRunes, tea.KeySpace:
+		st.Input += string(msg.Runes)
+		st.Err = nil
+	}
+	return m, nil
+}
+
+// projectPaths returns the paths of projects
+func projectPaths(projects []project.Project) []string {
+	dirs := make([]string, len(projects))
+	for i, p := range projects {
+		dirs[i] = p.Path
+	}
+	return dirs
+}
+
+func (m Model) bulkFetch(projects []project.Project) (tea.Model, tea.Cmd) {
+	return m.startBulk("Fetch", projects, false, project.Fetch)
+}
+
+func (m Model) bulkPull(projects []project.Project) (tea.Model, tea.Cmd) {
+	return m.startBulk("Pull", projects, false, project.Pull)
+}
+
+func (m Model) openBulkTag(projects []project.Project) (tea.Model, tea.Cmd) {
+	m.Overlay = &BulkMenuState{Projects: projects, Mode: bulkMenuTag}
+	return m, nil
+}
+
+// bulkTag adds a tag to the projects
+func (m Model) bulkTag(projects []project.Project, tag string) (tea.Model, tea.Cmd) {
+	if err := cache.AddTag(m.Config.ActiveProfile(), projectPaths(projects), tag); err != nil {
+		m.Status.Errorf("Error tagging projects: %v", err)
+		return m, nil
+	}
+	selected := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		selected[p.Path] = true
+	}
+	for i, p := range m.Projects {
+		if selected[p.Path] && !slices.Contains(p.Tags, tag) {
+			m.Projects[i].Tags = append(append([]string{}, p.Tags...), tag)
+		}
+	}
+	m.Status.Successf("Tagged %d projects #%s", len(projects), tag)
+	return m, m.refreshListItems()
+}
+
+// bulkFavorite adds the projects to the favorites
+func (m Model) bulkFavorite(projects []project.Project) (tea.Model, tea.Cmd) {
+	added := make(map[string]bool)
+	for _, p := range projects {
+		if !m.isFavorite(p.Path) {
+			m.Config.Favorites = append(m.Config.Favorites, p.Path)
+			added[p.Path] = true
+		}
+	}
+	if len(added) == 0 {
+		m.Status.Infof("The selected projects are already favorites")
+		return m, nil
+	}
+	if err := config.SaveConfig(m.Config); err != nil {
+		m.Status.Errorf("Error saving favorites: %v", err)
+		return m, nil
+	}
+	for i, p := range m.Projects {
+		if added[p.Path] {
+			m.Projects[i].Favorite = true
+		}
+	}
+	if err := m.saveCache(); err != nil {
+		m.Status.Errorf("Error saving cache: %v", err)
+	} else {
+		m.Status.Successf("Added %d favorites", len(added))
+	}
+	m.syncWatcher()
+	return m, m.refreshListItems()
+}
+
+func (m Model) openBulkArchive(projects []project.Project) (tea.Model, tea.Cmd) {
+	m.Overlay = &BulkMenuState{Projects: projects, Mode: bulkMenuArchive}
+	return m, nil
+}
+
+// bulkArchive moves the projects into the archive of their project
+// directory
+func (m Model) bulkArchive(projects []project.Project) (tea.Model, tea.Cmd) {
+	return m.startBulk("Archive", projects, true, func(path string) (string, error) {
+		target, err := project.Archive(path)
+		if err != nil {
+			return "", err
+		}
+		return "moved to " + target, nil
+	})
+}
+
+// openBulkCommand lists the custom commands of the projects and the
+// plugins
+func (m Model) openBulkCommand(projects []project.Project) (tea.Model, tea.Cmd) {
+	st := &BulkMenuState{Projects: projects, Mode: bulkMenuCommand}
+	seen := make(map[string]bool)
+	for _, p := range projects {
+		// An untrusted project file leaves the commands of the config
+		custom, err := runner.Custom(m.Config, p.Path)
+		if err != nil {
+			m.Status.Warnf("Custom commands of %s: %v", p.Name, err)
+		}
+		for _, c := range custom {
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				st.Commands = append(st.Commands, c)
+			}
+		}
+	}
+	st.Commands = append(st.Commands, runner.Plugins()...)
+	if len(st.Commands) == 0 {
+		m.Status.Warnf("No custom commands or plugins to run")
+		return m, nil
+	}
+	m.Overlay = st
+	return m, nil
+}
+
+// bulkRunCommand runs a custom command or plugin in the projects. Custom
+// commands are looked up again in each project, whose .den.toml may
+// define them differently or not at all, and fail in a project whose
+// .den.toml isn't trusted.
+func (m Model) bulkRunCommand(projects []project.Project, c runner.Command) (tea.Model, tea.Cmd) {
+	cfg := m.Config.Clone()
+	return m.startBulk("Run "+c.Name, projects, false, func(path string) (string, error) {
+		run := c
+		if !c.IsPlugin() {
+			custom, err := runner.Custom(cfg, path)
+			if err != nil {
+				return "", err
+			}
+			found := false
+			for _, pc := range custom {
+				if pc.Name == c.Name {
+					run, found = pc, true
+				}
+			}
+			if !found {
+				return "", fmt.Errorf("no %s command", c.Name)
+			}
+		}
+		out, err := run.Cmd(filepath.Base(path), path).CombinedOutput()
+		return bulk.LastLine(string(out)), err
+	})
+}
+
+// openWorkspace opens the projects together in a multi-root workspace
+func (m Model) openWorkspace(projects []project.Project) (tea.Model, tea.Cmd) {
+	ed, err := editor.WorkspaceEditor(m.Config)
+	if err != nil {
+		m.Status.Errorf("Error opening workspace: %v", err)
+		return m, nil
+	}
+	file, err := paths.StateFile("selection.code-workspace")
+	if err == nil {
+		err = editor.WriteWorkspace(file, projectPaths(projects))
+	}
+	if err != nil {
+		m.Status.Errorf("Error opening workspace: %v", err)
+		return m, nil
+	}
+	model, cmd := m.runEditor(ed, editor.Target{Path: file, Name: fmt.Sprintf("%d projects", len(projects))}, false)
+	return model, tea.Batch(recordVisits(m.Config.ActiveProfile(), projectPaths(projects)...), cmd)
+}
+
+func (m Model) renderBulkMenuView() string {
+	st := m.Overlay.(*BulkMenuState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader(fmt.Sprintf("%d Selected Projects", len(st.Projects))))
+	s.WriteString("\n\n")
+
+	names := make([]string, 0, len(st.Projects))
+	for _, p := range st.Projects {
+		names = append(names, p.Name)
+	}
+	summary := strings.Join(names, ", ")
+	if width := m.Width - 8; width > 0 {
+		summary = ansi.Truncate(summary, width, "…")
+	}
+	s.WriteString(m.Styles.Placeholder.Render(summary) + "\n\n")
+
+	var hint string
+	switch st.Mode {
+	case bulkMenuTag:
+		input := st.Input
+		if input == "" {
+			input = m.Styles.Placeholder.Render("tag")
+		}
+		s.WriteString(m.Styles.Input.Render("#"+input) + "\n")
+		if st.Err != nil {
+			s.WriteString(m.Styles.Error.Render(fmt.Sprintf("Error: %v", st.Err)) + "\n")
+		}
+		hint = helpLine(addDirKeys.Confirm, addDirKeys.Cancel)
+	case bulkMenuArchive:
+		s.WriteString(m.Styles.Error.Render(fmt.Sprintf("Move %d projects into %s? y/n", len(st.Projects), project.ArchiveDir)) + "\n")
+	case bulkMenuCommand:
+		var rows []string
+		for i, c := range st.Commands {
+			row := c.Name
+			if c.Description != "" {
+				row += "  " + m.Styles.Placeholder.Render(c.Description)
+			}
+			rows = append(rows, m.renderMenuRow(row, i == st.Cursor))
+		}
+		s.WriteString(padLines(rows))
+		hint = helpLine(m.KeyMap.Up, m.KeyMap.Down, key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run")), m.KeyMap.Escape)
+	default:
+		var rows []string
+		for i, action := range bulkActions() {
+			rows = append(rows, m.renderMenuRow(action.Name, i == st.Cursor))
+		}
+		s.WriteString(padLines(rows))
+		hint = helpLine(m.KeyMap.Up, m.KeyMap.Down, m.KeyMap.Enter, m.KeyMap.Escape)
+	}
+
+	if hint != "" {
+		s.WriteString("\n" + m.Styles.RegularItem.Render(hint))
+	}
+	return m.centerLines(s.String())
+}
+
+// padLines pads lines to the same width so that they stay lined up once
+// centered, and joins them
+func padLines(lines []string) string {
+	width := 0
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+	var s strings.Builder
+	for _, line := range lines {
+		s.WriteString(line + strings.Repeat(" ", width-lipgloss.Width(line)) + "\n")
+	}
+	return s.String()
+}
+
+// renderMenuRow renders an entry of a vertical menu
+func (m Model) renderMenuRow(row string, selected bool) string {
+	if selected {
+		return m.Styles.SelectedItem.Render("> " + row)
+	}
+	return m.Styles.RegularItem.Render("  " + row)
+}
+
+// bulkItem is a project of a bulk run
+type bulkItem struct {
+	Project project.Project
+	Status  bulk.Status
+	Output  string
+	Err     error
+}
+
+// BulkState tracks a bulk run. The run goes on when its progress table is
+// closed, and its results end up in the status bar.
+type BulkState struct {
+	Title string
+	Items []bulkItem
+	// Moves is set when the task moves the projects away. They are
+	// unselected and rescanned once the run is over.
+	Moves bool
+	// Offset is the number of rows scrolled past
+	Offset int
+}
+
+func (st *BulkState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleBulkUpdate(msg)
+}
+
+func (st *BulkState) view(m Model) string {
+	return m.renderBulkView()
+}
+
+// count returns the number of projects with status s
+func (st *BulkState) count(s bulk.Status) int {
+	n := 0
+	for _, item := range st.Items {
+		if item.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// BulkEventMsg carries the progress of a bulk run
+type BulkEventMsg struct {
+	state  *BulkState
+	events <-chan bulk.Event
+	event  bulk.Event
+	// done is set once every project is done
+	done bool
+}
+
+// startBulk runs task on the projects in the background and shows its
+// progress
+func (m Model) startBulk(title string, projects []project.Project, moves bool, task bulk.Task) (tea.Model, tea.Cmd) {
+	st := &BulkState{Title: title, Moves: moves}
+	for _, p := range projects {
+		st.Items = append(st.Items, bulkItem{Project: p})
+	}
+	m.Overlay = st
+	return m, waitForBulk(st, bulk.Run(projectPaths(projects), bulk.Limit, task))
+}
+
+// waitForBulk delivers the next event of a bulk run
+func waitForBulk(st *BulkState, events <-chan bulk.Event) tea.Cmd {
+	return func() tea.Msg {
+		e, ok := <-events
+		return BulkEventMsg{state: st, events: events, event: e, done: !ok}
+	}
+}
+
+func (m Model) handleBulkEvent(msg BulkEventMsg) (tea.Model, tea.Cmd) {
+	st := msg.state
+	if msg.done {
+		failed := st.count(bulk.Failed)
+		if failed > 0 {
+			m.Status.Errorf("%s failed in %d of %d projects", st.Title, failed, len(st.Items))
+		} else {
+			m.Status.Successf("%s finished in %d projects", st.Title, len(st.Items))
+		}
+		if !st.Moves {
+			return m, nil
+		}
+		for _, item := range st.Items {
+			if item.Status == bulk.Done {
+				delete(m.Selected, item.Project.Path)
+			}
+		}
+		return m, m.startRefresh()
+	}
+
+	e := msg.event
+	item := &st.Items[e.Index]
+	item.Status, item.Output, item.Err = e.Status, e.Output, e.Err
+	next := waitForBulk(st, msg.events)
+	if e.Status == bulk.Running || st.Moves {
+		return m, next
+	}
+	// The task probably changed the git status
+	return m, tea.Batch(next, detectProject(item.Project.Path, m.Config.Clone()))
+}
+
+// bulkHeight is the number of rows of the progress table that fit on the
+// screen
+func (m Model) bulkHeight(rows int) int {
+	// Keep room for the header, the summary and the help line
+	if m.Height > 12 {
+		return min(rows, m.Height-12)
+	}
+	return rows
+}
+
+func (m Model) handleBulkUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*BulkState)
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Offset > 0 {
+			st.Offset--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Offset < len(st.Items)-m.bulkHeight(len(st.Items)) {
+			st.Offset++
+		}
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+// bulkIcons mark where a project is at in a bulk run
+var bulkIcons = map[bulk.Status]string{
+	bulk.Pending: "·",
+	bulk.Running: "…",
+	bulk.Done:    "✓",
+	bulk.Failed:  "✗",
+}
+
+func (m Model) renderBulkView() string {
+	st := m.Overlay.(*BulkState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader(st.Title))
+	s.WriteString("\n\n")
+
+	done, failed := st.count(bulk.Done), st.count(bulk.Failed)
+	summary := fmt.Sprintf("%d/%d done", done+failed, len(st.Items))
+	if failed > 0 {
+		summary += fmt.Sprintf(" · %d failed", failed)
+	}
+	if running := st.count(bulk.Running); running > 0 {
+		summary += fmt.Sprintf(" · %d running", running)
+	}
+	s.WriteString(m.Styles.Placeholder.Render(summary) + "\n\n")
+
+	nameWidth := 0
+	for _, item := range st.Items {
+		nameWidth = max(nameWidth, lipgloss.Width(item.Project.Name))
+	}
+	end := min(len(st.Items), st.Offset+m.bulkHeight(len(st.Items)))
+	var rows []string
+	for _, item := range st.Items[st.Offset:end] {
+		style := m.Styles.Placeholder
+		detail := item.Status.String()
+		switch item.Status {
+		case bulk.Done:
+			style = m.Styles.Success
+			if item.Output != "" {
+				detail = item.Output
+			}
+		case bulk.Failed:
+			style = m.Styles.Error
+			detail = item.Err.Error()
+			if item.Output != "" {
+				detail += " · " + item.Output
+			}
+		case bulk.Running:
+			style = m.Styles.RegularItem
+		}
+		// Long errors would wrap once centered
+		if width := m.Width - nameWidth - 12; m.Width > 0 {
+			detail = ansi.Truncate(detail, max(width, 1), "…")
+		}
+		name := item.Project.Name + strings.Repeat(" ", nameWidth-lipgloss.Width(item.Project.Name))
+		rows = append(rows, style.Render(bulkIcons[item.Status]+" ")+m.Styles.RegularItem.Render(name)+"  "+style.Render(detail))
+	}
+	s.WriteString(padLines(rows))
+
+	hint := helpLine(withDesc(m.KeyMap.Escape, "close"))
+	if done+failed < len(st.Items) {
+		hint = helpLine(withDesc(m.KeyMap.Escape, "close, the run goes on"))
+	}
+	if end < len(st.Items) || st.Offset > 0 {
+		hint = helpLine(m.KeyMap.Up, m.KeyMap.Down) + " • " + hint
+	}
+	s.WriteString("\n" + m.Styles.RegularItem.Render(hint))
+
+	return m.centerLines(s.String())
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"den/internal/bulk"
+	"den/internal/config"
+	"den/internal/project"
+	"den/internal/theme"
+	"den/internal/ui"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSelection(t *testing.T) {
+	m := Model{
+		Config: config.DefaultConfig(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+	for _, name := range []string{"api", "cli", "web", "docs"} {
+		m.Projects = append(m.Projects, project.Project{Name: name, Path: "/code/" + name})
+	}
+	m.refreshListItems()
+	selected := func(m Model) string {
+		var names []string
+		for _, p := range m.selectedProjects() {
+			names = append(names, p.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	model, _ := m.toggleSelected()
+	m = model.(Model)
+	if got := selected(m); got != "api" {
+		t.Errorf("expected api to be selected, got %s", got)
+	}
+	if m.List.Index() != 1 {
+		t.Errorf("expected the cursor to move on, got %d", m.List.Index())
+	}
+
+	// A range goes from the last project toggled
+	m.List.Select(2)
+	model, _ = m.selectRange()
+	m = model.(Model)
+	if got := selected(m); got != "api,cli,web" {
+		t.Errorf("expected api to web to be selected, got %s", got)
+	}
+	if item := m.List.Items()[1].(ListItem); !item.Selected || !strings.HasPrefix(item.Title(), "✓") {
+		t.Errorf("expected cli to be marked, got %q", item.Title())
+	}
+
+	model, _ = m.selectAll()
+	m = model.(Model)
+	if got := selected(m); got != "api,cli,web,docs" {
+		t.Errorf("expected every project to be selected, got %s", got)
+	}
+	model, _ = m.selectAll()
+	m = model.(Model)
+	if got := selected(m); got != "" {
+		t.Errorf("expected select all to unselect every project, got %s", got)
+	}
+}
+
+func TestBulkEvents(t *testing.T) {
+	m := Model{Config: config.DefaultConfig(), Status: NewStatusBar()}
+	projects := []project.Project{{Name: "api", Path: "/code/api"}, {Name: "web", Path: "/code/web"}}
+	model, cmd := m.startBulk("Pull", projects, false, func(path string) (string, error) {
+		if path == "/code/web" {
+			return "", fmt.Errorf("not a git repository")
+		}
+		return "Already up to date.", nil
+	})
+	m = model.(Model)
+	st := m.Overlay.(*BulkState)
+
+	for {
+		msg, ok := cmd().(BulkEventMsg)
+		if !ok {
+			t.Fatal("expected a bulk event")
+		}
+		model, _ = m.handleBulkEvent(msg)
+		m = model.(Model)
+		if msg.done {
+			break
+		}
+		cmd = waitForBulk(st, msg.events)
+	}
+
+	if st.Items[0].Status != bulk.Done || st.Items[0].Output != "Already up to date." {
+		t.Errorf("expected api to be pulled, got %+v", st.Items[0])
+	}
+	if st.Items[1].Status != bulk.Failed || st.Items[1].Err == nil {
+		t.Errorf("expected web to fail, got %+v", st.Items[1])
+	}
+	if msg := m.Status.Current; msg == nil || msg.Severity != SeverityError || msg.Text != "Pull failed in 1 of 2 projects" {
+		t.Errorf("expected the failure in the status bar, got %+v", msg)
+	}
+}
+
+func TestBulkViewsTruncateWideText(t *testing.T) {
+	m := Model{Config: config.DefaultConfig(), Status: NewStatusBar(), Styles: ui.NewStyles(theme.GetTheme("")), Width: 40}
+	// Each of these characters takes two cells
+	projects := []project.Project{
+		{Name: "日本語のプロジェクト", Path: "/code/ja"},
+		{Name: "中文项目名称很长", Path: "/code/zh"},
+	}
+
+	m.Overlay = &BulkMenuState{Projects: projects}
+	for _, line := range strings.Split(m.renderBulkMenuView(), "\n") {
+		if w := lipgloss.Width(line); w > m.Width {
+			t.Errorf("menu line is %d cells wide: %q", w, line)
+		}
+	}
+
+	st := &BulkState{Title: "Pull"}
+	for _, p := range projects {
+		st.Items = append(st.Items, bulkItem{Project: p, Status: bulk.Failed, Err: fmt.Errorf("変更がコミットされていないためプルできません")})
+	}
+	m.Overlay = st
+	if view := m.renderBulkView(); !strings.Contains(view, "…") {
+		t.Errorf("expected the long error to be truncated, got %q", view)
+	}
+}
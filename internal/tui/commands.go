@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"bufio"
+	"den/internal/project"
+	"den/internal/runner"
+	"fmt"
+	"io"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CommandDoneMsg is sent when a custom command or plugin run from den exits
+type CommandDoneMsg struct {
+	Name    string
+	Project project.Project
+	Err     error
+}
+
+// pausedCmd runs a command in the terminal and waits for enter before den
+// takes the screen back, so that its output can be read
+type pausedCmd struct {
+	cmd  *exec.Cmd
+	name string
+}
+
+func (c *pausedCmd) SetStdin(r io.Reader)  { c.cmd.Stdin = r }
+func (c *pausedCmd) SetStdout(w io.Writer) { c.cmd.Stdout = w }
+func (c *pausedCmd) SetStderr(w io.Writer) { c.cmd.Stderr = w }
+
+func (c *pausedCmd) Run() error {
+	err := c.cmd.Run()
+	result := "finished"
+	if err != nil {
+		result = fmt.Sprintf("failed: %v", err)
+	}
+	fmt.Fprintf(c.cmd.Stdout, "\n%s %s. Press enter to go back to den.", c.name, result)
+	if c.cmd.Stdin != nil {
+		bufio.NewReader(c.cmd.Stdin).ReadString('\n')
+	}
+	return err
+}
+
+// runCommand runs a custom command or plugin in a project, in the terminal
+func (m Model) runCommand(c runner.Command, p project.Project) (tea.Model, tea.Cmd) {
+	run := &pausedCmd{cmd: c.Cmd(p.Name, p.Path), name: c.Name}
+	return m, tea.Batch(recordVisits(m.Config.ActiveProfile(), p.Path), tea.Exec(run, func(err error) tea.Msg {
+		return CommandDoneMsg{Name: c.Name, Project: p, Err: err}
+	}))
+}
+
+func (m Model) handleCommandDone(msg CommandDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("%s failed in %s: %v", msg.Name, msg.Project.Name, msg.Err)
+	} else {
+		m.Status.Successf("%s finished in %s", msg.Name, msg.Project.Name)
+	}
+	// The command may have changed the git status
+	return m, detectProject(msg.Project.Path, m.Config.Clone())
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"den/internal/project"
+	"den/internal/shellwords"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// ClipboardMethod tells how text reached the clipboard
+type ClipboardMethod string
+
+const (
+	// ClipboardSystem is the clipboard of the machine den runs on
+	ClipboardSystem ClipboardMethod = "the system clipboard"
+	// ClipboardOSC52 asks the terminal to set its clipboard, which works
+	// over SSH and on machines without a clipboard
+	ClipboardOSC52 ClipboardMethod = "OSC 52"
+)
+
+// CopyToClipboard copies text to the system clipboard, or through the
+// terminal tty with OSC 52 when there is none. Over SSH the system
+// clipboard is the remote one, so the terminal is used right away. tty may
+// be nil when there is no terminal.
+func CopyToClipboard(text string, tty *os.File) (ClipboardMethod, error) {
+	if !clipboard.Unsupported && !overSSH() {
+		if err := clipboard.WriteAll(text); err == nil {
+			return ClipboardSystem, nil
+		}
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	if tty == nil {
+		return "", fmt.Errorf("no system clipboard and no terminal")
+	}
+	if !term.IsTerminal(tty.Fd()) {
+		return "", fmt.Errorf("no system clipboard and %s is not a terminal", tty.Name())
+	}
+	if _, err := seq.WriteTo(tty); err != nil {
+		return "", fmt.Errorf("no system clipboard and the terminal could not be reached: %v", err)
+	}
+	return ClipboardOSC52, nil
+}
+
+// openTTY opens the terminal den runs in for writing
+func openTTY() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	}
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
+
+// overSSH reports whether den runs in an SSH session
+func overSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// CopyState tracks the copy menu of a project
+type CopyState struct {
+	Project project.Project
+	Items   []copyItem
+	Cursor  int
+	// Loading is set until the git entries are read
+	Loading bool
+}
+
+func (st *CopyState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleCopyUpdate(msg)
+}
+
+func (st *CopyState) view(m Model) string {
+	return m.renderCopyView()
+}
+
+// CopyItemsMsg carries the git entries of a project's copy menu
+type CopyItemsMsg struct {
+	path  string
+	items []copyItem
+}
+
+// CopiedMsg reports that an entry of the copy menu was copied
+type CopiedMsg struct {
+	Label   string
+	Project string
+	Method  ClipboardMethod
+	Err     error
+}
+
+// copyItem is something about a project that can be copied
+type copyItem struct {
+	Label string
+	Value string
+}
+
+// copyItems returns what can be copied about a project without git
+func copyItems(p project.Project) []copyItem {
+	return []copyItem{
+		{Label: "Path", Value: p.Path},
+		{Label: "Name", Value: p.Name},
+		{Label: "cd command", Value: "cd " + shellwords.Quote(p.Path)},
+	}
+}
+
+// loadGitCopyItems reads the git entries of a project's copy menu in the
+// background. The entries git does not know are left out.
+func loadGitCopyItems(path string) tea.Cmd {
+	return func() tea.Msg {
+		var items []copyItem
+		if url, err := project.RemoteURL(path); err == nil {
+			items = append(items, copyItem{Label: "Remote URL", Value: url})
+		}
+		if branch, err := project.Branch(path); err == nil {
+			items = append(items, copyItem{Label: "Branch", Value: branch})
+		}
+		if hash, err := project.HeadCommit(path); err == nil {
+			items = append(items, copyItem{Label: "Latest commit", Value: hash})
+		}
+		return CopyItemsMsg{path: path, items: items}
+	}
+}
+
+// openCopy shows the copy menu of a project
+func (m Model) openCopy(p project.Project) (tea.Model, tea.Cmd) {
+	m.Overlay = &CopyState{Project: p, Items: copyItems(p), Loading: true}
+	return m, loadGitCopyItems(p.Path)
+}
+
+// handleCopyItems adds the git entries to the copy menu, unless it was
+// closed or opened on another project meanwhile
+func (m Model) handleCopyItems(msg CopyItemsMsg) (tea.Model, tea.Cmd) {
+	st, ok := m.Overlay.(*CopyState)
+	if !ok || st.Project.Path != msg.path {
+		return m, nil
+	}
+	st.Items = append(st.Items, msg.items...)
+	st.Loading = false
+	return m, nil
+}
+
+// copyToClipboard copies an entry of the copy menu in the background, as
+// the system clipboard runs a helper program
+func copyToClipboard(item copyItem, projectName string) tea.Cmd {
+	return func() tea.Msg {
+		// Not stdout, where the renderer may be writing a frame at the
+		// same time
+		tty, err := openTTY()
+		if err == nil {
+			defer tty.Close()
+		}
+		method, err := CopyToClipboard(item.Value, tty)
+		return CopiedMsg{Label: strings.ToLower(item.Label), Project: projectName, Method: method, Err: err}
+	}
+}
+
+func (m Model) handleCopied(msg CopiedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("Error copying %s: %v", msg.Label, msg.Err)
+	} else {
+		m.Status.Successf("Copied %s of %s via %s", msg.Label, msg.Project, msg.Method)
+	}
+	return m, nil
+}
+
+func (m Model) handleCopyUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*CopyState)
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < len(st.Items)-1 {
+			st.Cursor++
+		}
+	case key.Matches(msg, m.KeyMap.Enter):
+		m.Overlay = nil
+		return m, copyToClipboard(st.Items[st.Cursor], st.Project.Name)
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+func (m Model) renderCopyView() string {
+	st := m.Overlay.(*CopyState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Copy from " + st.Project.Name))
+	s.WriteString("\n\n")
+
+	for i, item := range st.Items {
+		row := item.Label + "  " + m.Styles.Placeholder.Render(item.Value)
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+
+	if st.Loading {
+		s.WriteString(m.Styles.Placeholder.Render("  Reading git…") + "\n")
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render(helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(m.KeyMap.Enter, "copy"), withDesc(m.KeyMap.Escape, "back"))))
+
+	return m.centerLines(s.String())
+}
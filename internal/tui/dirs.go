@@ -0,0 +1,440 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/project"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirEditMode describes what the path input on the directories view does
+type dirEditMode int
+
+const (
+	dirEditNone dirEditMode = iota
+	dirEditRetarget
+	dirEditRename
+)
+
+// DirEntry is a configured project directory as shown in the directories view
+type DirEntry struct {
+	Path     string
+	Projects int
+	Exists   bool
+}
+
+// DirsState tracks the state of the directories view
+type DirsState struct {
+	Entries       []DirEntry
+	Cursor        int
+	EditMode      dirEditMode
+	Input         string
+	ConfirmRemove bool
+	Err           error
+	// settings is the settings screen the view was opened from, shown
+	// again when the view is closed
+	settings *SettingsState
+}
+
+func (st *DirsState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleDirsUpdate(msg)
+}
+
+func (st *DirsState) view(m Model) string {
+	return m.renderDirsView()
+}
+
+// openDirs shows the directories view
+func (m Model) openDirs(fromSettings bool) (tea.Model, tea.Cmd) {
+	st := &DirsState{}
+	if fromSettings {
+		st.settings, _ = m.Overlay.(*SettingsState)
+	}
+	m.Overlay = st
+	m.refreshDirEntries()
+	return m, nil
+}
+
+// closeDirs leaves the directories view
+func (m Model) closeDirs() (tea.Model, tea.Cmd) {
+	if settings := m.Overlay.(*DirsState).settings; settings != nil {
+		// Directory changes are saved immediately, keep the draft in sync
+		settings.Draft.ProjectDirs = append([]string{}, m.Config.ProjectDirs...)
+		settings.Draft.Favorites = append([]string{}, m.Config.Favorites...)
+		m.Overlay = settings
+		return m, nil
+	}
+	m.Overlay = nil
+	return m, nil
+}
+
+// refreshDirEntries rebuilds the directory entries from the config and
+// projects while the directories view is open
+func (m *Model) refreshDirEntries() {
+	st, ok := m.Overlay.(*DirsState)
+	if !ok {
+		return
+	}
+	counts := project.CountByRoot(m.Projects, m.Config.ProjectDirs)
+	entries := make([]DirEntry, len(m.Config.ProjectDirs))
+	for i, dir := range m.Config.ProjectDirs {
+		info, err := os.Stat(dir)
+		entries[i] = DirEntry{
+			Path:     dir,
+			Projects: counts[dir],
+			Exists:   err == nil && info.IsDir(),
+		}
+	}
+	st.Entries = entries
+	if st.Cursor >= len(entries) {
+		st.Cursor = len(entries) - 1
+	}
+	if st.Cursor < 0 {
+		st.Cursor = 0
+	}
+}
+
+// dirKeys are the keys of the project directories view
+var dirKeys = struct {
+	MoveUp, MoveDown, Remove, Retarget, Rename, Confirm key.Binding
+}{
+	MoveUp:   key.NewBinding(key.WithKeys("K", "shift+up"), key.WithHelp("K", "move up")),
+	MoveDown: key.NewBinding(key.WithKeys("J", "shift+down"), key.WithHelp("J", "move down")),
+	Remove:   key.NewBinding(key.WithKeys("d", "x", "delete"), key.WithHelp("d", "remove, after asking")),
+	Retarget: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "point at another directory")),
+	Rename:   key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "rename on disk")),
+	Confirm:  key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm the removal")),
+}
+
+func (m Model) handleDirsUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	if st.EditMode != dirEditNone {
+		return m.handleDirsInput(msg)
+	}
+
+	if st.ConfirmRemove {
+		st.ConfirmRemove = false
+		if key.Matches(msg, dirKeys.Confirm) {
+			return m.removeDir(st.Cursor)
+		}
+		return m, nil
+	}
+
+	hasEntries := len(st.Entries) > 0
+	switch {
+	case key.Matches(msg, dirKeys.MoveUp):
+		if hasEntries && st.Cursor > 0 {
+			return m.moveDir(st.Cursor, st.Cursor-1)
+		}
+		return m, nil
+	case key.Matches(msg, dirKeys.MoveDown):
+		if hasEntries && st.Cursor < len(st.Entries)-1 {
+			return m.moveDir(st.Cursor, st.Cursor+1)
+		}
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < len(st.Entries)-1 {
+			st.Cursor++
+		}
+		return m, nil
+	case key.Matches(msg, dirKeys.Remove):
+		if hasEntries {
+			st.ConfirmRemove = true
+			st.Err = nil
+		}
+		return m, nil
+	case key.Matches(msg, dirKeys.Retarget):
+		if hasEntries {
+			st.EditMode = dirEditRetarget
+			st.Input = st.Entries[st.Cursor].Path
+			st.Err = nil
+		}
+		return m, nil
+	case key.Matches(msg, dirKeys.Rename):
+		if hasEntries {
+			st.EditMode = dirEditRename
+			st.Input = st.Entries[st.Cursor].Path
+			st.Err = nil
+		}
+		return m, nil
+	case key.Matches(msg, m.KeyMap.AddDirectory):
+		m.Overlay = &AddDirState{}
+		m.Input = ""
+		m.TabState = &TabCompletionState{
+			Suggestions: getPathSuggestions("", m.Config),
+			Index:       0,
+			Page:        0,
+			PageSize:    DefaultPageSize,
+		}
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		return m.closeDirs()
+	}
+	return m, nil
+}
+
+func (m Model) handleDirsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	switch msg.Type {
+	case tea.KeyEnter:
+		mode := st.EditMode
+		input := strings.TrimSpace(st.Input)
+		st.EditMode = dirEditNone
+		st.Input = ""
+		if mode == dirEditRename {
+			return m.renameDir(st.Cursor, input)
+		}
+		return m.retargetDir(st.Cursor, input)
+	case tea.KeyEsc:
+		st.EditMode = dirEditNone
+		st.Input = ""
+	case tea.KeyBackspace:
+		if len(st.Input) > 0 {
+			runes := []rune(st.Input)
+			st.Input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyTab:
+		// Complete with the first matching directory
+		if suggestions := getPathSuggestions(st.Input, m.Config); len(suggestions) > 0 {
+			st.Input = suggestions[0]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		st.Input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// removeDir drops a project directory and its projects
+func (m Model) removeDir(idx int) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	dir := m.Config.ProjectDirs[idx]
+
+	dirs := make([]string, 0, len(m.Config.ProjectDirs)-1)
+	dirs = append(dirs, m.Config.ProjectDirs[:idx]...)
+	dirs = append(dirs, m.Config.ProjectDirs[idx+1:]...)
+	m.Config.ProjectDirs = dirs
+	if err := config.SaveConfig(m.Config); err != nil {
+		st.Err = fmt.Errorf("failed to save config: %v", err)
+		return m, nil
+	}
+
+	// Drop the directory's projects from the list and cache right away
+	projects := make([]project.Project, 0, len(m.Projects))
+	for _, p := range m.Projects {
+		if project.RootOf(p.Path, []string{dir}) == "" {
+			projects = append(projects, p)
+		}
+	}
+	m.Projects = projects
+	cmd := m.refreshListItems()
+	if err := m.saveCache(); err != nil {
+		st.Err = fmt.Errorf("failed to save cache: %v", err)
+	}
+
+	m.refreshDirEntries()
+	m.Status.Successf("Removed %s", dir)
+	return m, cmd
+}
+
+// moveDir swaps two project directories
+func (m Model) moveDir(from, to int) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	dirs := append([]string{}, m.Config.ProjectDirs...)
+	dirs[from], dirs[to] = dirs[to], dirs[from]
+	m.Config.ProjectDirs = dirs
+	if err := config.SaveConfig(m.Config); err != nil {
+		st.Err = fmt.Errorf("failed to save config: %v", err)
+		return m, nil
+	}
+
+	st.Cursor = to
+	m.refreshDirEntries()
+	return m, nil
+}
+
+// retargetDir points a project directory entry at a different directory
+func (m Model) retargetDir(idx int, target string) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	path, err := m.validateDirTarget(idx, target)
+	if err != nil {
+		st.Err = err
+		return m, nil
+	}
+	if info, err := os.Stat(path); err != nil {
+		st.Err = fmt.Errorf("cannot access directory: %v", err)
+		return m, nil
+	} else if !info.IsDir() {
+		st.Err = fmt.Errorf("not a directory: %s", path)
+		return m, nil
+	}
+
+	m.Config.ProjectDirs[idx] = path
+	if err := config.SaveConfig(m.Config); err != nil {
+		st.Err = fmt.Errorf("failed to save config: %v", err)
+		return m, nil
+	}
+
+	return m.rescanDirs()
+}
+
+// renameDir moves a project directory on disk and updates its entry
+func (m Model) renameDir(idx int, target string) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*DirsState)
+	path, err := m.validateDirTarget(idx, target)
+	if err != nil {
+		st.Err = err
+		return m, nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		st.Err = fmt.Errorf("already exists: %s", path)
+		return m, nil
+	}
+
+	oldPath := m.Config.ProjectDirs[idx]
+	if err := os.Rename(oldPath, path); err != nil {
+		st.Err = fmt.Errorf("failed to rename directory: %v", err)
+		return m, nil
+	}
+
+	// Favorites inside the directory move with it
+	m.Config.ProjectDirs[idx] = path
+	for i, fav := range m.Config.Favorites {
+		if project.RootOf(fav, []string{oldPath}) == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(oldPath, fav); err == nil {
+			m.Config.Favorites[i] = filepath.Join(path, rel)
+		}
+	}
+	if err := config.SaveConfig(m.Config); err != nil {
+		st.Err = fmt.Errorf("failed to save config: %v", err)
+		return m, nil
+	}
+
+	return m.rescanDirs()
+}
+
+// validateDirTarget resolves a new path for entry idx and checks for duplicates
+func (m Model) validateDirTarget(idx int, target string) (string, error) {
+	if target == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	path := target
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for i, dir := range m.Config.ProjectDirs {
+		if i != idx && dir == path {
+			return "", fmt.Errorf("directory already exists in config: %s", path)
+		}
+	}
+	return path, nil
+}
+
+// rescanDirs rescans projects in the background after the configured
+// directories changed. The entries are counted again once it is done.
+func (m Model) rescanDirs() (tea.Model, tea.Cmd) {
+	cmd := m.startRefresh()
+	m.refreshDirEntries()
+	return m, cmd
+}
+
+// refreshListItems rebuilds the list items from the loaded projects and
+// keeps the selected project selected. With a filter applied the returned
+// command filters the new items; the selection is restored once it is done.
+func (m *Model) refreshListItems() tea.Cmd {
+	var selected string
+	if item, ok := m.List.SelectedItem().(ListItem); ok {
+		selected = item.Project.Path
+	}
+
+	items := make([]list.Item, 0, len(m.Projects))
+	for _, p := range m.Projects {
+		if m.ShowFavoritesOnly && !p.Favorite {
+			continue
+		}
+		running, ports := m.processSummary(p.Path)
+		items = append(items, ListItem{Project: p, Running: running, Ports: ports, Selected: m.Selected[p.Path]})
+	}
+	cmd := m.List.SetItems(items)
+
+	if m.filterApplied() {
+		m.reselect = selected
+	} else {
+		m.selectProject(selected)
+	}
+	return cmd
+}
+
+// saveCache persists the loaded projects to the cache
+func (m Model) saveCache() error {
+	return project.BuildCache(m.Projects, m.Config).SaveCache(m.Config.ActiveProfile())
+}
+
+func (m Model) renderDirsView() string {
+	st := m.Overlay.(*DirsState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Project Directories"))
+	s.WriteString("\n\n")
+
+	if len(st.Entries) == 0 {
+		s.WriteString(m.Styles.Placeholder.Render("No project directories configured") + "\n")
+	}
+
+	for i, entry := range st.Entries {
+		path := entry.Path
+		if i == st.Cursor && st.EditMode != dirEditNone {
+			path = st.Input + "█"
+		}
+
+		var info string
+		if entry.Exists {
+			info = fmt.Sprintf("%d projects", entry.Projects)
+			if entry.Projects == 1 {
+				info = "1 project"
+			}
+		} else {
+			info = m.Styles.Error.Render("missing")
+		}
+
+		row := fmt.Sprintf("%d. %s  (%s)", i+1, path, info)
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+
+	if st.Err != nil {
+		s.WriteString("\n" + m.Styles.Error.Render(fmt.Sprintf("Error: %v", st.Err)) + "\n")
+	}
+
+	var help string
+	switch {
+	case st.ConfirmRemove:
+		help = fmt.Sprintf("Remove %s? %s • any other key: no", st.Entries[st.Cursor].Path, helpLine(withDesc(dirKeys.Confirm, "yes")))
+	case st.EditMode == dirEditRetarget:
+		help = "Point entry at another directory • " + helpLine(addDirKeys.Complete, withDesc(addDirKeys.Confirm, "apply"), addDirKeys.Cancel)
+	case st.EditMode == dirEditRename:
+		help = "Rename directory on disk • " + helpLine(addDirKeys.Complete, withDesc(addDirKeys.Confirm, "apply"), addDirKeys.Cancel)
+	default:
+		help = helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(dirKeys.MoveUp, "up"), withDesc(dirKeys.MoveDown, "down"),
+			withDesc(m.KeyMap.AddDirectory, "add"), withDesc(dirKeys.Remove, "remove"), withDesc(dirKeys.Retarget, "retarget"),
+			withDesc(dirKeys.Rename, "rename"), withDesc(m.KeyMap.Escape, "back"))
+	}
+	s.WriteString("\n" + m.Styles.RegularItem.Render(help))
+
+	return m.centerLines(s.String())
+}
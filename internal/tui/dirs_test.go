@@ -0,0 +1,241 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/project"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newDirsModel returns a model with the directories view open on dirs,
+// saving its config and cache to a temporary directory
+func newDirsModel(t *testing.T, dirs ...string) Model {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	t.Setenv("DEN_CONFIG", "")
+
+	cfg := config.DefaultConfig()
+	cfg.ProjectDirs = dirs
+	m := Model{
+		Config: cfg,
+		KeyMap: DefaultKeyMap(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+	model, _ := m.openDirs(false)
+	return model.(Model)
+}
+
+func pressDirKey(t *testing.T, m Model, keys ...string) Model {
+	t.Helper()
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		model, _ := m.handleDirsUpdate(msg)
+		m = model.(Model)
+	}
+	return m
+}
+
+func savedProjectDirs(t *testing.T) []string {
+	t.Helper()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	return cfg.ProjectDirs
+}
+
+func TestDirsReorder(t *testing.T) {
+	m := newDirsModel(t, "/code/a", "/code/b", "/code/c")
+	st := m.Overlay.(*DirsState)
+
+	m = pressDirKey(t, m, "J")
+	if got := strings.Join(m.Config.ProjectDirs, ","); got != "/code/b,/code/a,/code/c" {
+		t.Errorf("expected a to move down, got %s", got)
+	}
+	if st.Cursor != 1 {
+		t.Errorf("expected the cursor to follow the entry, got %d", st.Cursor)
+	}
+	if got := strings.Join(savedProjectDirs(t), ","); got != "/code/b,/code/a,/code/c" {
+		t.Errorf("expected the new order to be saved, got %s", got)
+	}
+
+	// The first entry can't move further up
+	m = pressDirKey(t, m, "K", "K")
+	if got := strings.Join(m.Config.ProjectDirs, ","); got != "/code/a,/code/b,/code/c" {
+		t.Errorf("expected a to move back up, got %s", got)
+	}
+}
+
+func TestDirsRemove(t *testing.T) {
+	m := newDirsModel(t, "/code/a", "/code/b")
+	m.Projects = []project.Project{
+		{Name: "api", Path: "/code/a/api"},
+		{Name: "web", Path: "/code/b/web"},
+	}
+
+	// Any key but y cancels
+	m = pressDirKey(t, m, "d", "n")
+	if len(m.Config.ProjectDirs) != 2 {
+		t.Fatalf("expected the removal to be cancelled, got %v", m.Config.ProjectDirs)
+	}
+
+	m = pressDirKey(t, m, "d", "y")
+	if got := strings.Join(m.Config.ProjectDirs, ","); got != "/code/b" {
+		t.Errorf("expected a to be removed, got %s", got)
+	}
+	if len(m.Projects) != 1 || m.Projects[0].Name != "web" {
+		t.Errorf("expected the projects of a to be dropped, got %v", m.Projects)
+	}
+	if got := strings.Join(savedProjectDirs(t), ","); got != "/code/b" {
+		t.Errorf("expected the removal to be saved, got %s", got)
+	}
+}
+
+func TestDirsRetarget(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	for _, dir := range []string{a, b} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newDirsModel(t, a, b)
+	st := m.Overlay.(*DirsState)
+
+	// Pointing at another entry's directory is refused
+	st.EditMode = dirEditRetarget
+	st.Input = b
+	m = pressDirKey(t, m, "enter")
+	if st.Err == nil || m.Config.ProjectDirs[0] != a {
+		t.Errorf("expected a duplicate to be refused, got %v, %v", st.Err, m.Config.ProjectDirs)
+	}
+
+	// So is a missing directory
+	st.EditMode = dirEditRetarget
+	st.Input = filepath.Join(root, "missing")
+	m = pressDirKey(t, m, "enter")
+	if st.Err == nil || m.Config.ProjectDirs[0] != a {
+		t.Errorf("expected a missing directory to be refused, got %v, %v", st.Err, m.Config.ProjectDirs)
+	}
+
+	c := filepath.Join(root, "c")
+	if err := os.Mkdir(c, 0755); err != nil {
+		t.Fatal(err)
+	}
+	st.Err = nil
+	st.EditMode = dirEditRetarget
+	st.Input = c
+	m = pressDirKey(t, m, "enter")
+	if st.Err != nil {
+		t.Fatalf("retarget failed: %v", st.Err)
+	}
+	if m.Config.ProjectDirs[0] != c || st.Entries[0].Path != c {
+		t.Errorf("expected the entry to point at c, got %v", m.Config.ProjectDirs)
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Errorf("expected a to stay on disk: %v", err)
+	}
+	if !m.Refreshing {
+		t.Error("expected the projects to be rescanned")
+	}
+}
+
+func TestDirsRename(t *testing.T) {
+	root := t.TempDir()
+	old := filepath.Join(root, "old")
+	for _, dir := range []string{"api", "web/nested"} {
+		if err := os.MkdirAll(filepath.Join(old, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := newDirsModel(t, old)
+	st := m.Overlay.(*DirsState)
+	outside := filepath.Join(root, "other", "api")
+	m.Config.Favorites = []string{
+		filepath.Join(old, "api"),
+		filepath.Join(old, "web", "nested"),
+		outside,
+	}
+
+	renamed := filepath.Join(root, "new")
+	m = pressDirKey(t, m, "R")
+	if st.EditMode != dirEditRename || st.Input != old {
+		t.Fatalf("expected to edit the path of old, got %v %q", st.EditMode, st.Input)
+	}
+	st.Input = renamed
+	m = pressDirKey(t, m, "enter")
+	if st.Err != nil {
+		t.Fatalf("rename failed: %v", st.Err)
+	}
+
+	if _, err := os.Stat(filepath.Join(renamed, "web", "nested")); err != nil {
+		t.Errorf("expected the directory to move: %v", err)
+	}
+	if m.Config.ProjectDirs[0] != renamed {
+		t.Errorf("expected the entry to follow, got %v", m.Config.ProjectDirs)
+	}
+	want := []string{
+		filepath.Join(renamed, "api"),
+		filepath.Join(renamed, "web", "nested"),
+		outside,
+	}
+	if got := strings.Join(m.Config.Favorites, ","); got != strings.Join(want, ",") {
+		t.Errorf("expected favorites %v, got %v", want, m.Config.Favorites)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got := strings.Join(cfg.Favorites, ","); got != strings.Join(want, ",") {
+		t.Errorf("expected the favorites to be saved, got %v", cfg.Favorites)
+	}
+
+	// Renaming onto an existing directory is refused
+	if err := os.Mkdir(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	st.EditMode = dirEditRename
+	st.Input = old
+	m = pressDirKey(t, m, "enter")
+	if st.Err == nil {
+		t.Error("expected renaming onto an existing directory to fail")
+	}
+}
+
+func TestDirsBackToSettings(t *testing.T) {
+	m := newDirsModel(t, "/code/a")
+	model, _ := m.openSettings()
+	m = model.(Model)
+	settings := m.Overlay.(*SettingsState)
+	model, _ = m.openDirs(true)
+	m = model.(Model)
+
+	m.Config.ProjectDirs = []string{"/code/b"}
+	m = pressDirKey(t, m, "q")
+	if m.Overlay != settings {
+		t.Fatalf("expected to go back to the settings, got %T", m.Overlay)
+	}
+	if got := strings.Join(settings.Draft.ProjectDirs, ","); got != "/code/b" {
+		t.Errorf("expected the draft to follow the directories, got %s", got)
+	}
+
+	// Adding a directory leaves both
+	model, _ = m.openDirs(true)
+	m = pressDirKey(t, model.(Model), "a")
+	if _, ok := m.Overlay.(*AddDirState); !ok {
+		t.Errorf("expected the add directory prompt, got %T", m.Overlay)
+	}
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/editor"
+	"den/internal/project"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EditorClosedMsg is sent when a terminal editor exits and den resumes
+type EditorClosedMsg struct {
+	Path string
+	// Config is set when the editor had the config file open
+	Config bool
+	Err    error
+}
+
+// ProjectDetectedMsg carries a project detected again, e.g. after editing it
+type ProjectDetectedMsg struct {
+	Project project.Project
+}
+
+// runEditor opens target in an editor. Terminal editors take over the
+// screen until they exit, then den resumes where it was. GUI editors are
+// started detached and den keeps running.
+func (m Model) runEditor(ed editor.Editor, t editor.Target, isConfig bool) (tea.Model, tea.Cmd) {
+	if !ed.Waits() {
+		if err := ed.Open(t); err != nil {
+			m.Status.Errorf("Error opening editor: %v", err)
+		} else {
+			m.Status.Successf("Opened %s in %s", t.Name, ed.Name)
+		}
+		return m, nil
+	}
+
+	cmd, err := ed.Command(t)
+	if err != nil {
+		m.Status.Errorf("Error opening editor: %v", err)
+		return m, nil
+	}
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return EditorClosedMsg{Path: t.Path, Config: isConfig, Err: err}
+	})
+}
+
+func (m Model) handleEditorClosed(msg EditorClosedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("Editor exited with an error: %v", msg.Err)
+	}
+	if msg.Config {
+		return m.reloadConfig()
+	}
+	// The edit probably changed the git status
+	return m, detectProject(msg.Path, m.Config.Clone())
+}
+
+// detectProject detects a single project again in the background
+func detectProject(path string, cfg *config.Config) tea.Cmd {
+	return func() tea.Msg {
+		p, err := project.DetectProject(path, cfg)
+		if err != nil {
+			return nil
+		}
+		return ProjectDetectedMsg{Project: *p}
+	}
+}
+
+func (m Model) handleProjectDetected(msg ProjectDetectedMsg) (tea.Model, tea.Cmd) {
+	return m, m.mergeProjects([]project.Project{msg.Project})
+}
+
+// handleConfigChanged reloads the config after the file was written by
+// something else, e.g. a GUI editor, and waits for the next write. Saves
+// by den itself leave the config as it is.
+func (m Model) handleConfigChanged() (tea.Model, tea.Cmd) {
+	next := waitForConfigChange(m.ConfigWatcher)
+	cfg, err := m.loadConfig()
+	if err == nil && sameConfig(cfg, m.Config) {
+		return m, next
+	}
+	model, cmd := m.reloadConfig()
+	return model, tea.Batch(cmd, next)
+}
+
+// sameConfig reports whether two configs have the same settings
+func sameConfig(a, b *config.Config) bool {
+	encodedA, errA := config.EncodeValue(*a)
+	encodedB, errB := config.EncodeValue(*b)
+	return errA == nil && errB == nil && encodedA == encodedB
+}
+
+// loadConfig reads the config file, keeping the profile in use
+func (m Model) loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err == nil && cfg.ActiveProfile() != m.Config.ActiveProfile() {
+		err = cfg.UseProfile(m.Config.ActiveProfile())
+	}
+	return cfg, err
+}
+
+// reloadConfig reads the config file again after it was edited by hand,
+// keeping the profile in use
+func (m Model) reloadConfig() (tea.Model, tea.Cmd) {
+	cfg, err := m.loadConfig()
+	if err != nil {
+		m.Status.Errorf("Error reloading config: %v", err)
+		return m, nil
+	}
+
+	// Update the shared config in place so every holder sees the change
+	*m.Config = *cfg
+	m.applyTheme(m.Config.Preferences.Theme)
+	// A draft of the old config would overwrite the edits
+	switch st := m.Overlay.(type) {
+	case *SettingsState:
+		m.Overlay = nil
+	case *DirsState:
+		st.settings = nil
+	}
+	m.Status.Successf("Config reloaded")
+	return m, m.startRefresh()
+}
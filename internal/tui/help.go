@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// HelpState tracks the help overlay
+type HelpState struct {
+	// Offset is the number of lines scrolled past
+	Offset int
+	// back is the view the help was opened from, if any
+	back Overlay
+}
+
+func (st *HelpState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleHelpUpdate(msg)
+}
+
+func (st *HelpState) view(m Model) string {
+	return m.renderHelpView()
+}
+
+// helpSection is a group of bindings in the help overlay
+type helpSection struct {
+	Title    string
+	Bindings []key.Binding
+}
+
+// helpSections lists every binding by mode. They come from the KeyMap,
+// so remapped keys show up as configured.
+func (m Model) helpSections() []helpSection {
+	var projects []key.Binding
+	for _, action := range keyActions {
+		if action.mode == keyModeList {
+			projects = append(projects, *action.binding(&m.KeyMap))
+		}
+	}
+	lk := m.List.KeyMap
+	projects = append(projects, lk.NextPage, lk.PrevPage, lk.GoToStart, lk.GoToEnd, lk.Quit)
+
+	back := key.NewBinding(
+		key.WithKeys(append(m.KeyMap.Escape.Keys(), "q")...),
+		key.WithHelp(m.KeyMap.Escape.Help().Key+"/q", "back"),
+	)
+
+	var menu []key.Binding
+	for _, action := range contextActions() {
+		if action.Run == nil {
+			continue
+		}
+		b := m.KeyMap.Enter
+		if action.Key != nil {
+			b = action.Key(m.KeyMap)
+		}
+		menu = append(menu, key.NewBinding(key.WithKeys(b.Keys()...), key.WithHelp(b.Help().Key, action.Name)))
+	}
+
+	var bulkMenu []key.Binding
+	for _, action := range bulkActions() {
+		if action.Run != nil {
+			b := m.KeyMap.Enter
+			bulkMenu = append(bulkMenu, key.NewBinding(key.WithKeys(b.Keys()...), key.WithHelp(b.Help().Key, action.Name)))
+		}
+	}
+	bulkMenu = append(bulkMenu, bulkMenuKeys.Confirm)
+
+	return []helpSection{
+		{Title: "Projects", Bindings: projects},
+		{Title: "Menus and views", Bindings: []key.Binding{m.KeyMap.Up, m.KeyMap.Down, m.KeyMap.Enter, back}},
+		{Title: "Context menu", Bindings: menu},
+		{Title: "Selected projects", Bindings: bulkMenu},
+		{Title: "Command palette", Bindings: []key.Binding{paletteKeys.Move, paletteKeys.Run, paletteKeys.Close}},
+		{Title: "Add directory", Bindings: []key.Binding{
+			addDirKeys.Confirm, addDirKeys.Complete, addDirKeys.Pick, addDirKeys.Page, addDirKeys.Cancel,
+		}},
+		{Title: "Processes", Bindings: []key.Binding{
+			processKeys.Logs, processKeys.Stop, processKeys.Restart, processKeys.Kill, processKeys.Confirm,
+		}},
+		{Title: "Project directories", Bindings: []key.Binding{
+			m.KeyMap.AddDirectory, dirKeys.MoveUp, dirKeys.MoveDown, dirKeys.Remove, dirKeys.Retarget, dirKeys.Rename, dirKeys.Confirm,
+		}},
+		{Title: "Settings", Bindings: []key.Binding{
+			settingKeys.Prev, settingKeys.Next, settingKeys.Edit, settingKeys.Save, settingKeys.EditFile,
+		}},
+	}
+}
+
+// helpLine joins bindings into a hint such as "enter: open • esc: back"
+func helpLine(bindings ...key.Binding) string {
+	parts := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if b.Enabled() {
+			parts = append(parts, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	return strings.Join(parts, " • ")
+}
+
+// withDesc returns b described as desc, for hints where a shared key does
+// something specific
+func withDesc(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// helpLines renders the sections, with the keys lined up
+func (m Model) helpLines() []string {
+	sections := m.helpSections()
+	width := 0
+	for _, section := range sections {
+		for _, b := range section.Bindings {
+			width = max(width, lipgloss.Width(b.Help().Key))
+		}
+	}
+
+	var lines []string
+	for i, section := range sections {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, m.Styles.SelectedItem.Render(section.Title))
+		for _, b := range section.Bindings {
+			k := b.Help().Key
+			lines = append(lines, m.Styles.SelectedMenuItem.Render(k+strings.Repeat(" ", width-lipgloss.Width(k)))+"  "+m.Styles.RegularItem.Render(b.Help().Desc))
+		}
+	}
+	return lines
+}
+
+// helpHeight is the number of help lines that fit on the screen
+func (m Model) helpHeight(lines int) int {
+	// Keep room for the header and the help line
+	if m.Height > 10 {
+		return min(lines, m.Height-10)
+	}
+	return lines
+}
+
+func (m Model) handleHelpUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*HelpState)
+	lines := len(m.helpLines())
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Offset > 0 {
+			st.Offset--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Offset < lines-m.helpHeight(lines) {
+			st.Offset++
+		}
+	case key.Matches(msg, m.KeyMap.Escape), key.Matches(msg, m.KeyMap.Help), msg.String() == "q":
+		m.Overlay = st.back
+	}
+	return m, nil
+}
+
+func (m Model) renderHelpView() string {
+	st := m.Overlay.(*HelpState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Keys"))
+	s.WriteString("\n\n")
+
+	lines := m.helpLines()
+	end := min(len(lines), st.Offset+m.helpHeight(len(lines)))
+	// Pad the lines to the same width so the keys stay lined up once
+	// centered
+	width := 0
+	for _, line := range lines {
+		width = max(width, lipgloss.Width(line))
+	}
+	for _, line := range lines[st.Offset:end] {
+		s.WriteString(line + strings.Repeat(" ", width-lipgloss.Width(line)) + "\n")
+	}
+
+	hint := m.KeyMap.Escape.Help().Key + "/" + m.KeyMap.Help.Help().Key + ": close"
+	if end < len(lines) || st.Offset > 0 {
+		hint = helpLine(m.KeyMap.Up, m.KeyMap.Down) + " • " + hint
+	}
+	s.WriteString("\n" + m.Styles.RegularItem.Render(hint))
+
+	return m.centerLines(s.String())
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/theme"
+	"den/internal/ui"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHelpFollowsRemappedKeys(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Keys = map[string][]string{"openTerminal": {"ctrl+t"}, "escape": {"ctrl+c"}}
+	m := Model{KeyMap: NewKeyMap(cfg)}
+
+	bindings := make(map[string][]string)
+	for _, section := range m.helpSections() {
+		for _, b := range section.Bindings {
+			bindings[b.Help().Desc] = b.Keys()
+		}
+	}
+
+	// Both the list shortcut and the context menu entry follow the remap
+	for _, desc := range []string{"terminal", "Terminal"} {
+		if got := bindings[desc]; len(got) != 1 || got[0] != "ctrl+t" {
+			t.Errorf("expected %s on ctrl+t, got %v", desc, got)
+		}
+	}
+	if got := bindings["back"]; strings.Join(got, ",") != "ctrl+c,q" {
+		t.Errorf("expected back on ctrl+c and q, got %v", got)
+	}
+
+	if got := helpLine(m.KeyMap.Enter, m.KeyMap.Escape); got != "enter: confirm • ctrl+c: cancel" {
+		t.Errorf("unexpected help line %q", got)
+	}
+
+	// The hints of the views follow the remap too
+	m.Styles = ui.NewStyles(theme.GetTheme(""))
+	m.Status = NewStatusBar()
+	m.Overlay = &HistoryState{}
+	if view := m.View(); !strings.Contains(view, "ctrl+c: back") {
+		t.Errorf("expected the history hint to show ctrl+c, got:\n%s", view)
+	}
+}
+
+func TestHelpCoversEveryAction(t *testing.T) {
+	m := Model{KeyMap: DefaultKeyMap()}
+	covered := make(map[string]bool)
+	for _, section := range m.helpSections() {
+		for _, b := range section.Bindings {
+			covered[b.Help().Desc] = true
+		}
+	}
+	for _, action := range keyActions {
+		if desc := action.binding(&m.KeyMap).Help().Desc; action.mode == keyModeList && !covered[desc] {
+			t.Errorf("action %s is missing from the help", action.name)
+		}
+	}
+	for _, action := range contextActions() {
+		if action.Run != nil && !covered[action.Name] {
+			t.Errorf("menu entry %s is missing from the help", action.Name)
+		}
+	}
+}
+
+func TestHelpGoesBackToContextMenu(t *testing.T) {
+	m := Model{
+		Config: config.DefaultConfig(),
+		KeyMap: DefaultKeyMap(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+		Styles: ui.NewStyles(theme.GetTheme("")),
+	}
+	press := func(msg tea.KeyMsg) {
+		model, _ := m.Update(msg)
+		m = model.(Model)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	menu, ok := m.Overlay.(*ContextMenuState)
+	if !ok {
+		t.Fatalf("expected the context menu, got %T", m.Overlay)
+	}
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if _, ok := m.Overlay.(*HelpState); !ok {
+		t.Fatalf("expected the help, got %T", m.Overlay)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.Overlay != menu || menu.Cursor != 1 {
+		t.Errorf("expected the menu back where it was, got %T", m.Overlay)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.Overlay != nil {
+		t.Errorf("expected the list, got %T", m.Overlay)
+	}
+}
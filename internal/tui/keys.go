@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"den/internal/config"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// Key binding modes. Bindings only conflict with others in the same mode.
+const (
+	keyModeList       = "list"
+	keyModeNavigation = "navigation"
+)
+
+// keyAction is a binding in KeyMap that can be remapped in the [keys] table
+type keyAction struct {
+	name    string
+	mode    string
+	binding func(k *KeyMap) *key.Binding
+}
+
+var keyActions = []keyAction{
+	{"addDirectory", keyModeList, func(k *KeyMap) *key.Binding { return &k.AddDirectory }},
+	{"showContext", keyModeList, func(k *KeyMap) *key.Binding { return &k.ShowContext }},
+	{"filter", keyModeList, func(k *KeyMap) *key.Binding { return &k.Filter }},
+	{"openConfig", keyModeList, func(k *KeyMap) *key.Binding { return &k.OpenConfig }},
+	{"toggleFavorite", keyModeList, func(k *KeyMap) *key.Binding { return &k.ToggleFavorite }},
+	{"filterFavorites", keyModeList, func(k *KeyMap) *key.Binding { return &k.FilterFavorites }},
+	{"manageDirs", keyModeList, func(k *KeyMap) *key.Binding { return &k.ManageDirs }},
+	{"switchProfile", keyModeList, func(k *KeyMap) *key.Binding { return &k.SwitchProfile }},
+	{"openTerminal", keyModeList, func(k *KeyMap) *key.Binding { return &k.OpenTerminal }},
+	{"history", keyModeList, func(k *KeyMap) *key.Binding { return &k.History }},
+	{"help", keyModeList, func(k *KeyMap) *key.Binding { return &k.Help }},
+	{"palette", keyModeList, func(k *KeyMap) *key.Binding { return &k.Palette }},
+	{"select", keyModeList, func(k *KeyMap) *key.Binding { return &k.Select }},
+	{"selectRange", keyModeList, func(k *KeyMap) *key.Binding { return &k.SelectRange }},
+	{"selectAll", keyModeList, func(k *KeyMap) *key.Binding { return &k.SelectAll }},
+	{"up", keyModeNavigation, func(k *KeyMap) *key.Binding { return &k.Up }},
+	{"down", keyModeNavigation, func(k *KeyMap) *key.Binding { return &k.Down }},
+	{"enter", keyModeNavigation, func(k *KeyMap) *key.Binding { return &k.Enter }},
+	{"escape", keyModeNavigation, func(k *KeyMap) *key.Binding { return &k.Escape }},
+}
+
+// NewKeyMap returns the default keybindings with the overrides from the
+// config's [keys] table applied. Unknown actions are ignored here and
+// reported by ValidateKeys.
+func NewKeyMap(cfg *config.Config) KeyMap {
+	k := DefaultKeyMap()
+	for _, action := range keyActions {
+		keys, ok := cfg.Keys[action.name]
+		if !ok || len(keys) == 0 {
+			continue
+		}
+		b := action.binding(&k)
+		b.SetKeys(keys...)
+		b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	}
+	return k
+}
+
+// ValidateKeys adds unknown actions and conflicting bindings from the
+// config's [keys] table to the report
+func ValidateKeys(cfg *config.Config, r *config.Report) {
+	known := make(map[string]bool, len(keyActions))
+	for _, action := range keyActions {
+		known[action.name] = true
+	}
+
+	names := make([]string, 0, len(cfg.Keys))
+	for name := range cfg.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !known[name] {
+			r.Warnf("keys."+name, "unknown action")
+		}
+	}
+
+	k := NewKeyMap(cfg)
+	bound := map[string]map[string]string{
+		keyModeList:       {},
+		keyModeNavigation: {},
+	}
+	for _, action := range keyActions {
+		for _, bk := range action.binding(&k).Keys() {
+			if other, ok := bound[action.mode][bk]; ok {
+				// Only report clashes the user introduced
+				if _, remapped := cfg.Keys[action.name]; remapped {
+					r.Errorf("keys."+action.name, "key %q is also bound to %s", bk, other)
+				} else if _, remapped := cfg.Keys[other]; remapped {
+					r.Errorf("keys."+other, "key %q is also bound to %s", bk, action.name)
+				}
+				continue
+			}
+			bound[action.mode][bk] = action.name
+		}
+	}
+
+	// The list handles its own keys when none of ours match
+	listKeys := list.DefaultKeyMap()
+	builtin := map[string]key.Binding{
+		"quit":     listKeys.Quit,
+		"nextPage": listKeys.NextPage,
+		"prevPage": listKeys.PrevPage,
+		"goToTop":  listKeys.GoToStart,
+		"goToEnd":  listKeys.GoToEnd,
+	}
+	builtinNames := make([]string, 0, len(builtin))
+	for name := range builtin {
+		builtinNames = append(builtinNames, name)
+	}
+	sort.Strings(builtinNames)
+	for _, name := range names {
+		if !known[name] {
+			continue
+		}
+		for _, bk := range cfg.Keys[name] {
+			for _, b := range builtinNames {
+				for _, lk := range builtin[b].Keys() {
+					if lk == bk {
+						r.Warnf("keys."+name, "key %q shadows the list's %s key", bk, b)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"den/internal/launch"
+	"den/internal/project"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LaunchDoneMsg is sent when the steps of a launch profile have run
+type LaunchDoneMsg struct {
+	Project project.Project
+	Steps   int
+	Err     error
+	// foreground is an editor to run in the terminal now
+	foreground *exec.Cmd
+}
+
+// startWorking runs the project's launch profile in the background
+func (m Model) startWorking(p project.Project) (tea.Model, tea.Cmd) {
+	l, err := launch.For(m.Config, p.Name, p.Path)
+	if m.askTrust(err, func(m Model) (tea.Model, tea.Cmd) { return m.startWorking(p) }) {
+		return m, nil
+	}
+	if err != nil {
+		m.Status.Errorf("Could not start %s: %v", p.Name, err)
+		return m, nil
+	}
+	m.Status.Infof("Starting %s…", p.Name)
+	cfg := m.Config.Clone()
+	return m, tea.Batch(recordVisits(m.Config.ActiveProfile(), p.Path), func() tea.Msg {
+		_, foreground, err := l.Run(cfg)
+		return LaunchDoneMsg{Project: p, Steps: len(l.Steps), Err: err, foreground: foreground}
+	})
+}
+
+func (m Model) handleLaunchDone(msg LaunchDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("Could not start %s: %v", msg.Project.Name, msg.Err)
+		// Steps before the failing one may have started processes
+		return m, m.loadProcesses(false)
+	}
+	m.Status.Successf("Started %s (%d steps)", msg.Project.Name, msg.Steps)
+	if msg.foreground == nil {
+		return m, m.loadProcesses(false)
+	}
+	path := msg.Project.Path
+	return m, tea.Batch(m.loadProcesses(false), tea.ExecProcess(msg.foreground, func(err error) tea.Msg {
+		return EditorClosedMsg{Path: path, Err: err}
+	}))
+}
@@ -2,14 +2,19 @@ package tui
 
 import (
 	"den/internal/config"
+	"den/internal/procscan"
 	"den/internal/project"
+	"den/internal/supervisor"
 	"den/internal/ui"
+	"den/internal/watch"
 
 	"fmt"
+	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // KeyMap defines keybindings for the application
@@ -24,6 +29,15 @@ type KeyMap struct {
 	OpenConfig      key.Binding
 	ToggleFavorite  key.Binding
 	FilterFavorites key.Binding
+	ManageDirs      key.Binding
+	SwitchProfile   key.Binding
+	OpenTerminal    key.Binding
+	History         key.Binding
+	Help            key.Binding
+	Palette         key.Binding
+	Select          key.Binding
+	SelectRange     key.Binding
+	SelectAll       key.Binding
 }
 
 // DefaultKeyMap returns the default keybindings
@@ -34,7 +48,7 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("a", "add directory"),
 		),
 		ShowContext: key.NewBinding(
-			key.WithKeys("enter", " "),
+			key.WithKeys("enter"),
 			key.WithHelp("enter", "show context"),
 		),
 		Up: key.NewBinding(
@@ -59,7 +73,7 @@ func DefaultKeyMap() KeyMap {
 		),
 		OpenConfig: key.NewBinding(
 			key.WithKeys("."),
-			key.WithHelp(".", "config"),
+			key.WithHelp(".", "settings"),
 		),
 		ToggleFavorite: key.NewBinding(
 			key.WithKeys("f"),
@@ -69,6 +83,42 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("F"),
 			key.WithHelp("F", "filter favorites"),
 		),
+		ManageDirs: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "directories"),
+		),
+		SwitchProfile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "profiles"),
+		),
+		OpenTerminal: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "terminal"),
+		),
+		History: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "message history"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "help"),
+		),
+		Palette: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "command palette"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "select"),
+		),
+		SelectRange: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "select range"),
+		),
+		SelectAll: key.NewBinding(
+			key.WithKeys("ctrl+a"),
+			key.WithHelp("ctrl+a", "select all shown"),
+		),
 	}
 }
 
@@ -78,18 +128,61 @@ type Model struct {
 	List              list.Model
 	Err               error
 	Config            *config.Config
-	InputMode         bool
 	Input             string
 	TabState          *TabCompletionState
-	Status            string
-	ShowContext       bool
-	ContextCursor     int
-	AddingDir         bool
+	Status            *StatusBar
 	Width             int
 	Height            int
 	Styles            *ui.Styles
 	KeyMap            KeyMap
 	ShowFavoritesOnly bool
+	Spinner           spinner.Model
+	Refreshing        bool
+	LastRefreshed     time.Time
+	// Overlay is the view open over the project list, nil when the list
+	// is shown
+	Overlay Overlay
+	// Watcher reports changes to the projects while den is open, nil
+	// when watching is not available
+	Watcher *watch.Watcher
+	// ConfigWatcher reports writes to the config file, e.g. by a GUI
+	// editor, nil when watching is not available
+	ConfigWatcher *watch.File
+	// Running are the background processes den started, by project path
+	Running map[string][]supervisor.Process
+	// Detected are the processes working in each project
+	Detected map[string][]procscan.Process
+	// Selected are the paths of the projects selected for bulk actions
+	Selected map[string]bool
+
+	// refreshID identifies the latest background refresh
+	refreshID int
+	// reselect is the project to select once the list is filtered again
+	reselect string
+	// logsID identifies the latest log view
+	logsID int
+	// selectAnchor is the project a range selection starts from
+	selectAnchor string
+}
+
+// Overlay is a view opened over the project list, such as a menu or the
+// settings. The open one gets the keys and is shown instead of the list.
+type Overlay interface {
+	update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd)
+	view(m Model) string
+}
+
+// ContextMenuState tracks the state of the context menu of a project
+type ContextMenuState struct {
+	Cursor int
+}
+
+// AddDirState tracks the prompt for a new project directory, which uses
+// the Input and TabState of the model
+type AddDirState struct {
+	// Required is set when there is no project directory yet: leaving
+	// the prompt without one quits
+	Required bool
 }
 
 // TabCompletionState tracks the state of tab completion
@@ -103,13 +196,32 @@ type TabCompletionState struct {
 // ListItem represents an item in the project list
 type ListItem struct {
 	Project project.Project
+	// Running is the number of processes working in the project, and
+	// Ports the ports they listen on
+	Running int
+	Ports   []int
+	// Selected is set when the project is selected for bulk actions
+	Selected bool
 }
 
 func (i ListItem) Title() string {
+	title := "  " + i.Project.Name
 	if i.Project.Favorite {
-		return "★ " + i.Project.Name
+		title = "★ " + i.Project.Name
+	}
+	if i.Selected {
+		title = "✓ " + title
 	}
-	return "  " + i.Project.Name
+	switch {
+	case i.Running == 1:
+		title += "  ● running"
+	case i.Running > 1:
+		title += fmt.Sprintf("  ● %d running", i.Running)
+	}
+	for _, port := range i.Ports {
+		title += fmt.Sprintf(" :%d", port)
+	}
+	return title
 }
 
 func (i ListItem) Description() string {
@@ -117,26 +229,28 @@ func (i ListItem) Description() string {
 	if i.Project.GitState != "" {
 		desc += " ( " + i.Project.GitState + " )"
 	}
+	for _, tag := range i.Project.Tags {
+		desc += " #" + tag
+	}
 	return desc
 }
 
 // FilterValue implements list.Item interface
 func (i ListItem) FilterValue() string {
-	favorite := ""
+	keywords := ""
 	if i.Project.Favorite {
-		favorite = "favorite starred"
+		keywords = "favorite starred"
 	}
-	return fmt.Sprintf("%s %s %s", i.Project.Name, i.Project.Path, favorite)
-}
-
-// ContextOptions defines the available context menu options
-var ContextOptions = []string{
-	// "Go To",
-	"Editor",
-	"Explorer",
-	"Copy Path",
-	"Toggle Favorite",
-	"Cancel",
+	if i.Running > 0 {
+		keywords += " running"
+	}
+	if i.Selected {
+		keywords += " selected"
+	}
+	for _, tag := range i.Project.Tags {
+		keywords += " #" + tag
+	}
+	return fmt.Sprintf("%s %s %s", i.Project.Name, i.Project.Path, keywords)
 }
 
 // InputPlaceholder is the text shown in the input field before user starts typing
@@ -145,10 +259,5 @@ const InputPlaceholder = "/path/to/your/projects"
 // DefaultPageSize is the number of suggestions shown per page
 const DefaultPageSize = 5
 
-// CopyToClipboard copies the given text to the system clipboard
-func CopyToClipboard(text string) error {
-	return clipboard.WriteAll(text)
-}
-
 // ProjectsLoadedMsg is sent when projects are loaded
 type ProjectsLoadedMsg []project.Project
@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"den/internal/editor"
+	"den/internal/project"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// OpenWithState tracks the state of the "Open with…" picker
+type OpenWithState struct {
+	Project project.Project
+	Editors []editor.Editor
+	Cursor  int
+}
+
+func (st *OpenWithState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleOpenWithUpdate(msg)
+}
+
+func (st *OpenWithState) view(m Model) string {
+	return m.renderOpenWithView()
+}
+
+// openOpenWith shows the editors of the editor list and the configured
+// editor profiles, with the default editor selected
+func (m Model) openOpenWith(p project.Project) (tea.Model, tea.Cmd) {
+	st := &OpenWithState{Project: p}
+	for i, name := range m.Config.EditorNames() {
+		st.Editors = append(st.Editors, editor.Resolve(name, m.Config))
+		if name == m.Config.Preferences.DefaultEditor {
+			st.Cursor = i
+		}
+	}
+	if len(st.Editors) == 0 {
+		m.Status.Warnf("No editors configured, add some to editorList or [editors]")
+		return m, nil
+	}
+	m.Overlay = st
+	return m, nil
+}
+
+func (m Model) handleOpenWithUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*OpenWithState)
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < len(st.Editors)-1 {
+			st.Cursor++
+		}
+	case key.Matches(msg, m.KeyMap.Enter):
+		m.Overlay = nil
+		return m.openWith(st.Editors[st.Cursor], st.Project)
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+func (m Model) renderOpenWithView() string {
+	st := m.Overlay.(*OpenWithState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Open " + st.Project.Name + " with"))
+	s.WriteString("\n\n")
+
+	for i, ed := range st.Editors {
+		row := ed.Name
+		detail := ed.Profile.Command
+		if ed.Profile.Terminal {
+			detail += " · terminal"
+		}
+		if detail != row {
+			row += "  " + m.Styles.Placeholder.Render("("+detail+")")
+		}
+
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render(helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(m.KeyMap.Enter, "open "+st.Project.Name), withDesc(m.KeyMap.Escape, "back"))))
+
+	return m.centerLines(s.String())
+}
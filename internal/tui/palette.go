@@ -0,0 +1,346 @@
+package tui
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/project"
+	"den/internal/runner"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// paletteCommand is an entry of the command palette
+type paletteCommand struct {
+	// ID tells commands apart in the recent commands
+	ID    string
+	Title string
+	// Detail is the project or a description
+	Detail string
+	// Key is the shortcut of the command, if any
+	Key key.Binding
+	Run func(m Model) (tea.Model, tea.Cmd)
+}
+
+// paletteKeys are the keys of the command palette, where other keys type
+var paletteKeys = struct {
+	Move, Run, Close key.Binding
+}{
+	Move:  key.NewBinding(key.WithKeys("up", "down", "shift+tab", "tab"), key.WithHelp("↑/↓", "move")),
+	Run:   key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "run")),
+	Close: key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close")),
+}
+
+// PaletteState tracks the command palette
+type PaletteState struct {
+	Input    string
+	Cursor   int
+	Commands []paletteCommand
+	// Matches are the commands matching Input, best first
+	Matches []paletteCommand
+	// Recent are the ids of the commands run last, most recent first
+	Recent []string
+}
+
+// RecentCommandsMsg carries the recent commands loaded for the palette
+type RecentCommandsMsg struct {
+	Recent []string
+	Err    error
+}
+
+func (st *PaletteState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handlePaletteUpdate(msg)
+}
+
+func (st *PaletteState) view(m Model) string {
+	return m.renderPaletteView()
+}
+
+// globalCommands are the commands that don't need a project
+func (m Model) globalCommands() []paletteCommand {
+	k := m.KeyMap
+	favorites := "Show favorites only"
+	if m.ShowFavoritesOnly {
+		favorites = "Show all projects"
+	}
+	return []paletteCommand{
+		{ID: "addDirectory", Title: "Add directory", Key: k.AddDirectory, Run: Model.openAddDir},
+		{ID: "manageDirs", Title: "Project directories", Key: k.ManageDirs, Run: func(m Model) (tea.Model, tea.Cmd) {
+			return m.openDirs(false)
+		}},
+		{ID: "openConfig", Title: "Settings", Key: k.OpenConfig, Run: Model.openSettings},
+		{ID: "switchProfile", Title: "Switch profile", Key: k.SwitchProfile, Run: Model.openProfiles},
+		{ID: "filterFavorites", Title: favorites, Key: k.FilterFavorites, Run: Model.toggleFavoritesOnly},
+		{ID: "refresh", Title: "Refresh projects", Run: func(m Model) (tea.Model, tea.Cmd) {
+			return m, requestRefresh
+		}},
+		{ID: "history", Title: "Message history", Key: k.History, Run: func(m Model) (tea.Model, tea.Cmd) {
+			m.Overlay = &HistoryState{}
+			return m, nil
+		}},
+		{ID: "help", Title: "Keys", Key: k.Help, Run: func(m Model) (tea.Model, tea.Cmd) {
+			m.Overlay = &HelpState{}
+			return m, nil
+		}},
+		{ID: "selectAll", Title: "Select all shown projects", Key: k.SelectAll, Run: Model.selectAll},
+		{ID: "quit", Title: "Quit", Key: m.List.KeyMap.Quit, Run: func(m Model) (tea.Model, tea.Cmd) {
+			return m, tea.Quit
+		}},
+	}
+}
+
+// selectionCommands are the bulk actions for the selected projects
+func (m Model) selectionCommands() []paletteCommand {
+	projects := m.selectedProjects()
+	if len(projects) == 0 {
+		return nil
+	}
+	detail := fmt.Sprintf("%d selected projects", len(projects))
+	var commands []paletteCommand
+	for _, action := range bulkActions() {
+		if action.Run == nil {
+			continue
+		}
+		run := action.Run
+		commands = append(commands, paletteCommand{
+			ID:     "bulk:" + action.Name,
+			Title:  action.Name,
+			Detail: detail,
+			Run:    func(m Model) (tea.Model, tea.Cmd) { return run(m, projects) },
+		})
+	}
+	return commands
+}
+
+// projectCommands are the context menu actions, custom commands and plugins
+// for a project
+func (m Model) projectCommands(p project.Project) []paletteCommand {
+	var commands []paletteCommand
+	for _, action := range contextActions() {
+		if action.Run == nil {
+			continue
+		}
+		c := paletteCommand{ID: "action:" + action.Name, Title: action.Name, Detail: p.Name}
+		if action.Key != nil {
+			c.Key = action.Key(m.KeyMap)
+		}
+		run := action.Run
+		c.Run = func(m Model) (tea.Model, tea.Cmd) { return run(m, p) }
+		commands = append(commands, c)
+	}
+
+	custom, err := runner.ForProject(m.Config, p.Path)
+	var untrusted *config.UntrustedError
+	if errors.As(err, &untrusted) {
+		// The commands of the file show up once it is trusted
+		c := paletteCommand{ID: "trust", Title: "Trust " + config.ProjectFile, Detail: p.Name + " · " + untrusted.Error()}
+		c.Run = func(m Model) (tea.Model, tea.Cmd) {
+			m.askTrust(untrusted, func(m Model) (tea.Model, tea.Cmd) { return m.openPalette() })
+			return m, nil
+		}
+		commands = append(commands, c)
+	} else if err != nil {
+		m.Status.Warnf("Custom commands of %s: %v", p.Name, err)
+		custom = runner.Plugins()
+	}
+	for _, rc := range custom {
+		rc := rc
+		c := paletteCommand{ID: "command:" + rc.Name, Title: "Run " + rc.Name, Detail: p.Name}
+		if rc.IsPlugin() {
+			c.ID = "plugin:" + rc.Name
+		}
+		if rc.Description != "" {
+			c.Detail += " · " + rc.Description
+		}
+		c.Run = func(m Model) (tea.Model, tea.Cmd) { return m.runCommand(rc, p) }
+		commands = append(commands, c)
+	}
+	return commands
+}
+
+// openPalette shows the command palette. The recent commands are loaded in
+// the background and move to the top once they are in.
+func (m Model) openPalette() (tea.Model, tea.Cmd) {
+	commands := append(m.selectionCommands(), m.globalCommands()...)
+	if i, ok := m.List.SelectedItem().(ListItem); ok {
+		commands = append(m.projectCommands(i.Project), commands...)
+	}
+	m.Overlay = &PaletteState{Commands: commands, Matches: rankCommands(commands, "", nil)}
+	profile := m.Config.ActiveProfile()
+	return m, func() tea.Msg {
+		recent, err := cache.RecentCommands(profile)
+		return RecentCommandsMsg{Recent: recent, Err: err}
+	}
+}
+
+func (m Model) handleRecentCommands(msg RecentCommandsMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		// The palette works without them
+		m.Status.Warnf("Could not load recent commands: %v", msg.Err)
+		return m, nil
+	}
+	if st, ok := m.Overlay.(*PaletteState); ok {
+		st.Recent = msg.Recent
+		st.Matches = rankCommands(st.Commands, st.Input, st.Recent)
+		st.Cursor = 0
+	}
+	return m, nil
+}
+
+// recordCommand adds a command to the recent commands in the background
+func recordCommand(profile, id string) tea.Cmd {
+	return func() tea.Msg {
+		return CacheSavedMsg{Err: cache.RecordCommand(profile, id)}
+	}
+}
+
+// rankCommands returns the commands matching query, recently used ones
+// first, then by how well they match
+func rankCommands(commands []paletteCommand, query string, recent []string) []paletteCommand {
+	rank := make(map[string]int, len(recent))
+	for i, id := range recent {
+		rank[id] = i + 1
+	}
+
+	var matches []paletteCommand
+	if query == "" {
+		matches = append(matches, commands...)
+	} else {
+		words := make([]string, len(commands))
+		for i, c := range commands {
+			words[i] = c.Title + " " + c.Detail
+		}
+		for _, match := range fuzzy.Find(query, words) {
+			matches = append(matches, commands[match.Index])
+		}
+	}
+
+	// Keep the order of the matches among commands not run recently
+	sort.SliceStable(matches, func(i, j int) bool {
+		ri, rj := rank[matches[i].ID], rank[matches[j].ID]
+		switch {
+		case ri == 0:
+			return false
+		case rj == 0:
+			return true
+		default:
+			return ri < rj
+		}
+	})
+	return matches
+}
+
+func (m Model) handlePaletteUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*PaletteState)
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.Overlay = nil
+	case tea.KeyUp, tea.KeyShiftTab:
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case tea.KeyDown, tea.KeyTab:
+		if st.Cursor < len(st.Matches)-1 {
+			st.Cursor++
+		}
+	case tea.KeyEnter:
+		m.Overlay = nil
+		if len(st.Matches) == 0 {
+			return m, nil
+		}
+		c := st.Matches[st.Cursor]
+		model, cmd := c.Run(m)
+		return model, tea.Batch(recordCommand(m.Config.ActiveProfile(), c.ID), cmd)
+	case tea.KeyBackspace:
+		if st.Input != "" {
+			runes := []rune(st.Input)
+			st.Input = string(runes[:len(runes)-1])
+			st.Matches = rankCommands(st.Commands, st.Input, st.Recent)
+			st.Cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		st.Input += string(msg.Runes)
+		st.Matches = rankCommands(st.Commands, st.Input, st.Recent)
+		st.Cursor = 0
+	default:
+		if key.Matches(msg, m.KeyMap.Palette) {
+			m.Overlay = nil
+		}
+	}
+	return m, nil
+}
+
+func (m Model) renderPaletteView() string {
+	st := m.Overlay.(*PaletteState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Commands"))
+	s.WriteString("\n\n")
+
+	input := st.Input
+	if input == "" {
+		input = m.Styles.Placeholder.Render("Type to search")
+	}
+	s.WriteString(m.Styles.Input.Render("> "+input) + "\n")
+
+	recent := make(map[string]bool, len(st.Recent))
+	for _, id := range st.Recent {
+		recent[id] = true
+	}
+
+	// Keep room for the header, the input and the help line
+	height := len(st.Matches)
+	if m.Height > 14 {
+		height = min(height, m.Height-14)
+	}
+	start := max(0, st.Cursor-height+1)
+	end := min(len(st.Matches), start+height)
+
+	width := 0
+	for _, c := range st.Matches[start:end] {
+		width = max(width, lipgloss.Width(c.Title))
+	}
+	var rows []string
+	for i := start; i < end; i++ {
+		c := st.Matches[i]
+		row := c.Title + strings.Repeat(" ", width-lipgloss.Width(c.Title))
+		detail := c.Detail
+		if recent[c.ID] {
+			detail = strings.TrimPrefix(detail+" · recent", " · ")
+		}
+		if detail != "" {
+			row += "  " + m.Styles.Placeholder.Render(detail)
+		}
+		if keys := c.Key.Help().Key; keys != "" {
+			row += "  " + m.Styles.SelectedMenuItem.Render(keys)
+		}
+		if i == st.Cursor {
+			rows = append(rows, m.Styles.SelectedItem.Render("> "+row))
+		} else {
+			rows = append(rows, m.Styles.RegularItem.Render("  "+row))
+		}
+	}
+	// Pad the rows to the same width so the titles stay lined up once
+	// centered
+	rowWidth := 0
+	for _, row := range rows {
+		rowWidth = max(rowWidth, lipgloss.Width(row))
+	}
+	for _, row := range rows {
+		s.WriteString(row + strings.Repeat(" ", rowWidth-lipgloss.Width(row)) + "\n")
+	}
+	if len(st.Matches) == 0 {
+		s.WriteString(m.Styles.Placeholder.Render("No matching commands") + "\n")
+	}
+
+	hint := helpLine(paletteKeys.Move, paletteKeys.Run, paletteKeys.Close)
+	s.WriteString("\n" + m.Styles.RegularItem.Render(fmt.Sprintf("%s • %d commands", hint, len(st.Matches))))
+
+	return m.centerLines(s.String())
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/project"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRankCommands(t *testing.T) {
+	commands := []paletteCommand{
+		{ID: "editor", Title: "Editor"},
+		{ID: "explorer", Title: "Explorer"},
+		{ID: "settings", Title: "Settings"},
+		{ID: "test", Title: "Run test", Detail: "api · Run the tests"},
+	}
+	ids := func(cs []paletteCommand) string {
+		var s []string
+		for _, c := range cs {
+			s = append(s, c.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	if got := ids(rankCommands(commands, "", nil)); got != "editor,explorer,settings,test" {
+		t.Errorf("expected the registry order without a query, got %s", got)
+	}
+	if got := ids(rankCommands(commands, "", []string{"test", "settings"})); got != "test,settings,editor,explorer" {
+		t.Errorf("expected recent commands first, got %s", got)
+	}
+	if got := ids(rankCommands(commands, "exp", nil)); got != "explorer" {
+		t.Errorf("expected only explorer to match exp, got %s", got)
+	}
+	// A recent command comes before better matches
+	if got := ids(rankCommands(commands, "e", []string{"test"})); !strings.HasPrefix(got, "test,") {
+		t.Errorf("expected the recent test command first, got %s", got)
+	}
+}
+
+func TestProjectCommandsNeedTrust(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	t.Setenv("DEN_CONFIG", "")
+	// No plugins
+	t.Setenv("PATH", "")
+	dir := filepath.Join(tmpDir, "api")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, config.ProjectFile), []byte("[commands.serve]\nrun = \"go run .\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Commands = map[string]config.CommandConfig{"test": {Run: "make test"}}
+	p := project.Project{Name: "api", Path: dir}
+	m := Model{
+		Config: cfg,
+		KeyMap: DefaultKeyMap(),
+		List:   list.New([]list.Item{ListItem{Project: p}}, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+	find := func(commands []paletteCommand, id string) *paletteCommand {
+		for i := range commands {
+			if commands[i].ID == id {
+				return &commands[i]
+			}
+		}
+		return nil
+	}
+
+	commands := m.projectCommands(p)
+	if find(commands, "command:serve") != nil {
+		t.Error("expected no commands from an untrusted file")
+	}
+	if find(commands, "command:test") == nil {
+		t.Error("expected the commands of the config")
+	}
+	trust := find(commands, "trust")
+	if trust == nil {
+		t.Fatal("expected an entry to trust the file")
+	}
+
+	model, _ := trust.Run(m)
+	m = model.(Model)
+	if _, ok := m.Overlay.(*TrustState); !ok {
+		t.Fatal("expected the trust prompt")
+	}
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = model.(Model)
+	palette, ok := m.Overlay.(*PaletteState)
+	if !ok {
+		t.Fatal("expected the palette to open again")
+	}
+	if find(palette.Commands, "command:serve") == nil || find(palette.Commands, "trust") != nil {
+		t.Error("expected the commands of the trusted file")
+	}
+}
+
+func TestPaletteLoadsRecentCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	t.Setenv("DEN_CONFIG", "")
+
+	cfg := config.DefaultConfig()
+	if err := cache.RecordCommand(cfg.ActiveProfile(), "quit"); err != nil {
+		t.Fatal(err)
+	}
+	m := Model{
+		Config: cfg,
+		KeyMap: DefaultKeyMap(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+
+	model, cmd := m.openPalette()
+	m = model.(Model)
+	palette, ok := m.Overlay.(*PaletteState)
+	if !ok {
+		t.Fatal("expected the palette to open")
+	}
+	if cmd == nil {
+		t.Fatal("expected the recent commands to load in the background")
+	}
+	model, _ = m.Update(cmd())
+	m = model.(Model)
+	if len(palette.Matches) == 0 || palette.Matches[0].ID != "quit" {
+		t.Errorf("expected the recent command first once loaded, got %+v", palette.Matches)
+	}
+}
@@ -0,0 +1,403 @@
+package tui
+
+import (
+	"bytes"
+	"den/internal/procscan"
+	"den/internal/project"
+	"den/internal/supervisor"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// processInterval is how often the list checks which processes still run
+const processInterval = 3 * time.Second
+
+// logInterval is how often the log view reads the log again
+const logInterval = time.Second
+
+// logTailBytes bounds how much of a log file is read
+const logTailBytes = 64 * 1024
+
+// processKeys are the keys of the processes view. Logs, stop and restart
+// only apply to processes den started.
+var processKeys = struct {
+	Logs, Stop, Restart, Kill, Confirm key.Binding
+}{
+	Logs:    key.NewBinding(key.WithKeys("l", "enter"), key.WithHelp("l/enter", "follow the log")),
+	Stop:    key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "stop")),
+	Restart: key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "restart")),
+	Kill:    key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "kill, after asking")),
+	Confirm: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "confirm the kill")),
+}
+
+// ProcessesMsg carries the processes running in projects, by project path
+type ProcessesMsg struct {
+	// Running are the background processes den started
+	Running map[string][]supervisor.Process
+	// Detected are all processes working in a project, where supported
+	Detected map[string][]procscan.Process
+	// poll is set for the periodic check, which schedules the next one
+	poll bool
+}
+
+// ProcessActionMsg reports a stop, restart or kill. Status tells what
+// failed when Err is set.
+type ProcessActionMsg struct {
+	Status string
+	Err    error
+}
+
+// LogTickMsg asks the log view to read the log again
+type LogTickMsg struct {
+	id int
+}
+
+// ProcessesState tracks the processes view of a project
+type ProcessesState struct {
+	Project project.Project
+	Cursor  int
+	// Confirm is the process to kill once the user confirms
+	Confirm *processRow
+}
+
+func (st *ProcessesState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleProcessesUpdate(msg)
+}
+
+func (st *ProcessesState) view(m Model) string {
+	return m.renderProcessesView()
+}
+
+// processRow is a process in the processes view. Supervised processes can
+// also be restarted and have a log.
+type processRow struct {
+	PID        int
+	Command    string
+	Started    time.Time
+	Ports      []int
+	Supervised *supervisor.Process
+}
+
+// LogsState tracks the log view of a process
+type LogsState struct {
+	// id tells the ticks of this view from those of a view closed before
+	id      int
+	Process supervisor.Process
+	Lines   []string
+	Err     error
+	// back is the processes view the log was opened from
+	back Overlay
+}
+
+func (st *LogsState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleLogsUpdate(msg)
+}
+
+func (st *LogsState) view(m Model) string {
+	return m.renderLogsView()
+}
+
+// loadProcesses finds the processes running in the projects in the
+// background
+func (m Model) loadProcesses(poll bool) tea.Cmd {
+	paths := make([]string, len(m.Projects))
+	for i, p := range m.Projects {
+		paths[i] = p.Path
+	}
+	return func() tea.Msg {
+		running, err := supervisor.ByProject()
+		if err != nil {
+			running = nil
+		}
+		// Scanning is best effort, e.g. /proc may be restricted
+		detected, _ := procscan.Scan(paths)
+		return ProcessesMsg{Running: running, Detected: detected, poll: poll}
+	}
+}
+
+// pollProcesses checks the running processes after processInterval
+func (m Model) pollProcesses() tea.Cmd {
+	load := m.loadProcesses(true)
+	return tea.Tick(processInterval, func(time.Time) tea.Msg {
+		return load()
+	})
+}
+
+func (m Model) handleProcesses(msg ProcessesMsg) (tea.Model, tea.Cmd) {
+	var next tea.Cmd
+	if msg.poll {
+		next = m.pollProcesses()
+	}
+	if reflect.DeepEqual(m.Running, msg.Running) && reflect.DeepEqual(m.Detected, msg.Detected) {
+		return m, next
+	}
+	m.Running = msg.Running
+	m.Detected = msg.Detected
+	if st, ok := m.Overlay.(*ProcessesState); ok {
+		st.Cursor = min(st.Cursor, max(0, len(m.processRows(st.Project.Path))-1))
+	}
+	return m, tea.Batch(m.refreshListItems(), next)
+}
+
+// processRows returns the processes of a project: those den started, then
+// the others found working in it
+func (m Model) processRows(path string) []processRow {
+	var rows []processRow
+	supervised := make(map[int]bool)
+	for _, p := range m.Running[path] {
+		p := p
+		supervised[p.PID] = true
+		row := processRow{PID: p.PID, Command: p.Command, Started: p.Started, Supervised: &p}
+		if p.Port != 0 {
+			row.Ports = []int{p.Port}
+		}
+		rows = append(rows, row)
+	}
+	for _, p := range m.Detected[path] {
+		if supervised[p.PID] {
+			continue
+		}
+		rows = append(rows, processRow{PID: p.PID, Command: p.CommandLine(), Started: p.Started, Ports: p.Ports})
+	}
+	return rows
+}
+
+// processSummary returns the number of processes running in a project and
+// the ports they listen on
+func (m Model) processSummary(path string) (int, []int) {
+	rows := m.processRows(path)
+	seen := make(map[int]bool)
+	var ports []int
+	for _, row := range rows {
+		for _, port := range row.Ports {
+			if !seen[port] {
+				seen[port] = true
+				ports = append(ports, port)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return len(rows), ports
+}
+
+// openProcesses shows the processes running in a project
+func (m Model) openProcesses(p project.Project) (tea.Model, tea.Cmd) {
+	if len(m.processRows(p.Path)) == 0 {
+		m.Status.Warnf("Nothing is running in %s", p.Name)
+		return m, nil
+	}
+	m.Overlay = &ProcessesState{Project: p}
+	return m, nil
+}
+
+func (m Model) handleProcessesUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*ProcessesState)
+	rows := m.processRows(st.Project.Path)
+	if len(rows) == 0 {
+		m.Overlay = nil
+		return m, nil
+	}
+	row := rows[min(st.Cursor, len(rows)-1)]
+
+	if st.Confirm != nil {
+		target := *st.Confirm
+		st.Confirm = nil
+		if !key.Matches(msg, processKeys.Confirm) {
+			return m, nil
+		}
+		if target.Supervised != nil {
+			return m.stopProcess(*target.Supervised)
+		}
+		m.Status.Infof("Killing %s…", target.Command)
+		return m, func() tea.Msg {
+			if err := procscan.Kill(target.PID); err != nil {
+				return ProcessActionMsg{Status: fmt.Sprintf("Could not kill %d", target.PID), Err: err}
+			}
+			return ProcessActionMsg{Status: fmt.Sprintf("Killed %s (pid %d)", target.Command, target.PID)}
+		}
+	}
+
+	// Restarting and logs only apply to processes den started
+	proc := row.Supervised
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < len(rows)-1 {
+			st.Cursor++
+		}
+	case key.Matches(msg, processKeys.Kill):
+		st.Confirm = &row
+	case key.Matches(msg, processKeys.Stop) && proc != nil:
+		return m.stopProcess(*proc)
+	case key.Matches(msg, processKeys.Restart) && proc != nil:
+		m.Status.Infof("Restarting %s…", proc.Command)
+		return m, func() tea.Msg {
+			p, err := supervisor.Restart(proc.PID)
+			if err != nil {
+				return ProcessActionMsg{Status: fmt.Sprintf("Could not restart %s", proc.Command), Err: err}
+			}
+			return ProcessActionMsg{Status: fmt.Sprintf("Restarted %s (pid %d)", p.Command, p.PID)}
+		}
+	case key.Matches(msg, processKeys.Logs) && proc != nil:
+		m.logsID++
+		// Closing the log goes back to the processes
+		logs := &LogsState{id: m.logsID, Process: *proc, back: st}
+		logs.read()
+		m.Overlay = logs
+		return m, tickLogs(m.logsID)
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+// stopProcess stops a process den started, and what it started
+func (m Model) stopProcess(proc supervisor.Process) (tea.Model, tea.Cmd) {
+	m.Status.Infof("Stopping %s…", proc.Command)
+	return m, func() tea.Msg {
+		if err := supervisor.Stop(proc.PID); err != nil {
+			return ProcessActionMsg{Status: fmt.Sprintf("Could not stop %s", proc.Command), Err: err}
+		}
+		return ProcessActionMsg{Status: fmt.Sprintf("Stopped %s", proc.Command)}
+	}
+}
+
+func (m Model) handleProcessAction(msg ProcessActionMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("%s: %v", msg.Status, msg.Err)
+	} else {
+		m.Status.Successf("%s", msg.Status)
+	}
+	return m, m.loadProcesses(false)
+}
+
+func (m Model) renderProcessesView() string {
+	st := m.Overlay.(*ProcessesState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Running in " + st.Project.Name))
+	s.WriteString("\n\n")
+
+	hasSupervised := false
+	for i, p := range m.processRows(st.Project.Path) {
+		row := fmt.Sprintf("%s  pid %d", p.Command, p.PID)
+		if !p.Started.IsZero() {
+			row += fmt.Sprintf(" · up %s", time.Since(p.Started).Round(time.Second))
+		}
+		for _, port := range p.Ports {
+			row += fmt.Sprintf(" · port %d", port)
+		}
+		if p.Supervised != nil {
+			hasSupervised = true
+			row += " · started by den"
+		}
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+	if st.Confirm != nil {
+		s.WriteString("\n" + m.Styles.Error.Render(fmt.Sprintf("Kill %s (pid %d)? y/n", st.Confirm.Command, st.Confirm.PID)) + "\n")
+	} else if msg := m.Status.Current; msg != nil {
+		s.WriteString("\n" + m.renderStatusMessage(*msg) + "\n")
+	}
+
+	back := withDesc(m.KeyMap.Escape, "back")
+	help := helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(processKeys.Kill, "kill"), back)
+	if hasSupervised {
+		help = helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(processKeys.Logs, "logs"), processKeys.Stop, processKeys.Restart, withDesc(processKeys.Kill, "kill"), back)
+	}
+	s.WriteString("\n" + m.Styles.RegularItem.Render(help))
+
+	return m.centerLines(s.String())
+}
+
+// tickLogs reads the log again after logInterval
+func tickLogs(id int) tea.Cmd {
+	return tea.Tick(logInterval, func(time.Time) tea.Msg {
+		return LogTickMsg{id: id}
+	})
+}
+
+func (m Model) handleLogTick(msg LogTickMsg) (tea.Model, tea.Cmd) {
+	// The tick stops once its log view is closed
+	st, ok := m.Overlay.(*LogsState)
+	if !ok || st.id != msg.id {
+		return m, nil
+	}
+	st.read()
+	return m, tickLogs(msg.id)
+}
+
+// read loads the end of the log file
+func (st *LogsState) read() {
+	f, err := os.Open(st.Process.Log)
+	if err != nil {
+		st.Err = err
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		st.Err = err
+		return
+	}
+	offset := max(0, info.Size()-logTailBytes)
+	buf := make([]byte, info.Size()-offset)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		st.Err = err
+		return
+	}
+	if offset > 0 {
+		// Drop the partial first line
+		if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+			buf = buf[i+1:]
+		}
+	}
+	st.Lines = strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	st.Err = nil
+}
+
+func (m Model) handleLogsUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, m.KeyMap.Escape) || msg.String() == "q" {
+		m.Overlay = m.Overlay.(*LogsState).back
+	}
+	return m, nil
+}
+
+func (m Model) renderLogsView() string {
+	st := m.Overlay.(*LogsState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader(st.Process.Command))
+	s.WriteString("\n")
+	s.WriteString(m.Styles.Placeholder.Render(st.Process.Log) + "\n\n")
+
+	if st.Err != nil {
+		s.WriteString(m.Styles.Error.Render(fmt.Sprintf("Could not read log: %v", st.Err)) + "\n")
+	} else {
+		// Keep room for the header and the help line
+		lines := st.Lines
+		if height := m.Height - 10; height > 0 && len(lines) > height {
+			lines = lines[len(lines)-height:]
+		}
+		for _, line := range lines {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render("following the log • "+helpLine(withDesc(m.KeyMap.Escape, "back"))))
+	return s.String()
+}
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/supervisor"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestStopProcessReportsFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+	t.Setenv("DEN_CONFIG", "")
+
+	m := Model{
+		Config: config.DefaultConfig(),
+		KeyMap: DefaultKeyMap(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+	// Not a process den started
+	_, cmd := m.stopProcess(supervisor.Process{PID: 999999, Command: "npm run dev"})
+	msg, ok := cmd().(ProcessActionMsg)
+	if !ok {
+		t.Fatal("expected a ProcessActionMsg")
+	}
+	if msg.Err == nil {
+		t.Errorf("expected the failure to be reported as an error, got %q", msg.Status)
+	}
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"den/internal/cache"
+	"den/internal/config"
+	"den/internal/project"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProfilesState tracks the state of the profile switcher. The first entry
+// is always "" for the top-level settings.
+type ProfilesState struct {
+	Names  []string
+	Cursor int
+	Err    error
+}
+
+func (st *ProfilesState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleProfilesUpdate(msg)
+}
+
+func (st *ProfilesState) view(m Model) string {
+	return m.renderProfilesView()
+}
+
+// openProfiles shows the profile switcher with the active profile selected
+func (m Model) openProfiles() (tea.Model, tea.Cmd) {
+	names := append([]string{""}, m.Config.ProfileNames()...)
+	st := &ProfilesState{Names: names}
+	for i, name := range names {
+		if name == m.Config.ActiveProfile() {
+			st.Cursor = i
+		}
+	}
+	m.Overlay = st
+	return m, nil
+}
+
+func (m Model) handleProfilesUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*ProfilesState)
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Cursor > 0 {
+			st.Cursor--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Cursor < len(st.Names)-1 {
+			st.Cursor++
+		}
+	case key.Matches(msg, m.KeyMap.Enter):
+		return m.switchProfile(st.Names[st.Cursor])
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+// switchProfile makes name the active and default profile, shows its
+// cached projects and refreshes them in the background
+func (m Model) switchProfile(name string) (tea.Model, tea.Cmd) {
+	if name == m.Config.ActiveProfile() {
+		m.Overlay = nil
+		return m, nil
+	}
+
+	if err := m.Config.UseProfile(name); err != nil {
+		m.Overlay.(*ProfilesState).Err = err
+		return m, nil
+	}
+	m.Config.Profile = name
+	if err := config.SaveConfig(m.Config); err != nil {
+		m.Overlay.(*ProfilesState).Err = fmt.Errorf("error saving config: %v", err)
+		return m, nil
+	}
+
+	m.Overlay = nil
+	m.applyTheme(m.Config.Preferences.Theme)
+	m.Status.Successf("Switched to profile %s", profileLabel(name))
+
+	// Show the profile's cached projects, then refresh them
+	m.Projects = nil
+	m.LastRefreshed = time.Time{}
+	if projectCache, err := cache.LoadCache(name); err == nil {
+		m.Projects = project.ConvertCacheToProjects(projectCache.Projects)
+		m.LastRefreshed = projectCache.LastUpdated
+	}
+	cmd := m.refreshListItems()
+
+	if len(m.Config.ProjectDirs) == 0 {
+		// Same as the first run: ask for a directory
+		m.Overlay = &AddDirState{Required: true}
+		m.Input = ""
+		m.TabState = &TabCompletionState{
+			Suggestions: getPathSuggestions("", m.Config),
+			Index:       0,
+			Page:        0,
+			PageSize:    DefaultPageSize,
+		}
+		return m, cmd
+	}
+
+	return m, tea.Batch(cmd, m.startRefresh())
+}
+
+// profileLabel is the display name of a profile
+func profileLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// listTitle is the project list title, with the active profile
+func (m Model) listTitle() string {
+	title := m.Config.Preferences.ProjectListTitle
+	if profile := m.Config.ActiveProfile(); profile != "" {
+		title += " · " + profile
+	}
+	return title
+}
+
+func (m Model) renderProfilesView() string {
+	st := m.Overlay.(*ProfilesState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Profiles"))
+	s.WriteString("\n\n")
+
+	for i, name := range st.Names {
+		row := profileLabel(name)
+		if name == "" {
+			row += "  (top-level settings)"
+		} else {
+			p := m.Config.Profiles[name]
+			dirs := p.ProjectDirs
+			if name == m.Config.ActiveProfile() {
+				dirs = m.Config.ProjectDirs
+			}
+			row += fmt.Sprintf("  (%d directories)", len(dirs))
+		}
+		if name == m.Config.ActiveProfile() {
+			row += " ✓"
+		}
+
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+
+	if len(st.Names) == 1 {
+		s.WriteString("\n" + m.Styles.Placeholder.Render("No profiles yet. Add a [profiles.<name>] table to the config file.") + "\n")
+	}
+
+	if st.Err != nil {
+		s.WriteString("\n" + m.Styles.Error.Render(fmt.Sprintf("Error: %v", st.Err)) + "\n")
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render(helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(m.KeyMap.Enter, "switch"), withDesc(m.KeyMap.Escape, "back"))))
+
+	return m.centerLines(s.String())
+}
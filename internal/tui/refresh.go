@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"den/internal/cache"
+	"den/internal/daemon"
+	"den/internal/project"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// refreshBatchSize is how many re-detected projects are sent to the list at once
+const refreshBatchSize = 8
+
+// RefreshMsg asks the model to rescan projects in the background
+type RefreshMsg struct{}
+
+// ProjectsUpdatedMsg carries projects re-detected by a background refresh.
+// More messages follow until a RefreshDoneMsg.
+type ProjectsUpdatedMsg struct {
+	Projects []project.Project
+	id       int
+	updates  <-chan tea.Msg
+}
+
+// RefreshDoneMsg ends a background refresh with the complete project list
+type RefreshDoneMsg struct {
+	Projects []project.Project
+	Time     time.Time
+	id       int
+	cache    *cache.ProjectCache
+	// profile is the config profile the cache belongs to
+	profile string
+}
+
+// NewSpinner returns the spinner shown while projects are refreshed
+func NewSpinner() spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.MiniDot))
+}
+
+// requestRefresh is a command that starts a background refresh
+func requestRefresh() tea.Msg {
+	return RefreshMsg{}
+}
+
+// startRefresh rescans the project directories in the background, or asks
+// the daemon to if one is running. Projects that changed are streamed to
+// the list as they are detected, and the cache is saved when the rescan is
+// done. A refresh started while another is running supersedes it.
+func (m *Model) startRefresh() tea.Cmd {
+	if len(m.Config.ProjectDirs) == 0 {
+		return nil
+	}
+
+	m.refreshID++
+	id := m.refreshID
+	wasRefreshing := m.Refreshing
+	m.Refreshing = true
+
+	cfg := m.Config.Clone()
+
+	updates := make(chan tea.Msg)
+	go func() {
+		// A running daemon does the work, and usually has nothing left to do
+		if updated, err := daemon.Index(cfg.ActiveProfile(), true); err == nil {
+			updates <- RefreshDoneMsg{
+				Projects: project.ConvertCacheToProjects(updated.Projects),
+				Time:     updated.LastUpdated,
+				id:       id,
+				cache:    updated,
+				profile:  cfg.ActiveProfile(),
+			}
+			return
+		}
+
+		// A broken cache just means a full scan
+		prev, _ := cache.LoadCache(cfg.ActiveProfile())
+		var batch []project.Project
+		updated, _ := project.RescanStream(prev, cfg.ProjectDirs, cfg, func(p project.Project) {
+			batch = append(batch, p)
+			if len(batch) == refreshBatchSize {
+				updates <- ProjectsUpdatedMsg{Projects: batch, id: id, updates: updates}
+				batch = nil
+			}
+		})
+		if len(batch) > 0 {
+			updates <- ProjectsUpdatedMsg{Projects: batch, id: id, updates: updates}
+		}
+		updates <- RefreshDoneMsg{
+			Projects: project.ConvertCacheToProjects(updated.Projects),
+			Time:     updated.LastUpdated,
+			id:       id,
+			cache:    updated,
+			profile:  cfg.ActiveProfile(),
+		}
+	}()
+
+	if wasRefreshing {
+		// The spinner is already ticking
+		return waitForRefresh(updates)
+	}
+	return tea.Batch(m.Spinner.Tick, waitForRefresh(updates))
+}
+
+// waitForRefresh delivers the next message of a background refresh
+func waitForRefresh(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// handleProjectsUpdated merges re-detected projects into the list. Updates
+// from a superseded refresh are dropped, but still read so that its
+// goroutine can finish.
+func (m Model) handleProjectsUpdated(msg ProjectsUpdatedMsg) (tea.Model, tea.Cmd) {
+	next := waitForRefresh(msg.updates)
+	if msg.id != m.refreshID {
+		return m, next
+	}
+	return m, tea.Batch(m.mergeProjects(msg.Projects), next)
+}
+
+func (m Model) handleRefreshDone(msg RefreshDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.id != m.refreshID {
+		return m, nil
+	}
+	m.Refreshing = false
+	m.LastRefreshed = msg.Time
+
+	// Favorites may have been toggled while the refresh was running
+	for i := range msg.Projects {
+		msg.Projects[i].Favorite = m.isFavorite(msg.Projects[i].Path)
+	}
+	for i := range msg.cache.Projects {
+		msg.cache.Projects[i].Favorite = m.isFavorite(msg.cache.Projects[i].Path)
+	}
+	// The complete list also drops projects that no longer exist
+	m.Projects = msg.Projects
+	m.syncWatcher()
+	m.refreshDirEntries()
+	return m, tea.Batch(m.refreshListItems(), persistCache(msg.profile, msg.cache))
+}
+
+// CacheSavedMsg reports that a refreshed cache was saved
+type CacheSavedMsg struct {
+	Err error
+}
+
+// persistCache saves c to the store of a config profile in the background,
+// as the store may have to wait for another den to let go of it
+func persistCache(profile string, c *cache.ProjectCache) tea.Cmd {
+	return func() tea.Msg {
+		return CacheSavedMsg{Err: c.SaveCache(profile)}
+	}
+}
+
+// recordVisits adds projects to the history of a config profile in the
+// background, for the same reason as persistCache
+func recordVisits(profile string, paths ...string) tea.Cmd {
+	return func() tea.Msg {
+		for _, path := range paths {
+			if err := cache.RecordVisit(profile, path); err != nil {
+				return CacheSavedMsg{Err: err}
+			}
+		}
+		return CacheSavedMsg{}
+	}
+}
+
+// mergeProjects replaces loaded projects with updated versions by path and
+// adds new ones
+func (m *Model) mergeProjects(updated []project.Project) tea.Cmd {
+	projects := append([]project.Project{}, m.Projects...)
+	index := make(map[string]int, len(projects))
+	for i, p := range projects {
+		index[p.Path] = i
+	}
+	for _, p := range updated {
+		p.Favorite = m.isFavorite(p.Path)
+		if i, ok := index[p.Path]; ok {
+			// Detection doesn't know about tags
+			if p.Tags == nil {
+				p.Tags = projects[i].Tags
+			}
+			projects[i] = p
+		} else {
+			index[p.Path] = len(projects)
+			projects = append(projects, p)
+		}
+	}
+	m.Projects = projects
+	return m.refreshListItems()
+}
+
+func (m Model) isFavorite(path string) bool {
+	for _, fav := range m.Config.Favorites {
+		if fav == path {
+			return true
+		}
+	}
+	return false
+}
+
+// selectProject moves the cursor to the visible project at path, if any
+func (m *Model) selectProject(path string) {
+	for i, item := range m.List.VisibleItems() {
+		if listItem, ok := item.(ListItem); ok && listItem.Project.Path == path {
+			m.List.Select(i)
+			return
+		}
+	}
+}
+
+// refreshStatus shows a spinner while refreshing, and the age of the
+// cache otherwise
+func (m Model) refreshStatus() string {
+	if m.Refreshing {
+		return m.Spinner.View() + " refreshing"
+	}
+	if m.LastRefreshed.IsZero() {
+		return ""
+	}
+	status := "updated " + formatAge(time.Since(m.LastRefreshed))
+	if m.Watcher != nil {
+		status += " · watching"
+		if skipped := m.Watcher.Skipped(); skipped > 0 {
+			status += fmt.Sprintf(" (%d projects not watched, too many to watch)", skipped)
+		}
+	}
+	return status
+}
+
+// formatAge formats a duration as a short "ago" string
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// filterApplied reports whether the list shows filtered items
+func (m Model) filterApplied() bool {
+	return m.List.FilterState() != list.Unfiltered
+}
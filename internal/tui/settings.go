@@ -0,0 +1,424 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/editor"
+	"den/internal/theme"
+	"den/internal/ui"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// settingKind describes how a settings field is edited
+type settingKind int
+
+const (
+	settingText settingKind = iota
+	settingChoice
+	settingToggle
+	// settingLink opens another view instead of editing a value
+	settingLink
+)
+
+// settingField describes a single editable row on the settings screen
+type settingField struct {
+	label string
+	kind  settingKind
+	// freeform choice fields can also be typed in with enter
+	freeform bool
+	choices  func(cfg *config.Config) []string
+	get      func(cfg *config.Config) string
+	set      func(cfg *config.Config, value string)
+	open     func(m Model) (tea.Model, tea.Cmd)
+}
+
+// SettingsState tracks the state of the settings screen
+type SettingsState struct {
+	Draft   *config.Config
+	Cursor  int
+	Editing bool
+	Input   string
+	Err     error
+}
+
+func (st *SettingsState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleSettingsUpdate(msg)
+}
+
+func (st *SettingsState) view(m Model) string {
+	return m.renderSettingsView()
+}
+
+// commonFileManagers are offered as choices when they are installed
+var commonFileManagers = []string{"xdg-open", "nautilus", "dolphin", "thunar", "nemo", "open", "explorer"}
+
+var settingsFields = []settingField{
+	{
+		label:    "Editor",
+		kind:     settingChoice,
+		freeform: true,
+		choices: func(cfg *config.Config) []string {
+			return withCurrent(cfg.Preferences.EditorList, cfg.Preferences.DefaultEditor)
+		},
+		get: func(cfg *config.Config) string { return cfg.Preferences.DefaultEditor },
+		set: func(cfg *config.Config, v string) { cfg.Preferences.DefaultEditor = v },
+	},
+	{
+		label:    "File manager",
+		kind:     settingChoice,
+		freeform: true,
+		choices: func(cfg *config.Config) []string {
+			var installed []string
+			for _, fm := range commonFileManagers {
+				if _, err := exec.LookPath(fm); err == nil {
+					installed = append(installed, fm)
+				}
+			}
+			return withCurrent(installed, cfg.Preferences.DefaultFileManager)
+		},
+		get: func(cfg *config.Config) string { return cfg.Preferences.DefaultFileManager },
+		set: func(cfg *config.Config, v string) { cfg.Preferences.DefaultFileManager = v },
+	},
+	{
+		label:    "Terminal",
+		kind:     settingChoice,
+		freeform: true,
+		choices: func(cfg *config.Config) []string {
+			return withCurrent(editor.InstalledTerminals(), cfg.Preferences.Terminal)
+		},
+		get: func(cfg *config.Config) string { return cfg.Preferences.Terminal },
+		set: func(cfg *config.Config, v string) { cfg.Preferences.Terminal = v },
+	},
+	{
+		label: "Theme",
+		kind:  settingChoice,
+		choices: func(cfg *config.Config) []string {
+			names := theme.ListThemes()
+			sort.Strings(names)
+			return names
+		},
+		get: func(cfg *config.Config) string { return cfg.Preferences.Theme },
+		set: func(cfg *config.Config, v string) { cfg.Preferences.Theme = v },
+	},
+	{
+		label: "Show git status",
+		kind:  settingToggle,
+		get:   func(cfg *config.Config) string { return formatBool(cfg.Preferences.ShowGitStatus) },
+		set:   func(cfg *config.Config, v string) { cfg.Preferences.ShowGitStatus = v == "on" },
+	},
+	{
+		label:   "Git status style",
+		kind:    settingChoice,
+		choices: func(cfg *config.Config) []string { return config.GitStatusStyles },
+		get:     func(cfg *config.Config) string { return cfg.Preferences.GitStatusStyle },
+		set:     func(cfg *config.Config, v string) { cfg.Preferences.GitStatusStyle = v },
+	},
+	{
+		label: "Show hidden files",
+		kind:  settingToggle,
+		get:   func(cfg *config.Config) string { return formatBool(cfg.Preferences.ShowHiddenFiles) },
+		set:   func(cfg *config.Config, v string) { cfg.Preferences.ShowHiddenFiles = v == "on" },
+	},
+	{
+		label: "List title",
+		kind:  settingText,
+		get:   func(cfg *config.Config) string { return cfg.Preferences.ProjectListTitle },
+		set:   func(cfg *config.Config, v string) { cfg.Preferences.ProjectListTitle = v },
+	},
+	{
+		label: "Project directories",
+		kind:  settingLink,
+		get: func(cfg *config.Config) string {
+			return fmt.Sprintf("%d configured", len(cfg.ProjectDirs))
+		},
+		open: func(m Model) (tea.Model, tea.Cmd) { return m.openDirs(true) },
+	},
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// withCurrent returns choices with the current value included
+func withCurrent(choices []string, current string) []string {
+	for _, c := range choices {
+		if c == current {
+			return choices
+		}
+	}
+	if current == "" {
+		return choices
+	}
+	return append(append([]string{}, choices...), current)
+}
+
+// openSettings shows the settings screen with a copy of the current config
+func (m Model) openSettings() (tea.Model, tea.Cmd) {
+	m.Overlay = &SettingsState{Draft: m.Config.Clone()}
+	return m, nil
+}
+
+// closeSettings leaves the settings screen, restoring the saved theme
+func (m Model) closeSettings() (tea.Model, tea.Cmd) {
+	if m.Overlay.(*SettingsState).Draft.Preferences.Theme != m.Config.Preferences.Theme {
+		m.applyTheme(m.Config.Preferences.Theme)
+	}
+	m.Overlay = nil
+	return m, nil
+}
+
+// settingKeys are the keys of the settings screen
+var settingKeys = struct {
+	Prev, Next, Edit, Save, EditFile key.Binding
+}{
+	Prev:     key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "previous value")),
+	Next:     key.NewBinding(key.WithKeys("right", "l"), key.WithHelp("→/l", "next value")),
+	Edit:     key.NewBinding(key.WithKeys("enter", " "), key.WithHelp("enter", "edit or toggle")),
+	Save:     key.NewBinding(key.WithKeys("s", "ctrl+s"), key.WithHelp("s", "save")),
+	EditFile: key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit the config file")),
+}
+
+func (m Model) handleSettingsUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*SettingsState)
+	if st.Editing {
+		return m.handleSettingsInput(msg)
+	}
+
+	field := settingsFields[st.Cursor]
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		st.Cursor--
+		if st.Cursor < 0 {
+			st.Cursor = len(settingsFields) - 1
+		}
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Down):
+		st.Cursor = (st.Cursor + 1) % len(settingsFields)
+		return m, nil
+	case key.Matches(msg, settingKeys.Prev):
+		m.cycleSetting(field, -1)
+		return m, nil
+	case key.Matches(msg, settingKeys.Next):
+		m.cycleSetting(field, 1)
+		return m, nil
+	case key.Matches(msg, settingKeys.Edit):
+		switch {
+		case field.kind == settingLink:
+			return field.open(m)
+		case field.kind == settingToggle:
+			m.cycleSetting(field, 1)
+		case field.kind == settingText || field.freeform:
+			st.Editing = true
+			st.Input = field.get(st.Draft)
+		default:
+			m.cycleSetting(field, 1)
+		}
+		return m, nil
+	case key.Matches(msg, settingKeys.Save):
+		return m.saveSettings()
+	case key.Matches(msg, settingKeys.EditFile):
+		// Fall back to editing the raw file
+		configPath, err := config.GetConfigPath()
+		if err != nil {
+			st.Err = fmt.Errorf("error getting config path: %v", err)
+			return m, nil
+		}
+		ed, err := editor.DefaultEditor(m.Config)
+		if err != nil {
+			st.Err = fmt.Errorf("error opening config: %v", err)
+			return m, nil
+		}
+		return m.runEditor(ed, editor.ProjectTarget(configPath), true)
+	case key.Matches(msg, m.KeyMap.Escape), msg.String() == "q":
+		return m.closeSettings()
+	}
+	return m, nil
+}
+
+func (m Model) handleSettingsInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*SettingsState)
+	switch msg.Type {
+	case tea.KeyEnter:
+		settingsFields[st.Cursor].set(st.Draft, strings.TrimSpace(st.Input))
+		st.Editing = false
+		st.Input = ""
+		st.Err = nil
+	case tea.KeyEsc:
+		st.Editing = false
+		st.Input = ""
+	case tea.KeyBackspace:
+		if len(st.Input) > 0 {
+			runes := []rune(st.Input)
+			st.Input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		st.Input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// cycleSetting moves a choice or toggle field by delta
+func (m *Model) cycleSetting(field settingField, delta int) {
+	st := m.Overlay.(*SettingsState)
+	switch field.kind {
+	case settingToggle:
+		if field.get(st.Draft) == "on" {
+			field.set(st.Draft, "off")
+		} else {
+			field.set(st.Draft, "on")
+		}
+	case settingChoice:
+		choices := field.choices(st.Draft)
+		if len(choices) == 0 {
+			return
+		}
+		idx := 0
+		current := field.get(st.Draft)
+		for i, c := range choices {
+			if c == current {
+				idx = (i + delta + len(choices)) % len(choices)
+				break
+			}
+		}
+		field.set(st.Draft, choices[idx])
+	default:
+		return
+	}
+	st.Err = nil
+
+	// Preview theme changes immediately
+	if field.label == "Theme" {
+		m.applyTheme(st.Draft.Preferences.Theme)
+	}
+}
+
+// saveSettings validates the draft, persists it and applies it to the running UI
+func (m Model) saveSettings() (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*SettingsState)
+	draft := st.Draft
+
+	// Make directories absolute before validating
+	for i, dir := range draft.ProjectDirs {
+		if abs, err := filepath.Abs(dir); err == nil {
+			draft.ProjectDirs[i] = abs
+		}
+	}
+
+	if err := validateSettings(draft); err != nil {
+		st.Err = err
+		return m, nil
+	}
+
+	needsRescan := !equalStrings(draft.ProjectDirs, m.Config.ProjectDirs) ||
+		draft.Preferences.ShowGitStatus != m.Config.Preferences.ShowGitStatus ||
+		draft.Preferences.GitStatusStyle != m.Config.Preferences.GitStatusStyle
+
+	if err := config.SaveConfig(draft); err != nil {
+		st.Err = fmt.Errorf("failed to save config: %v", err)
+		return m, nil
+	}
+
+	// Update the shared config in place so every holder sees the change
+	*m.Config = *draft.Clone()
+	m.applyTheme(m.Config.Preferences.Theme)
+	m.Overlay = nil
+	m.Status.Successf("Settings saved")
+
+	if !needsRescan {
+		return m, nil
+	}
+	return m, m.startRefresh()
+}
+
+// validateSettings checks a draft config before it is saved
+func validateSettings(cfg *config.Config) error {
+	for _, p := range config.Validate(cfg).Problems {
+		if p.Severity == config.SeverityError {
+			return fmt.Errorf("%s: %s", p.Key, p.Message)
+		}
+	}
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyTheme rebuilds styles and the list delegate for the named theme
+func (m *Model) applyTheme(name string) {
+	activeTheme := theme.GetTheme(name)
+	styles := ui.NewStyles(activeTheme)
+	if m.Width > 0 {
+		styles.Instruction = styles.Instruction.Width(m.Width - 4)
+		styles.Title = styles.Title.Width(m.Width - 4)
+	}
+	m.Styles = styles
+	m.List.SetDelegate(ui.CreateThemedDelegate(activeTheme))
+	m.List.Styles.Title = styles.ListTitle
+}
+
+func (m Model) renderSettingsView() string {
+	st := m.Overlay.(*SettingsState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Settings"))
+	s.WriteString("\n\n")
+
+	labelWidth := 0
+	for _, field := range settingsFields {
+		if len(field.label) > labelWidth {
+			labelWidth = len(field.label)
+		}
+	}
+
+	for i, field := range settingsFields {
+		value := field.get(st.Draft)
+		if value == "" {
+			value = "(system default)"
+		}
+		if i == st.Cursor && st.Editing {
+			value = st.Input + "█"
+		} else if field.kind == settingChoice {
+			value = "‹ " + value + " ›"
+		} else if field.kind == settingLink {
+			value += " →"
+		}
+
+		row := fmt.Sprintf("%-*s  %s", labelWidth, field.label, value)
+		if i == st.Cursor {
+			s.WriteString(m.Styles.SelectedItem.Render("> "+row) + "\n")
+		} else {
+			s.WriteString(m.Styles.RegularItem.Render("  "+row) + "\n")
+		}
+	}
+
+	if st.Err != nil {
+		s.WriteString("\n" + m.Styles.Error.Render(fmt.Sprintf("Error: %v", st.Err)) + "\n")
+	}
+
+	help := helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(settingKeys.Prev, "previous"), withDesc(settingKeys.Next, "next"),
+		withDesc(settingKeys.Edit, "edit/toggle"), settingKeys.Save, withDesc(settingKeys.EditFile, "edit file"), m.KeyMap.Escape)
+	if st.Editing {
+		help = helpLine(withDesc(addDirKeys.Confirm, "apply"), withDesc(addDirKeys.Cancel, "discard"))
+	}
+	s.WriteString("\n" + m.Styles.RegularItem.Render(help))
+
+	return m.centerLines(s.String())
+}
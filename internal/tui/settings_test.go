@@ -0,0 +1,228 @@
+package tui
+
+import (
+	"den/internal/config"
+	"den/internal/theme"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// validSettings returns a config that passes validation on any machine
+func validSettings() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Preferences.DefaultEditor = "sh"
+	cfg.Preferences.EditorList = nil
+	return cfg
+}
+
+// settingsField returns the settings field with the given label
+func settingsField(t *testing.T, label string) settingField {
+	t.Helper()
+	for _, field := range settingsFields {
+		if field.label == label {
+			return field
+		}
+	}
+	t.Fatalf("no settings field %q", label)
+	return settingField{}
+}
+
+func TestValidateSettings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	withColon := filepath.Join(t.TempDir(), "a:b")
+	if err := os.Mkdir(withColon, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(cfg *config.Config)
+		errKey string
+	}{
+		{"valid", func(cfg *config.Config) {}, ""},
+		{"directory with a colon", func(cfg *config.Config) { cfg.ProjectDirs = []string{withColon} }, ""},
+		{"empty editor", func(cfg *config.Config) { cfg.Preferences.DefaultEditor = " " }, "preferences.defaultEditor"},
+		{"missing editor", func(cfg *config.Config) { cfg.Preferences.DefaultEditor = "den-no-such-editor" }, "preferences.defaultEditor"},
+		{"unknown theme", func(cfg *config.Config) { cfg.Preferences.Theme = "plaid" }, "preferences.theme"},
+		{"unknown git status style", func(cfg *config.Config) { cfg.Preferences.GitStatusStyle = "fancy" }, "preferences.gitStatusStyle"},
+		{"empty list title", func(cfg *config.Config) { cfg.Preferences.ProjectListTitle = "" }, "preferences.projectListTitle"},
+		{"file as project directory", func(cfg *config.Config) { cfg.ProjectDirs = []string{file} }, "projectDirs"},
+		// Warnings don't stop the settings from being saved
+		{"missing project directory", func(cfg *config.Config) { cfg.ProjectDirs = []string{filepath.Join(file, "missing")} }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validSettings()
+			tt.change(cfg)
+			err := validateSettings(cfg)
+			if tt.errKey == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errKey+":") {
+				t.Errorf("expected an error for %s, got %v", tt.errKey, err)
+			}
+		})
+	}
+}
+
+func TestCycleSetting(t *testing.T) {
+	themes := theme.ListThemes()
+	sort.Strings(themes)
+
+	tests := []struct {
+		label string
+		from  string
+		delta int
+		want  string
+	}{
+		{"Show git status", "on", 1, "off"},
+		{"Show git status", "off", -1, "on"},
+		{"Git status style", "text", 1, "nerd"},
+		{"Git status style", "nerd", 1, "text"},
+		{"Git status style", "text", -1, "nerd"},
+		{"Theme", themes[0], -1, themes[len(themes)-1]},
+		{"Theme", themes[len(themes)-1], 1, themes[0]},
+		// A current value that isn't a choice starts over at the first one
+		{"Git status style", "fancy", 1, "text"},
+		// Text fields are only typed in
+		{"List title", "Projects", 1, "Projects"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label+" "+tt.from, func(t *testing.T) {
+			m := Model{
+				Config: validSettings(),
+				List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+			}
+			field := settingsField(t, tt.label)
+			st := &SettingsState{Draft: m.Config.Clone()}
+			m.Overlay = st
+			field.set(st.Draft, tt.from)
+			m.cycleSetting(field, tt.delta)
+			if got := field.get(st.Draft); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if got, saved := field.get(m.Config), field.get(validSettings()); got != saved {
+				t.Errorf("expected only the draft to change, the config has %q", got)
+			}
+		})
+	}
+}
+
+func TestSaveSettings(t *testing.T) {
+	projectDir := t.TempDir()
+	withColon := filepath.Join(t.TempDir(), "work:2024")
+	if err := os.Mkdir(withColon, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(cfg *config.Config)
+		wantErr bool
+		rescan  bool
+		check   func(t *testing.T, cfg *config.Config)
+	}{
+		{
+			name:   "title",
+			change: func(cfg *config.Config) { cfg.Preferences.ProjectListTitle = "Code" },
+			check: func(t *testing.T, cfg *config.Config) {
+				if cfg.Preferences.ProjectListTitle != "Code" {
+					t.Errorf("expected the title to be saved, got %q", cfg.Preferences.ProjectListTitle)
+				}
+			},
+		},
+		{
+			name:   "directory with a colon",
+			change: func(cfg *config.Config) { cfg.ProjectDirs = []string{withColon} },
+			rescan: true,
+			check: func(t *testing.T, cfg *config.Config) {
+				if len(cfg.ProjectDirs) != 1 || cfg.ProjectDirs[0] != withColon {
+					t.Errorf("expected %s to be saved as one directory, got %v", withColon, cfg.ProjectDirs)
+				}
+			},
+		},
+		{
+			name:   "relative directory",
+			change: func(cfg *config.Config) { cfg.ProjectDirs = []string{"."} },
+			rescan: true,
+			check: func(t *testing.T, cfg *config.Config) {
+				if len(cfg.ProjectDirs) != 1 || cfg.ProjectDirs[0] != cwd {
+					t.Errorf("expected the directory to be made absolute, got %v", cfg.ProjectDirs)
+				}
+			},
+		},
+		{
+			name:   "git status",
+			change: func(cfg *config.Config) { cfg.Preferences.ShowGitStatus = false },
+			rescan: true,
+		},
+		{
+			name:    "invalid",
+			change:  func(cfg *config.Config) { cfg.Preferences.GitStatusStyle = "fancy" },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+			t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+			t.Setenv("XDG_STATE_HOME", filepath.Join(tmpDir, "state"))
+			t.Setenv("DEN_CONFIG", "")
+
+			cfg := validSettings()
+			cfg.ProjectDirs = []string{projectDir}
+			m := Model{
+				Config: cfg,
+				List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+				Status: NewStatusBar(),
+			}
+			model, _ := m.openSettings()
+			m = model.(Model)
+			tt.change(m.Overlay.(*SettingsState).Draft)
+
+			model, _ = m.saveSettings()
+			m = model.(Model)
+			if tt.wantErr {
+				if st, ok := m.Overlay.(*SettingsState); !ok || st.Err == nil {
+					t.Fatal("expected the settings to stay open with an error")
+				}
+				path, _ := config.GetConfigPath()
+				if _, err := os.Stat(path); err == nil {
+					t.Error("expected an invalid draft not to be saved")
+				}
+				return
+			}
+			if st, ok := m.Overlay.(*SettingsState); ok {
+				t.Fatalf("expected the settings to close, got error %v", st.Err)
+			}
+			if m.Refreshing != tt.rescan {
+				t.Errorf("expected rescan %t, got %t", tt.rescan, m.Refreshing)
+			}
+
+			saved, err := config.LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, saved)
+				tt.check(t, m.Config)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Severity ranks status messages
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeveritySuccess
+	SeverityWarning
+	SeverityError
+)
+
+// statusTimeouts is how long a message stays in the status bar, errors
+// stay longest
+var statusTimeouts = map[Severity]time.Duration{
+	SeverityInfo:    4 * time.Second,
+	SeveritySuccess: 4 * time.Second,
+	SeverityWarning: 8 * time.Second,
+	SeverityError:   15 * time.Second,
+}
+
+// statusHistoryLimit bounds the message history
+const statusHistoryLimit = 100
+
+// StatusMessage is a message shown in the status bar
+type StatusMessage struct {
+	Text     string
+	Severity Severity
+	Time     time.Time
+	id       int
+}
+
+// StatusBar holds the message shown at the bottom of the screen and the
+// messages shown before it. It is shared by all copies of the model, so
+// handlers can report from value receivers.
+type StatusBar struct {
+	// Current is the message shown, nil once it was dismissed
+	Current *StatusMessage
+	// History holds the recent messages, oldest first
+	History []StatusMessage
+	nextID  int
+	// scheduled is the id of the latest message with a dismissal tick
+	scheduled int
+}
+
+// StatusDismissMsg clears a message once its time is up
+type StatusDismissMsg struct {
+	id int
+}
+
+// NewStatusBar returns an empty status bar
+func NewStatusBar() *StatusBar {
+	return &StatusBar{}
+}
+
+// Infof shows an informational message
+func (b *StatusBar) Infof(format string, args ...any) {
+	b.push(SeverityInfo, fmt.Sprintf(format, args...))
+}
+
+// Successf shows that something worked
+func (b *StatusBar) Successf(format string, args ...any) {
+	b.push(SeveritySuccess, fmt.Sprintf(format, args...))
+}
+
+// Warnf shows something the user should know about
+func (b *StatusBar) Warnf(format string, args ...any) {
+	b.push(SeverityWarning, fmt.Sprintf(format, args...))
+}
+
+// Errorf shows that something failed
+func (b *StatusBar) Errorf(format string, args ...any) {
+	b.push(SeverityError, fmt.Sprintf(format, args...))
+}
+
+func (b *StatusBar) push(severity Severity, text string) {
+	b.nextID++
+	msg := StatusMessage{Text: text, Severity: severity, Time: time.Now(), id: b.nextID}
+	b.Current = &msg
+	b.History = append(b.History, msg)
+	if len(b.History) > statusHistoryLimit {
+		b.History = b.History[len(b.History)-statusHistoryLimit:]
+	}
+}
+
+// Text returns the message shown, or ""
+func (b *StatusBar) Text() string {
+	if b.Current == nil {
+		return ""
+	}
+	return b.Current.Text
+}
+
+// schedule returns the dismissal tick of a message shown since the last
+// call, if any
+func (b *StatusBar) schedule() tea.Cmd {
+	msg := b.Current
+	if msg == nil || msg.id == b.scheduled {
+		return nil
+	}
+	b.scheduled = msg.id
+	return tea.Tick(statusTimeouts[msg.Severity], func(time.Time) tea.Msg {
+		return StatusDismissMsg{id: msg.id}
+	})
+}
+
+// dismiss clears the message shown, unless a newer one replaced it
+func (b *StatusBar) dismiss(id int) {
+	if b.Current != nil && b.Current.id == id {
+		b.Current = nil
+	}
+}
+
+// statusIcons mark the severity of a message
+var statusIcons = map[Severity]string{
+	SeverityInfo:    "•",
+	SeveritySuccess: "✓",
+	SeverityWarning: "!",
+	SeverityError:   "✗",
+}
+
+// renderStatusMessage renders a message in the style of its severity
+func (m Model) renderStatusMessage(msg StatusMessage) string {
+	style := m.Styles.Placeholder
+	switch msg.Severity {
+	case SeveritySuccess:
+		style = m.Styles.Success
+	case SeverityWarning:
+		style = m.Styles.Warning
+	case SeverityError:
+		style = m.Styles.Error
+	}
+	return style.Render(statusIcons[msg.Severity] + " " + msg.Text)
+}
+
+// renderStatusBar shows the current message on the left, and the project
+// counts and the age of the cache on the right
+func (m Model) renderStatusBar() string {
+	var left string
+	if msg := m.Status.Current; msg != nil {
+		left = m.renderStatusMessage(*msg)
+	}
+
+	info := []string{fmt.Sprintf("%d projects", len(m.Projects))}
+	if m.List.IsFiltered() || m.ShowFavoritesOnly {
+		info = append(info, fmt.Sprintf("%d shown", len(m.List.VisibleItems())))
+	}
+	if n := len(m.selectedProjects()); n > 0 {
+		info = append(info, fmt.Sprintf("%d selected", n))
+	}
+	if refresh := m.refreshStatus(); refresh != "" {
+		info = append(info, refresh)
+	}
+	right := m.Styles.Placeholder.Render(strings.Join(info, " · "))
+
+	width := m.List.Width() - 4
+	gap := width - lipgloss.Width(left) - lipgloss.Width(right)
+	if gap < 2 {
+		// Too narrow for both, the message matters more
+		if left != "" {
+			return left
+		}
+		return right
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// HistoryState tracks the message history view
+type HistoryState struct {
+	// Offset is the number of newest messages scrolled past
+	Offset int
+}
+
+func (st *HistoryState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleHistoryUpdate(msg)
+}
+
+func (st *HistoryState) view(m Model) string {
+	return m.renderHistoryView()
+}
+
+func (m Model) handleHistoryUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*HistoryState)
+	switch {
+	case key.Matches(msg, m.KeyMap.Up):
+		if st.Offset > 0 {
+			st.Offset--
+		}
+	case key.Matches(msg, m.KeyMap.Down):
+		if st.Offset < len(m.Status.History)-1 {
+			st.Offset++
+		}
+	case key.Matches(msg, m.KeyMap.Escape), key.Matches(msg, m.KeyMap.History), msg.String() == "q":
+		m.Overlay = nil
+	}
+	return m, nil
+}
+
+func (m Model) renderHistoryView() string {
+	st := m.Overlay.(*HistoryState)
+	var s strings.Builder
+
+	s.WriteString(m.renderGradientHeader("Messages"))
+	s.WriteString("\n\n")
+
+	history := m.Status.History
+	if len(history) == 0 {
+		s.WriteString(m.Styles.Placeholder.Render("No messages yet") + "\n")
+	}
+	// Newest first, keeping room for the header and the help line
+	height := len(history)
+	if m.Height > 10 {
+		height = m.Height - 10
+	}
+	for i := len(history) - 1 - st.Offset; i >= 0 && height > 0; i-- {
+		msg := history[i]
+		s.WriteString(m.Styles.Placeholder.Render(msg.Time.Format("15:04:05")) + "  " + m.renderStatusMessage(msg) + "\n")
+		height--
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render(helpLine(m.KeyMap.Up, m.KeyMap.Down, withDesc(m.KeyMap.Escape, "back"))))
+
+	return m.centerLines(s.String())
+}
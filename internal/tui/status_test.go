@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"den/internal/config"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestStatusSeverity(t *testing.T) {
+	b := NewStatusBar()
+	tests := []struct {
+		show func(format string, args ...any)
+		want Severity
+	}{
+		{b.Infof, SeverityInfo},
+		{b.Successf, SeveritySuccess},
+		{b.Warnf, SeverityWarning},
+		{b.Errorf, SeverityError},
+	}
+	for _, tt := range tests {
+		tt.show("message %d", tt.want)
+		if b.Current == nil || b.Current.Severity != tt.want {
+			t.Fatalf("expected severity %d, got %+v", tt.want, b.Current)
+		}
+		if b.Text() != fmt.Sprintf("message %d", tt.want) {
+			t.Errorf("expected the message to be shown, got %q", b.Text())
+		}
+	}
+
+	// More serious messages stay at least as long
+	for s := SeverityInfo; s < SeverityError; s++ {
+		if statusTimeouts[s] > statusTimeouts[s+1] {
+			t.Errorf("severity %d stays longer than %d: %v > %v", s, s+1, statusTimeouts[s], statusTimeouts[s+1])
+		}
+	}
+	if statusTimeouts[SeverityError] <= statusTimeouts[SeverityInfo] {
+		t.Error("expected errors to stay longer than information")
+	}
+}
+
+func TestStatusDismiss(t *testing.T) {
+	saved := statusTimeouts
+	defer func() { statusTimeouts = saved }()
+	statusTimeouts = map[Severity]time.Duration{
+		SeverityInfo:    time.Millisecond,
+		SeveritySuccess: time.Millisecond,
+		SeverityWarning: time.Millisecond,
+		SeverityError:   time.Millisecond,
+	}
+
+	m := Model{
+		Config: config.DefaultConfig(),
+		List:   list.New(nil, list.NewDefaultDelegate(), 80, 40),
+		Status: NewStatusBar(),
+	}
+
+	m.Status.Infof("saved")
+	tick := m.Status.schedule()
+	if tick == nil {
+		t.Fatal("expected a dismissal tick for a new message")
+	}
+	if m.Status.schedule() != nil {
+		t.Error("expected one tick per message")
+	}
+
+	// A stale tick leaves a newer message alone
+	m.Status.Errorf("failed")
+	newer := m.Status.schedule()
+	model, _ := m.Update(tick())
+	m = model.(Model)
+	if m.Status.Text() != "failed" {
+		t.Errorf("expected the newer message to stay, got %q", m.Status.Text())
+	}
+
+	model, _ = m.Update(newer())
+	m = model.(Model)
+	if m.Status.Current != nil {
+		t.Errorf("expected the message to be dismissed, got %q", m.Status.Text())
+	}
+	if len(m.Status.History) != 2 {
+		t.Errorf("expected dismissed messages to stay in the history, got %d", len(m.Status.History))
+	}
+}
+
+func TestStatusHistoryLimit(t *testing.T) {
+	b := NewStatusBar()
+	for i := 0; i < statusHistoryLimit+5; i++ {
+		b.Infof("message %d", i)
+	}
+	if len(b.History) != statusHistoryLimit {
+		t.Fatalf("expected %d messages, got %d", statusHistoryLimit, len(b.History))
+	}
+	if first := b.History[0].Text; first != "message 5" {
+		t.Errorf("expected the oldest messages to go, got %q first", first)
+	}
+	if last := b.History[len(b.History)-1].Text; last != fmt.Sprintf("message %d", statusHistoryLimit+4) {
+		t.Errorf("expected the newest message last, got %q", last)
+	}
+}
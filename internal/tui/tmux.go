@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"den/internal/project"
+	"den/internal/tmux"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TmuxDetachedMsg is sent when den resumes after the user detached from a
+// project's tmux session
+type TmuxDetachedMsg struct {
+	Path string
+	Err  error
+}
+
+// openInTmux creates or reuses the project's tmux session and shows it.
+// Inside tmux the client switches to it and den keeps running in its own
+// window; outside, den attaches and resumes once the user detaches.
+func (m Model) openInTmux(p project.Project) (tea.Model, tea.Cmd) {
+	if !tmux.Available() {
+		m.Status.Warnf("tmux is not installed")
+		return m, nil
+	}
+	cmd, err := tmux.Server{}.Open(p.Name, p.Path, m.Config)
+	if m.askTrust(err, func(m Model) (tea.Model, tea.Cmd) { return m.openInTmux(p) }) {
+		return m, nil
+	}
+	if err != nil {
+		m.Status.Errorf("Error opening tmux session: %v", err)
+		return m, nil
+	}
+	visit := recordVisits(m.Config.ActiveProfile(), p.Path)
+	if tmux.Inside() {
+		if out, err := cmd.CombinedOutput(); err != nil {
+			m.Status.Errorf("Error switching to tmux session: %v %s", err, out)
+		} else {
+			m.Status.Successf("Switched to tmux session %s", tmux.SessionName(p.Name, p.Path))
+		}
+		return m, visit
+	}
+	return m, tea.Batch(visit, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return TmuxDetachedMsg{Path: p.Path, Err: err}
+	}))
+}
+
+func (m Model) handleTmuxDetached(msg TmuxDetachedMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.Status.Errorf("tmux exited with an error: %v", msg.Err)
+	}
+	// Work in the session probably changed the git status
+	return m, detectProject(msg.Path, m.Config.Clone())
+}
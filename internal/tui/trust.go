@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"den/internal/config"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TrustState asks the user to trust a project's ProjectFile before den
+// runs anything from it
+type TrustState struct {
+	File *config.UntrustedError
+	// Retry runs the action that needed the file again once it is trusted
+	Retry func(m Model) (tea.Model, tea.Cmd)
+	// back is the view the prompt opened over, if any
+	back Overlay
+}
+
+func (st *TrustState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleTrustUpdate(msg)
+}
+
+func (st *TrustState) view(m Model) string {
+	return m.renderTrustView()
+}
+
+// trustKeys are the keys of the trust prompt
+var trustKeys = struct {
+	Trust key.Binding
+}{
+	Trust: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "trust the file")),
+}
+
+// askTrust opens the trust prompt when err is about an untrusted project
+// file, and reports whether it did. retry runs once the file is trusted.
+func (m *Model) askTrust(err error, retry func(m Model) (tea.Model, tea.Cmd)) bool {
+	var untrusted *config.UntrustedError
+	if !errors.As(err, &untrusted) {
+		return false
+	}
+	m.Overlay = &TrustState{File: untrusted, Retry: retry, back: m.Overlay}
+	return true
+}
+
+func (m Model) handleTrustUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*TrustState)
+	m.Overlay = st.back
+	if !key.Matches(msg, trustKeys.Trust) {
+		m.Status.Infof("Did not trust %s", st.File.Path)
+		return m, nil
+	}
+	// Trust what was shown, a file that changed since stays untrusted
+	if err := config.TrustProjectFile(st.File.Dir, st.File.Data); err != nil {
+		m.Status.Errorf("Error trusting %s: %v", st.File.Path, err)
+		return m, nil
+	}
+	m.Status.Successf("Trusted %s", st.File.Path)
+	return st.Retry(m)
+}
+
+func (m Model) renderTrustView() string {
+	st := m.Overlay.(*TrustState)
+	var s strings.Builder
+
+	title := "Trust " + config.ProjectFile + "?"
+	if st.File.Changed {
+		title = config.ProjectFile + " changed"
+	}
+	s.WriteString(m.renderGradientHeader(title))
+	s.WriteString("\n\n")
+	s.WriteString(m.Styles.Warning.Render(fmt.Sprintf("%s can run commands in tmux panes, launch steps and custom commands.", st.File.Path)) + "\n")
+	s.WriteString(m.Styles.RegularItem.Render("Only trust it if you know what it does.") + "\n\n")
+
+	// Keep room for the header, the warning and the help line
+	lines := strings.Split(strings.TrimRight(string(st.File.Data), "\n"), "\n")
+	height := len(lines)
+	if m.Height > 14 && height > m.Height-14 {
+		height = m.Height - 14
+	}
+	for _, line := range lines[:height] {
+		s.WriteString(m.Styles.Placeholder.Render("  "+line) + "\n")
+	}
+	if height < len(lines) {
+		s.WriteString(m.Styles.Placeholder.Render(fmt.Sprintf("  … %d more lines, see the file", len(lines)-height)) + "\n")
+	}
+
+	s.WriteString("\n" + m.Styles.RegularItem.Render(helpLine(withDesc(trustKeys.Trust, "trust and continue"))+" • any other key: cancel"))
+
+	return m.centerLines(s.String())
+}
@@ -1,24 +1,22 @@
 package tui
 
 import (
-	"den/internal/cache"
 	"den/internal/config"
-	"den/internal/editor"
 	"den/internal/project"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	if m.AddingDir {
+	if _, ok := m.Overlay.(*AddDirState); ok {
 		// Show initial suggestions
 		suggestions := getPathSuggestions("", m.Config)
 		m.TabState = &TabCompletionState{
@@ -28,11 +26,23 @@ func (m Model) Init() tea.Cmd {
 			PageSize:    DefaultPageSize,
 		}
 	}
-	return nil
+	// Cached projects are shown right away, refresh them in the background
+	// and keep them up to date as files change
+	m.syncWatcher()
+	return tea.Batch(requestRefresh, waitForChanges(m.Watcher), waitForConfigChange(m.ConfigWatcher), m.loadProcesses(true))
 }
 
 // Update handles all state updates
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	// Messages shown by the handlers go away on their own
+	if dismiss := m.Status.schedule(); dismiss != nil {
+		cmd = tea.Batch(cmd, dismiss)
+	}
+	return model, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
@@ -40,8 +50,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Width = msg.Width
 		m.Height = msg.Height
 
-		// Update list dimensions - subtract 7 lines for gradient header (3 lines) + spacing (2 lines) + top margin (2 lines)
-		m.List.SetSize(msg.Width, msg.Height-7)
+		// Update list dimensions - subtract 8 lines for gradient header (3 lines) + spacing (2 lines) + top margin (2 lines) + refresh status (1 line)
+		m.List.SetSize(msg.Width, msg.Height-8)
 
 		// Update instruction width based on window size
 		m.Styles.Instruction = m.Styles.Instruction.Copy().
@@ -54,102 +64,148 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 
 	case ProjectsLoadedMsg:
-		items := make([]list.Item, len(msg))
-		for i, p := range msg {
-			items[i] = ListItem{Project: p}
+		m.Projects = msg
+		return m, m.refreshListItems()
+
+	case RefreshMsg:
+		return m, m.startRefresh()
+
+	case ProjectsUpdatedMsg:
+		return m.handleProjectsUpdated(msg)
+
+	case RefreshDoneMsg:
+		return m.handleRefreshDone(msg)
+
+	case RecentCommandsMsg:
+		return m.handleRecentCommands(msg)
+
+	case CacheSavedMsg:
+		if msg.Err != nil {
+			m.Status.Errorf("Error saving cache: %v", msg.Err)
 		}
-		m.List.SetItems(items)
-		return m, cmd
+		return m, nil
 
-	case tea.KeyMsg:
-		// First check if the list wants to handle this key message
-		if !m.ShowContext && !m.AddingDir && !m.InputMode {
-			// Always let the list handle filtering keys
-			if m.List.FilterState() == list.Filtering {
-				var cmd tea.Cmd
-				m.List, cmd = m.List.Update(msg)
-				return m, cmd
-			}
+	case EditorClosedMsg:
+		return m.handleEditorClosed(msg)
 
-			// Check for filter trigger
-			if key.Matches(msg, m.List.KeyMap.Filter) {
-				var cmd tea.Cmd
-				m.List, cmd = m.List.Update(msg)
-				return m, cmd
-			}
+	case LaunchDoneMsg:
+		return m.handleLaunchDone(msg)
+
+	case TmuxDetachedMsg:
+		return m.handleTmuxDetached(msg)
+
+	case ProcessesMsg:
+		return m.handleProcesses(msg)
+
+	case ProcessActionMsg:
+		return m.handleProcessAction(msg)
+
+	case LogTickMsg:
+		return m.handleLogTick(msg)
+
+	case CommandDoneMsg:
+		return m.handleCommandDone(msg)
+
+	case CopyItemsMsg:
+		return m.handleCopyItems(msg)
+
+	case CopiedMsg:
+		return m.handleCopied(msg)
+
+	case BulkEventMsg:
+		return m.handleBulkEvent(msg)
+
+	case StatusDismissMsg:
+		m.Status.dismiss(msg.id)
+		return m, nil
+
+	case ProjectDetectedMsg:
+		return m.handleProjectDetected(msg)
+
+	case ConfigChangedMsg:
+		return m.handleConfigChanged()
+
+	case FilesChangedMsg:
+		return m.handleFilesChanged()
+
+	case spinner.TickMsg:
+		if m.Refreshing {
+			m.Spinner, cmd = m.Spinner.Update(msg)
 		}
+		return m, cmd
 
-		// Handle directory addition mode
-		if m.AddingDir {
-			return m.handleAddingDirUpdate(msg)
+	case tea.KeyMsg:
+		// The open overlay gets the keys
+		if m.Overlay != nil {
+			return m.Overlay.update(m, msg)
 		}
 
-		// Handle context menu if it's shown
-		if m.ShowContext {
-			return m.handleContextMenuUpdate(msg)
+		// Always let the list handle filtering keys
+		if m.List.FilterState() == list.Filtering {
+			var cmd tea.Cmd
+			m.List, cmd = m.List.Update(msg)
+			return m, cmd
 		}
 
-		// Handle input mode
-		if m.InputMode {
-			return m.handleInputModeUpdate(msg)
+		// Check for filter trigger
+		if key.Matches(msg, m.List.KeyMap.Filter) {
+			var cmd tea.Cmd
+			m.List, cmd = m.List.Update(msg)
+			return m, cmd
 		}
 
 		// Handle normal mode key presses
 		switch {
 		case key.Matches(msg, m.KeyMap.AddDirectory):
-			if !m.ShowContext && !m.InputMode {
-				m.AddingDir = true
-				m.Input = ""
-				// Show initial suggestions
-				suggestions := getPathSuggestions("", m.Config)
-				m.TabState = &TabCompletionState{
-					Suggestions: suggestions,
-					Index:       0,
-					Page:        0,
-					PageSize:    DefaultPageSize,
-				}
-				return m, nil
-			}
+			return m.openAddDir()
 		case key.Matches(msg, m.KeyMap.ShowContext):
-			if !m.ShowContext && !m.InputMode && m.List.FilterState() != list.Filtering {
-				m.ShowContext = true
-				m.ContextCursor = 0
-				return m, nil
+			// With projects selected the menu acts on all of them
+			if len(m.selectedProjects()) > 0 {
+				return m.openBulkMenu()
 			}
+			m.Overlay = &ContextMenuState{}
+			return m, nil
 		case key.Matches(msg, m.KeyMap.OpenConfig):
-			if !m.ShowContext && !m.InputMode && !m.AddingDir {
-				configPath, err := config.GetConfigPath()
-				if err != nil {
-					m.Status = fmt.Sprintf("Error getting config path: %v", err)
-					return m, nil
-				}
-				if err := editor.OpenInEditor(configPath, m.Config); err != nil {
-					m.Status = fmt.Sprintf("Error opening config: %v", err)
-					return m, nil
-				}
-				return m, tea.Quit
+			return m.openSettings()
+		case key.Matches(msg, m.KeyMap.ManageDirs):
+			return m.openDirs(false)
+		case key.Matches(msg, m.KeyMap.SwitchProfile):
+			return m.openProfiles()
+		case key.Matches(msg, m.KeyMap.History):
+			m.Overlay = &HistoryState{}
+			return m, nil
+		case key.Matches(msg, m.KeyMap.Help):
+			m.Overlay = &HelpState{}
+			return m, nil
+		case key.Matches(msg, m.KeyMap.Palette):
+			return m.openPalette()
+		case key.Matches(msg, m.KeyMap.OpenTerminal):
+			if i, ok := m.List.SelectedItem().(ListItem); ok {
+				return m.openInTerminal(i.Project)
 			}
-		case msg.String() == "F":
-			m.ShowFavoritesOnly = !m.ShowFavoritesOnly
-			if m.ShowFavoritesOnly {
-				// Filter to show only favorites
-				items := m.List.Items()
-				favoriteItems := make([]list.Item, 0)
-				for _, item := range items {
-					if listItem, ok := item.(ListItem); ok && listItem.Project.Favorite {
-						favoriteItems = append(favoriteItems, item)
-					}
-				}
-				m.List.SetItems(favoriteItems)
-			} else {
-				// Restore all items
-				items := make([]list.Item, len(m.Projects))
-				for i, p := range m.Projects {
-					items[i] = ListItem{Project: p}
+			return m, nil
+		case key.Matches(msg, m.KeyMap.ToggleFavorite):
+			if i, ok := m.List.SelectedItem().(ListItem); ok {
+				cmd, err := m.toggleFavorite(i.Project)
+				if err != nil {
+					m.Status.Errorf("%v", err)
 				}
-				m.List.SetItems(items)
+				return m, cmd
 			}
 			return m, nil
+		case key.Matches(msg, m.KeyMap.FilterFavorites):
+			return m.toggleFavoritesOnly()
+		case key.Matches(msg, m.KeyMap.Select):
+			return m.toggleSelected()
+		case key.Matches(msg, m.KeyMap.SelectRange):
+			return m.selectRange()
+		case key.Matches(msg, m.KeyMap.SelectAll):
+			return m.selectAll()
+		case key.Matches(msg, m.KeyMap.Escape):
+			// Esc clears an applied filter first
+			if len(m.Selected) > 0 && !m.filterApplied() {
+				return m.clearSelection()
+			}
 		}
 
 		// Let the list handle all other keys
@@ -161,17 +217,67 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Make sure to pass all other messages to the list
 		var cmd tea.Cmd
 		m.List, cmd = m.List.Update(msg)
+		if _, ok := msg.(list.FilterMatchesMsg); ok && m.reselect != "" {
+			// Items changed under an applied filter, select the same project
+			m.selectProject(m.reselect)
+			m.reselect = ""
+		}
 		return m, cmd
 	}
 }
 
+// openAddDir shows the prompt for a new project directory
+func (m Model) openAddDir() (tea.Model, tea.Cmd) {
+	m.Overlay = &AddDirState{}
+	m.Input = ""
+	// Show initial suggestions
+	suggestions := getPathSuggestions("", m.Config)
+	m.TabState = &TabCompletionState{
+		Suggestions: suggestions,
+		Index:       0,
+		Page:        0,
+		PageSize:    DefaultPageSize,
+	}
+	return m, nil
+}
+
+// toggleFavoritesOnly switches between all projects and the favorites
+func (m Model) toggleFavoritesOnly() (tea.Model, tea.Cmd) {
+	m.ShowFavoritesOnly = !m.ShowFavoritesOnly
+	// Rebuilding the items keeps the selection and the running processes
+	return m, m.refreshListItems()
+}
+
+// addDirKeys are the keys of the add directory prompt
+var addDirKeys = struct {
+	Confirm, Complete, Pick, Page, Cancel key.Binding
+}{
+	Confirm:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+	Complete: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete")),
+	Pick:     key.NewBinding(key.WithKeys("up", "down"), key.WithHelp("↑/↓", "navigate")),
+	Page:     key.NewBinding(key.WithKeys("left", "right"), key.WithHelp("←/→", "more")),
+	Cancel:   key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+func (st *AddDirState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleAddingDirUpdate(msg)
+}
+
+func (st *AddDirState) view(m Model) string {
+	return m.renderAddingDirView()
+}
+
 func (m Model) handleAddingDirUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*AddDirState)
 	switch msg.Type {
 	case tea.KeyEnter:
 		return m.handleNewDirectoryConfirmation()
 
 	case tea.KeyEsc:
-		m.AddingDir = false
+		if st.Required && len(m.Config.ProjectDirs) == 0 {
+			return m, tea.Quit
+		}
+		m.Overlay = nil
 		m.Input = ""
 		m.TabState = nil
 		m.Err = nil
@@ -292,7 +398,7 @@ func (m Model) handleAddingDirUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		default:
-			if !m.ShowContext && msg.Type == tea.KeyRunes {
+			if msg.Type == tea.KeyRunes {
 				m.Input += string(msg.Runes)
 				// Get suggestions immediately when typing
 				suggestions := getPathSuggestions(m.Input, m.Config)
@@ -308,160 +414,90 @@ func (m Model) handleAddingDirUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
-func (m Model) handleInputModeUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyTab:
-		if m.TabState == nil {
-			suggestions := getPathSuggestions(m.Input, m.Config)
-			if len(suggestions) > 0 {
-				m.TabState = &TabCompletionState{
-					Suggestions: suggestions,
-					Index:       0,
-				}
-				m.Input = m.TabState.Suggestions[0]
-			}
-		} else {
-			m.TabState.Index = (m.TabState.Index + 1) % len(m.TabState.Suggestions)
-			m.Input = m.TabState.Suggestions[m.TabState.Index]
-		}
-		return m, nil
-
-	case tea.KeyEnter:
-		return m.handleNewDirectoryConfirmation()
-
-	case tea.KeyEsc:
-		if len(m.Config.ProjectDirs) > 0 {
-			m.InputMode = false
-			return m, nil
-		}
-		return m, tea.Quit
-
-	case tea.KeyBackspace, tea.KeyDelete:
-		m.TabState = nil
-		if len(m.Input) > 0 {
-			m.Input = m.Input[:len(m.Input)-1]
-		}
-		m.Err = nil
-		return m, nil
+func (st *ContextMenuState) update(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.handleContextMenuUpdate(msg)
+}
 
-	default:
-		if msg.Type == tea.KeyRunes {
-			m.Input += string(msg.Runes)
-			// Get suggestions immediately when typing
-			suggestions := getPathSuggestions(m.Input, m.Config)
-			if len(suggestions) > 0 {
-				m.TabState = &TabCompletionState{
-					Suggestions: suggestions,
-					Index:       0,
-					Page:        0,
-					PageSize:    DefaultPageSize,
-				}
-			} else {
-				m.TabState = nil
-			}
-			m.Err = nil
-		}
-		return m, nil
-	}
+func (st *ContextMenuState) view(m Model) string {
+	return m.renderContextView()
 }
 
 func (m Model) handleContextMenuUpdate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.Overlay.(*ContextMenuState)
 	switch {
 	case key.Matches(msg, m.KeyMap.Up):
-		m.ContextCursor--
-		if m.ContextCursor < 0 {
-			m.ContextCursor = len(ContextOptions) - 1
+		st.Cursor--
+		if st.Cursor < 0 {
+			st.Cursor = len(contextActions()) - 1
 		}
 		return m, nil
 	case key.Matches(msg, m.KeyMap.Down):
-		m.ContextCursor = (m.ContextCursor + 1) % len(ContextOptions)
+		st.Cursor = (st.Cursor + 1) % len(contextActions())
 		return m, nil
 	case key.Matches(msg, m.KeyMap.Enter):
 		return m.handleContextMenuSelection()
 	case key.Matches(msg, m.KeyMap.Escape):
-		m.ShowContext = false
+		m.Overlay = nil
+		return m, nil
+	case key.Matches(msg, m.KeyMap.Help):
+		// Closing the help goes back to the menu
+		m.Overlay = &HelpState{back: st}
 		return m, nil
 	}
 	return m, nil
 }
 
 func (m Model) handleContextMenuSelection() (tea.Model, tea.Cmd) {
-	if i, ok := m.List.SelectedItem().(ListItem); ok {
-		switch m.ContextCursor {
-		case 0: // Open in Editor
-			if err := editor.OpenInEditor(i.Project.Path, m.Config); err != nil {
-				m.Status = fmt.Sprintf("Error opening editor: %v", err)
-			}
-			return m, tea.Quit
-
-		case 1: // Open in File Explorer
-			if err := editor.OpenInFileExplorer(i.Project.Path, m.Config); err != nil {
-				m.Status = fmt.Sprintf("Error opening file explorer: %v", err)
-			}
-			return m, tea.Quit
-
-		case 2: // Copy Path
-			if err := CopyToClipboard(i.Project.Path); err != nil {
-				m.Status = fmt.Sprintf("Error copying to clipboard: %v", err)
-			} else {
-				m.Status = "Path copied to clipboard"
-			}
-			m.ShowContext = false
+	st := m.Overlay.(*ContextMenuState)
+	m.Overlay = nil
+	action := contextActions()[st.Cursor]
+	if i, ok := m.List.SelectedItem().(ListItem); ok && action.Run != nil {
+		return action.Run(m, i.Project)
+	}
+	return m, nil
+}
 
-		case 3: // Toggle Favorite
-			// Toggle favorite status
-			i.Project.Favorite = !i.Project.Favorite
+// toggleFavorite flips the favorite status of a project and persists it
+func (m *Model) toggleFavorite(p project.Project) (tea.Cmd, error) {
+	p.Favorite = !p.Favorite
 
-			// Update favorites in config
-			if i.Project.Favorite {
-				m.Config.Favorites = append(m.Config.Favorites, i.Project.Path)
-			} else {
-				// Remove from favorites
-				favorites := make([]string, 0)
-				for _, fav := range m.Config.Favorites {
-					if fav != i.Project.Path {
-						favorites = append(favorites, fav)
-					}
-				}
-				m.Config.Favorites = favorites
+	// Update favorites in config
+	if p.Favorite {
+		m.Config.Favorites = append(m.Config.Favorites, p.Path)
+	} else {
+		// Remove from favorites
+		favorites := make([]string, 0)
+		for _, fav := range m.Config.Favorites {
+			if fav != p.Path {
+				favorites = append(favorites, fav)
 			}
+		}
+		m.Config.Favorites = favorites
+	}
 
-			// Save config
-			if err := config.SaveConfig(m.Config); err != nil {
-				m.Status = fmt.Sprintf("Error saving favorites: %v", err)
-				return m, nil
-			}
+	// Save config
+	if err := config.SaveConfig(m.Config); err != nil {
+		return nil, fmt.Errorf("error saving favorites: %v", err)
+	}
 
-			// Update project in the main list
-			for idx, proj := range m.Projects {
-				if proj.Path == i.Project.Path {
-					m.Projects[idx].Favorite = i.Project.Favorite
-					break
-				}
-			}
+	// Update project in the main list
+	for idx, proj := range m.Projects {
+		if proj.Path == p.Path {
+			m.Projects[idx].Favorite = p.Favorite
+			break
+		}
+	}
 
-			// Update cache
-			cache := &cache.ProjectCache{
-				Projects:    project.ConvertProjectsToCache(m.Projects),
-				LastUpdated: time.Now(),
-			}
-			if err := cache.SaveCache(); err != nil {
-				m.Status = fmt.Sprintf("Error saving cache: %v", err)
-				return m, nil
-			}
+	// Update cache
+	if err := m.saveCache(); err != nil {
+		return nil, fmt.Errorf("error saving cache: %v", err)
+	}
 
-			// Update list items
-			items := make([]list.Item, len(m.Projects))
-			for i, p := range m.Projects {
-				items[i] = ListItem{Project: p}
-			}
-			m.List.SetItems(items)
+	// Update list items
+	cmd := m.refreshListItems()
 
-			m.Status = "Favorite status updated"
-		}
-	}
-	m.ShowContext = false
-	return m, nil
+	m.Status.Successf("Favorite status updated")
+	return cmd, nil
 }
 
 func (m Model) handleNewDirectoryConfirmation() (tea.Model, tea.Cmd) {
@@ -496,8 +532,7 @@ func (m Model) handleNewDirectoryConfirmation() (tea.Model, tea.Cmd) {
 	}
 
 	// Reset state and prepare for project scanning
-	m.AddingDir = false
-	m.InputMode = false
+	m.Overlay = nil
 	m.Input = ""
 
 	// Reinitialize list key bindings
@@ -509,10 +544,8 @@ func (m Model) handleNewDirectoryConfirmation() (tea.Model, tea.Cmd) {
 	m.List.SetShowFilter(true)
 	m.List.SetShowHelp(true)
 
-	// Return command to scan for projects
-	return m, func() tea.Msg {
-		return ProjectsLoadedMsg(project.ScanForProjects(m.Config.ProjectDirs, m.Config))
-	}
+	// Scan the new directory in the background
+	return m, m.startRefresh()
 }
 
 func getPathSuggestions(partial string, cfg *config.Config) []string {
@@ -9,58 +9,11 @@ import (
 
 // View renders the current state of the model
 func (m Model) View() string {
-	if m.AddingDir {
-		return m.renderAddingDirView()
+	if m.Overlay != nil {
+		return m.Overlay.view(m)
 	}
 
-	if m.ShowContext {
-		return m.renderContextView()
-	}
-
-	// Create gradient/shadow header that fades from solid in middle to light on edges
-	titleText := m.Config.Preferences.ProjectListTitle
-	// Use full screen width if available, otherwise use title width
-	totalWidth := len(titleText) + 6
-	if m.Width > 0 {
-		totalWidth = m.Width
-	}
-
-	// Create gradient pattern: light -> medium -> dark -> solid -> dark -> medium -> light
-	var topBar, bottomBar string
-	shades := []string{"░", "▒", "▓", "█"}
-
-	for i := 0; i < totalWidth; i++ {
-		// Calculate distance from center
-		center := totalWidth / 2
-		distFromCenter := center - i
-		if distFromCenter < 0 {
-			distFromCenter = -distFromCenter
-		}
-
-		// Map distance to shade (closer to center = darker)
-		shadeIndex := 3 - (distFromCenter * 4 / (totalWidth / 2))
-		if shadeIndex < 0 {
-			shadeIndex = 0
-		}
-		if shadeIndex > 3 {
-			shadeIndex = 3
-		}
-
-		topBar += shades[shadeIndex]
-		bottomBar += shades[shadeIndex]
-	}
-
-	// Center the title text within the full width
-	centeredTitle := lipgloss.NewStyle().
-		Width(totalWidth).
-		Align(lipgloss.Center).
-		Render(titleText)
-
-	gradientHeader := m.Styles.ListTitle.Render(
-		topBar + "\n" +
-		centeredTitle + "\n" +
-		bottomBar,
-	)
+	gradientHeader := m.renderGradientHeader(m.listTitle())
 
 	listView := m.List.View()
 
@@ -79,18 +32,10 @@ func (m Model) View() string {
 		)
 	}
 
+	listView = lipgloss.JoinVertical(lipgloss.Left, listView, m.renderStatusBar())
+
 	// Center each line of the list individually
-	if m.Width > 0 {
-		lines := strings.Split(listView, "\n")
-		centeredLines := make([]string, len(lines))
-		for i, line := range lines {
-			centeredLines[i] = lipgloss.NewStyle().
-				Width(m.Width).
-				Align(lipgloss.Center).
-				Render(line)
-		}
-		listView = strings.Join(centeredLines, "\n")
-	}
+	listView = m.centerLines(listView)
 
 	view := "\n" + gradientHeader + "\n\n" + listView
 
@@ -144,31 +89,56 @@ func (m Model) renderAddingDirView() string {
 			Render(fmt.Sprintf("Error: %v", m.Err)))
 	}
 
-	s.WriteString("\n\n" + m.Styles.RegularItem.Render(
-		"Enter: confirm • Tab: complete • ↑/↓: navigate • ←/→: more • Esc: cancel",
-	))
-
-	view := s.String()
+	s.WriteString("\n\n" + m.Styles.RegularItem.Render(helpLine(
+		addDirKeys.Confirm, addDirKeys.Complete, addDirKeys.Pick, addDirKeys.Page, addDirKeys.Cancel,
+	)))
 
 	// Center each line individually
-	if m.Width > 0 {
-		lines := strings.Split(view, "\n")
-		centeredLines := make([]string, len(lines))
-		for i, line := range lines {
-			centeredLines[i] = lipgloss.NewStyle().
-				Width(m.Width).
-				Align(lipgloss.Center).
-				Render(line)
+	return m.centerLines(s.String())
+}
+
+func (m Model) renderContextView() string {
+	gradientHeader := m.renderGradientHeader(m.listTitle())
+
+	listView := m.List.View()
+
+	// Create horizontal menu
+	var menuItems []string
+	width := 0
+	maxWidth := m.List.Width() - 4 // Account for margins
+
+	st := m.Overlay.(*ContextMenuState)
+	for i, action := range contextActions() {
+		var item string
+		if i == st.Cursor {
+			item = m.Styles.SelectedMenuItem.Render(action.Name)
+		} else {
+			item = m.Styles.MenuItem.Render(action.Name)
+		}
+
+		// Check if adding this item would exceed available width
+		itemWidth := lipgloss.Width(item) + 1 // +1 for separator
+		if width+itemWidth > maxWidth {
+			break
 		}
-		view = strings.Join(centeredLines, "\n")
+
+		menuItems = append(menuItems, item)
+		width += itemWidth
 	}
 
-	return view
+	menu := m.Styles.Context.Render(strings.Join(menuItems, " • "))
+	hint := m.Styles.Placeholder.Render(helpLine(m.KeyMap.Up, m.KeyMap.Down, m.KeyMap.Enter, m.KeyMap.Escape, m.KeyMap.Help))
+	listView = listView + "\n" + menu + "\n" + hint + "\n" + m.renderStatusBar()
+
+	// Center each line of the list individually
+	listView = m.centerLines(listView)
+
+	return "\n" + gradientHeader + "\n\n" + listView
 }
 
-func (m Model) renderContextView() string {
-	// Create gradient/shadow header that fades from solid in middle to light on edges
-	titleText := m.Config.Preferences.ProjectListTitle
+// renderGradientHeader renders a title between gradient/shadow bars that fade
+// from solid in the middle to light on the edges
+func (m Model) renderGradientHeader(titleText string) string {
 	// Use full screen width if available, otherwise use title width
 	totalWidth := len(titleText) + 6
 	if m.Width > 0 {
@@ -208,50 +178,25 @@ func (m Model) renderContextView() string {
 
 	gradientHeader := m.Styles.ListTitle.Render(
 		topBar + "\n" +
-		centeredTitle + "\n" +
-		bottomBar,
+			centeredTitle + "\n" +
+			bottomBar,
 	)
 
-	listView := m.List.View()
-
-	// Create horizontal menu
-	var menuItems []string
-	width := 0
-	maxWidth := m.List.Width() - 4 // Account for margins
-
-	for i, opt := range ContextOptions {
-		item := opt
-		if i == m.ContextCursor {
-			item = m.Styles.SelectedMenuItem.Render(opt)
-		} else {
-			item = m.Styles.MenuItem.Render(opt)
-		}
-
-		// Check if adding this item would exceed available width
-		itemWidth := lipgloss.Width(item) + 1 // +1 for separator
-		if width+itemWidth > maxWidth {
-			break
-		}
+	return gradientHeader
+}
 
-		menuItems = append(menuItems, item)
-		width += itemWidth
+// centerLines centers each line of view within the window width
+func (m Model) centerLines(view string) string {
+	if m.Width == 0 {
+		return view
 	}
-
-	menu := m.Styles.Context.Render(strings.Join(menuItems, " • "))
-	listView = listView + "\n" + menu
-
-	// Center each line of the list individually
-	if m.Width > 0 {
-		lines := strings.Split(listView, "\n")
-		centeredLines := make([]string, len(lines))
-		for i, line := range lines {
-			centeredLines[i] = lipgloss.NewStyle().
-				Width(m.Width).
-				Align(lipgloss.Center).
-				Render(line)
-		}
-		listView = strings.Join(centeredLines, "\n")
+	lines := strings.Split(view, "\n")
+	centeredLines := make([]string, len(lines))
+	for i, line := range lines {
+		centeredLines[i] = lipgloss.NewStyle().
+			Width(m.Width).
+			Align(lipgloss.Center).
+			Render(line)
 	}
-
-	return "\n" + gradientHeader + "\n\n" + listView
+	return strings.Join(centeredLines, "\n")
 }
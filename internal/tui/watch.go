@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"den/internal/watch"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FilesChangedMsg reports that the watcher saw projects being added,
+// removed or changing their git state
+type FilesChangedMsg struct{}
+
+// waitForChanges delivers the next change seen by the watcher
+func waitForChanges(w *watch.Watcher) tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		<-w.Changes()
+		return FilesChangedMsg{}
+	}
+}
+
+// ConfigChangedMsg reports that the config file was written
+type ConfigChangedMsg struct{}
+
+// waitForConfigChange delivers the next write to the config file
+func waitForConfigChange(w *watch.File) tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		<-w.Changes()
+		return ConfigChangedMsg{}
+	}
+}
+
+// handleFilesChanged refreshes the projects, which only detects the ones
+// that changed, and waits for the next change
+func (m Model) handleFilesChanged() (tea.Model, tea.Cmd) {
+	return m, tea.Batch(m.startRefresh(), waitForChanges(m.Watcher))
+}
+
+// syncWatcher watches the configured directories and the loaded projects,
+// favorites first so that they are watched even when the limit is reached
+func (m Model) syncWatcher() {
+	if m.Watcher == nil {
+		return
+	}
+	paths := make([]string, 0, len(m.Projects))
+	for _, p := range m.Projects {
+		if p.Favorite {
+			paths = append(paths, p.Path)
+		}
+	}
+	for _, p := range m.Projects {
+		if !p.Favorite {
+			paths = append(paths, p.Path)
+		}
+	}
+	m.Watcher.Sync(m.Config.ProjectDirs, paths)
+}
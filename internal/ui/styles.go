@@ -20,6 +20,9 @@ type Styles struct {
 	SelectedMenuItem lipgloss.Style
 	Placeholder      lipgloss.Style
 	FavoriteIcon     lipgloss.Style
+	Error            lipgloss.Style
+	Warning          lipgloss.Style
+	Success          lipgloss.Style
 }
 
 // NewStyles creates a new Styles instance with the given theme
@@ -74,6 +77,15 @@ func NewStyles(activeTheme theme.Theme) *Styles {
 		FavoriteIcon: lipgloss.NewStyle().
 			Foreground(activeTheme.Primary).
 			SetString("★ "),
+
+		Error: lipgloss.NewStyle().
+			Foreground(activeTheme.Error),
+
+		Warning: lipgloss.NewStyle().
+			Foreground(activeTheme.Warning),
+
+		Success: lipgloss.NewStyle().
+			Foreground(activeTheme.Success),
 	}
 }
 
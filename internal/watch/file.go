@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"path/filepath"
+
+	"github.com/bep/debounce"
+	"github.com/fsnotify/fsnotify"
+)
+
+// File watches a single file, such as the config, and reports when it was
+// written. It watches the directory of the file, as many editors save by
+// replacing the file, which drops a watch on the file itself.
+type File struct {
+	fs      *fsnotify.Watcher
+	path    string
+	changes chan struct{}
+	notify  func(func())
+}
+
+// NewFile starts watching the file at path, which may not exist yet
+func NewFile(path string) (*File, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	if err := fs.Add(filepath.Dir(path)); err != nil {
+		fs.Close()
+		return nil, err
+	}
+	f := &File{
+		fs:      fs,
+		path:    path,
+		changes: make(chan struct{}, 1),
+		notify:  debounce.New(Delay),
+	}
+	go f.run()
+	return f, nil
+}
+
+// Changes receives a value after the file was written. Writes that happen
+// before the value is received are merged into it.
+func (f *File) Changes() <-chan struct{} {
+	return f.changes
+}
+
+// Close stops watching
+func (f *File) Close() error {
+	return f.fs.Close()
+}
+
+func (f *File) run() {
+	for {
+		select {
+		case event, ok := <-f.fs.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == f.path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				f.notify(f.send)
+			}
+		case _, ok := <-f.fs.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// send reports a change unless one is already waiting
+func (f *File) send() {
+	select {
+	case f.changes <- struct{}{}:
+	default:
+	}
+}
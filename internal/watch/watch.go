@@ -0,0 +1,195 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bep/debounce"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Delay is how long the watcher waits for a burst of changes to end, a
+// checkout or a clone touches many files
+const Delay = 300 * time.Millisecond
+
+// defaultLimit is the most watches a watcher adds
+const defaultLimit = 4096
+
+// kind is what a watched directory is
+type kind int
+
+const (
+	kindRoot kind = iota // a project directory, for added and removed projects
+	kindGit              // a project's .git, for HEAD and index
+	kindRefs             // a project's .git/refs/heads, for commits
+)
+
+// Watcher watches the project directories and the git state of each
+// project, and reports when they changed. It adds at most limit watches so
+// that huge trees can't exhaust the inotify watches of the user.
+type Watcher struct {
+	fs      *fsnotify.Watcher
+	limit   int
+	changes chan struct{}
+	notify  func(func())
+
+	mu      sync.Mutex
+	watched map[string]kind
+	skipped int
+}
+
+// DefaultLimit returns the number of watches to use: a quarter of the
+// user's inotify limit, leaving room for other programs, and at most 4096
+func DefaultLimit() int {
+	data, err := os.ReadFile("/proc/sys/fs/inotify/max_user_watches")
+	if err != nil {
+		return defaultLimit
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || max/4 > defaultLimit {
+		return defaultLimit
+	}
+	return max / 4
+}
+
+// New starts a watcher that adds at most limit watches
+func New(limit int) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:      fs,
+		limit:   limit,
+		changes: make(chan struct{}, 1),
+		notify:  debounce.New(Delay),
+		watched: make(map[string]kind),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Changes receives a value after a burst of changes. Changes that happen
+// before the value is received are merged into it.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// Skipped returns the number of projects that are not watched because of
+// the limit
+func (w *Watcher) Skipped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.skipped
+}
+
+// Sync watches roots and the git state of projects, and stops watching
+// everything else. Roots come first, then projects in the given order until
+// the limit is reached.
+func (w *Watcher) Sync(roots, projects []string) {
+	want := make(map[string]kind)
+	add := func(path string, k kind) {
+		if len(want) < w.limit {
+			want[filepath.Clean(path)] = k
+		}
+	}
+
+	for _, root := range roots {
+		add(root, kindRoot)
+	}
+	skipped := 0
+	for _, path := range projects {
+		gitDir := filepath.Join(path, ".git")
+		if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+			continue
+		}
+		if len(want)+2 > w.limit {
+			skipped++
+			continue
+		}
+		add(gitDir, kindGit)
+		add(filepath.Join(gitDir, "refs", "heads"), kindRefs)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.skipped = skipped
+	for path := range w.watched {
+		if _, ok := want[path]; !ok {
+			w.fs.Remove(path)
+			delete(w.watched, path)
+		}
+	}
+	for path, k := range want {
+		if _, ok := w.watched[path]; ok {
+			continue
+		}
+		// Directories may be gone by now, the next Sync tries again
+		if err := w.fs.Add(path); err == nil {
+			w.watched[path] = k
+		}
+	}
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if w.relevant(event) {
+				w.notify(w.send)
+			}
+		case _, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			// An overflow drops events, assume something changed
+			w.notify(w.send)
+		}
+	}
+}
+
+// send reports a change unless one is already waiting
+func (w *Watcher) send() {
+	select {
+	case w.changes <- struct{}{}:
+	default:
+	}
+}
+
+// relevant reports whether an event changes the project list or the git
+// state of a project
+func (w *Watcher) relevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Base(event.Name)
+
+	w.mu.Lock()
+	k, ok := w.watched[filepath.Dir(event.Name)]
+	w.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	switch k {
+	case kindRoot:
+		return !strings.HasPrefix(name, ".")
+	case kindGit:
+		return name == "HEAD" || name == "index"
+	case kindRefs:
+		return !strings.HasSuffix(name, ".lock")
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func makeRepo(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, ".git", "refs", "heads"), 0755); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+}
+
+func expectChange(t *testing.T, w *Watcher, what string) {
+	t.Helper()
+	select {
+	case <-w.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no change reported after %s", what)
+	}
+}
+
+func TestWatcherReportsChanges(t *testing.T) {
+	root := t.TempDir()
+	api := filepath.Join(root, "api")
+	makeRepo(t, api)
+
+	w, err := New(100)
+	if err != nil {
+		t.Skipf("watching is not available: %v", err)
+	}
+	defer w.Close()
+	w.Sync([]string{root}, []string{api})
+
+	if err := os.Mkdir(filepath.Join(root, "web"), 0755); err != nil {
+		t.Fatalf("Failed to create project: %v", err)
+	}
+	expectChange(t, w, "adding a project")
+
+	if err := os.WriteFile(filepath.Join(api, ".git", "refs", "heads", "main"), []byte("0000\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ref: %v", err)
+	}
+	expectChange(t, w, "a commit")
+
+	// Objects and other git files don't change the status shown
+	if err := os.WriteFile(filepath.Join(api, ".git", "FETCH_HEAD"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write FETCH_HEAD: %v", err)
+	}
+	select {
+	case <-w.Changes():
+		t.Error("expected FETCH_HEAD to be ignored")
+	case <-time.After(2 * Delay):
+	}
+}
+
+func TestWatcherIsBounded(t *testing.T) {
+	root := t.TempDir()
+	var projects []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		path := filepath.Join(root, name)
+		makeRepo(t, path)
+		projects = append(projects, path)
+	}
+
+	w, err := New(5)
+	if err != nil {
+		t.Skipf("watching is not available: %v", err)
+	}
+	defer w.Close()
+
+	// The root and two projects with two watches each fit
+	w.Sync([]string{root}, projects)
+	if len(w.watched) != 5 || w.Skipped() != 2 {
+		t.Errorf("expected 5 watches and 2 skipped projects, got %d and %d", len(w.watched), w.Skipped())
+	}
+
+	// Watches of projects that are gone are released
+	w.Sync([]string{root}, projects[:1])
+	if len(w.watched) != 3 || w.Skipped() != 0 {
+		t.Errorf("expected 3 watches, got %d", len(w.watched))
+	}
+}
+
+func TestFileReportsWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+
+	f, err := NewFile(path)
+	if err != nil {
+		t.Skipf("watching is not available: %v", err)
+	}
+	defer f.Close()
+
+	expect := func(what string) {
+		t.Helper()
+		select {
+		case <-f.Changes():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change reported after %s", what)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("version = 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	expect("creating the file")
+
+	// Editors often save by replacing the file
+	tmp := filepath.Join(dir, ".config.toml.swp")
+	if err := os.WriteFile(tmp, []byte("version = 2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write temporary file: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Failed to replace config: %v", err)
+	}
+	expect("replacing the file")
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.toml"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write other file: %v", err)
+	}
+	select {
+	case <-f.Changes():
+		t.Error("expected other files to be ignored")
+	case <-time.After(2 * Delay):
+	}
+}
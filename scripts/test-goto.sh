@@ -22,9 +22,36 @@ git init
 git add README.md
 git commit -m "Initial commit"
 
-# Create den config pointing to test directory
-mkdir -p ~/.config/den
-cat > ~/.config/den/config.json << EOL
+# Create a separate den config pointing to test directory so the user's
+# config is left alone
+TEST_CONFIG=$(mktemp -d)/config.toml
+export DEN_CONFIG="${TEST_CONFIG}"
+cat > "${TEST_CONFIG}" << EOL
+version = 2
+projectDirs = ["${TEST_DIR}"]
+
+[preferences]
+defaultEditor = "code"
+editorList = ["code", "vim", "nano"]
+showHiddenFiles = false
+showGitStatus = true
+gitStatusStyle = "text"
+theme = "default"
+projectListTitle = "Test Projects"
+EOL
+
+# Build den if needed
+cd -
+if [ ! -f "./den" ]; then
+    echo -e "${GREEN}Building den...${NC}"
+    go build
+fi
+
+# The config.json of early versions is converted to TOML when den loads it
+echo -e "\n${GREEN}Testing legacy config migration...${NC}"
+LEGACY_HOME=$(mktemp -d)
+mkdir -p "${LEGACY_HOME}/config/den"
+cat > "${LEGACY_HOME}/config/den/config.json" << EOL
 {
     "projectDirs": ["${TEST_DIR}"],
     "preferences": {
@@ -37,13 +64,19 @@ cat > ~/.config/den/config.json << EOL
     }
 }
 EOL
-
-# Build den if needed
-cd -
-if [ ! -f "./den" ]; then
-    echo -e "${GREEN}Building den...${NC}"
-    go build
+# Any command that loads the config migrates it; the project doesn't exist
+env -u DEN_CONFIG XDG_CONFIG_HOME="${LEGACY_HOME}/config" XDG_STATE_HOME="${LEGACY_HOME}/state" \
+    ./den start den-test-no-such-project > /dev/null 2>&1 || true
+if grep -q "${TEST_DIR}" "${LEGACY_HOME}/config/den/config.toml" 2> /dev/null &&
+    [ -f "${LEGACY_HOME}/config/den/config.json.bak" ] &&
+    [ ! -f "${LEGACY_HOME}/config/den/config.json" ]; then
+    echo "config.json was migrated to config.toml"
+else
+    echo "config.json was not migrated to config.toml"
+    rm -rf "${LEGACY_HOME}"
+    exit 1
 fi
+rm -rf "${LEGACY_HOME}"
 
 # Ensure shell integration is installed
 ./den --install
@@ -81,4 +114,4 @@ echo "4. Check if your directory changed to: ${TEST_DIR}"
 # Cleanup
 echo -e "\n${GREEN}Cleaning up...${NC}"
 rm -rf "${TEST_DIR}"
-rm -rf ~/.config/den/config.json 
\ No newline at end of file
+rm -rf "$(dirname "${TEST_CONFIG}")" 
\ No newline at end of file